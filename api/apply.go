@@ -0,0 +1,618 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/utils"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func init() {
+	utils.Parser.AddCommand("apply",
+		"Make harbor match a manifest of projects.",
+		"Create and update the projects described by the YAML manifest given by -f, with their metadata, members and labels, and delete the members and labels not listed in it. The changes made are printed, nothing is changed if harbor matches the manifest already. Run diff first to see what is going to change.",
+		&applyCmd)
+	utils.Parser.AddCommand("diff",
+		"Show what apply would change.",
+		"Show the changes which apply of the YAML manifest given by -f would make, without making them.",
+		&diffCmd)
+}
+
+// A manifest describes the projects as they should be:
+//
+//	projects:
+//	- name: team-a
+//	  public: false
+//	  metadata:
+//	    enable_content_trust: true
+//	    auto_scan: true
+//	  members:
+//	  - user: alice
+//	    role: developer
+//	  labels:
+//	  - name: release
+//	    description: Released images.
+//	    color: "#00FF00"
+//
+// Only what is given is managed: the metadata not listed are kept, so are all
+// the members if members is left out, and all the labels if labels is. Given
+// members (or labels), even empty, are the whole list, the others are deleted
+// except the membership of the current user, and of the groups. Projects left
+// out of the manifest are never deleted.
+type manifest struct {
+	Projects []*manifestProject `yaml:"projects"`
+}
+
+type manifestProject struct {
+	Name     string                 `yaml:"name"`
+	Public   *bool                  `yaml:"public"`
+	Metadata map[string]interface{} `yaml:"metadata"`
+	Members  []*manifestMember      `yaml:"members"`
+	Labels   []*manifestLabel       `yaml:"labels"`
+}
+
+type manifestMember struct {
+	User string `yaml:"user"`
+	Role string `yaml:"role"`
+}
+
+type manifestLabel struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Color       string `yaml:"color"`
+}
+
+// roles maps the role names of manifests to the role IDs.
+var roles = map[string]int{
+	"projectAdmin":  1,
+	"project-admin": 1,
+	"admin":         1,
+	"developer":     2,
+	"guest":         3,
+}
+
+// roleName returns the name of roleID used by harbor.
+func roleName(roleID int) string {
+	switch roleID {
+	case 1:
+		return "projectAdmin"
+	case 2:
+		return "developer"
+	case 3:
+		return "guest"
+	}
+	return strconv.Itoa(roleID)
+}
+
+// loadManifest reads and checks the manifest in file.
+func loadManifest(file string) (*manifest, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var m manifest
+	if err := yaml.UnmarshalStrict(data, &m); err != nil {
+		return nil, utils.Usagef("invalid manifest %s: %v", file, err)
+	}
+
+	projects := map[string]bool{}
+	for _, p := range m.Projects {
+		if p.Name == "" {
+			return nil, utils.Usagef("invalid manifest %s: a project has no name", file)
+		}
+		if projects[p.Name] {
+			return nil, utils.Usagef("invalid manifest %s: project %s is given more than once", file, p.Name)
+		}
+		projects[p.Name] = true
+
+		for k, v := range p.Metadata {
+			if v == nil {
+				return nil, utils.Usagef("invalid manifest %s: metadata %s of project %s has no value", file, k, p.Name)
+			}
+		}
+		if _, ok := p.Metadata["public"]; ok && p.Public != nil {
+			return nil, utils.Usagef("invalid manifest %s: public of project %s is given twice, by public and metadata", file, p.Name)
+		}
+
+		users := map[string]bool{}
+		for _, mb := range p.Members {
+			if mb.User == "" {
+				return nil, utils.Usagef("invalid manifest %s: a member of project %s has no user", file, p.Name)
+			}
+			if _, ok := roles[mb.Role]; !ok {
+				return nil, utils.Usagef("invalid manifest %s: invalid role %q of %s in project %s, valid roles are projectAdmin, developer and guest", file, mb.Role, mb.User, p.Name)
+			}
+			if users[mb.User] {
+				return nil, utils.Usagef("invalid manifest %s: member %s of project %s is given more than once", file, mb.User, p.Name)
+			}
+			users[mb.User] = true
+		}
+
+		labels := map[string]bool{}
+		for _, l := range p.Labels {
+			if l.Name == "" {
+				return nil, utils.Usagef("invalid manifest %s: a label of project %s has no name", file, p.Name)
+			}
+			if labels[l.Name] {
+				return nil, utils.Usagef("invalid manifest %s: label %s of project %s is given more than once", file, l.Name, p.Name)
+			}
+			labels[l.Name] = true
+		}
+	}
+	return &m, nil
+}
+
+// metadata returns the metadata of p as harbor stores them, as strings.
+func (p *manifestProject) metadata() map[string]string {
+	md := map[string]string{}
+	for k, v := range p.Metadata {
+		md[k] = fmt.Sprint(v)
+	}
+	if p.Public != nil {
+		md["public"] = strconv.FormatBool(*p.Public)
+	}
+	return md
+}
+
+// change is a change apply makes to get harbor to match the manifest.
+type change struct {
+	Action  string `json:"action"` // create, update or delete
+	Kind    string `json:"kind"`   // project, metadata, member or label
+	Project string `json:"project"`
+	Name    string `json:"name"`
+	From    string `json:"from,omitempty"`
+	To      string `json:"to,omitempty"`
+
+	ref      *projectRef
+	run      func(c *harbor.Client) error
+	labelID  int // of the label deleted, to be confirmed
+	memberID int // of the member deleted, to be confirmed
+}
+
+// projectRef is the ID of a project, which is known after the creation if it
+// is created by the plan.
+type projectRef struct {
+	name string
+	id   int
+}
+
+// plan returns the changes to make harbor match m, in order.
+func plan(c *harbor.Client, m *manifest) ([]*change, error) {
+	me, err := c.GetCurrentUser()
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []*change{}
+	for _, p := range m.Projects {
+		cs, err := planProject(c, p, me.Username)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, cs...)
+	}
+	return changes, nil
+}
+
+func planProject(c *harbor.Client, p *manifestProject, me string) ([]*change, error) {
+	ref := &projectRef{name: p.Name}
+	id, err := utils.ProjectID(c, 0, p.Name)
+	switch {
+	case err == nil:
+		ref.id = id
+	case errors.Is(err, harbor.ErrNotFound):
+		return planNewProject(p, ref, me), nil
+	default:
+		return nil, err
+	}
+
+	var changes []*change
+	prj, err := c.GetProject(ref.id)
+	if err != nil {
+		return nil, err
+	}
+	md := p.metadata()
+	for _, k := range sortedKeys(md) {
+		old, found := prj.Metadata[k]
+		if found && old == md[k] {
+			continue
+		}
+		changes = append(changes, metadataChange(ref, k, old, md[k], found))
+	}
+
+	if p.Members != nil {
+		cs, err := planMembers(c, p, ref, me)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, cs...)
+	}
+	if p.Labels != nil {
+		cs, err := planLabels(c, p, ref)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, cs...)
+	}
+	return changes, nil
+}
+
+// projectReqMetadata are the metadata set by ProjectReq on creation.
+var projectReqMetadata = map[string]bool{
+	"public":               true,
+	"enable_content_trust": true,
+	"prevent_vul":          true,
+	"severity":             true,
+	"auto_scan":            true,
+}
+
+// planNewProject returns the changes creating p, everything of it is new but
+// the membership of the current user, who becomes its projectAdmin.
+func planNewProject(p *manifestProject, ref *projectRef, me string) []*change {
+	md := p.metadata()
+	public := "private"
+	if md["public"] == "true" {
+		public = "public"
+	}
+	changes := []*change{{
+		Action:  "create",
+		Kind:    "project",
+		Project: p.Name,
+		ref:     ref,
+		Name:    p.Name,
+		To:      public,
+		run: func(c *harbor.Client) error {
+			req := &harbor.ProjectReq{
+				ProjectName:                                p.Name,
+				EnableContentTrust:                         md["enable_content_trust"] == "true",
+				PreventVulnerableImagesFromRunning:         md["prevent_vul"] == "true",
+				PreventVulnerableImagesFromRunningSeverity: md["severity"],
+				AutomaticallyScanImagesOnPush:              md["auto_scan"] == "true",
+			}
+			if md["public"] == "true" {
+				req.Public = 1
+			}
+			if err := c.CreateProject(req); err != nil {
+				return err
+			}
+			id, err := utils.ProjectID(c, 0, p.Name)
+			if err != nil && errors.Is(err, harbor.ErrNotFound) && (utils.Opts.DryRun || utils.Opts.PrintCurl) {
+				fmt.Fprintf(os.Stderr, "Project %s is not created by --dry-run, the changes of it are not shown.\n", p.Name)
+				return nil
+			}
+			ref.id = id
+			return err
+		},
+	}}
+
+	for _, k := range sortedKeys(md) {
+		if projectReqMetadata[k] {
+			// set on creation already
+			changes = append(changes, &change{Action: "create", Kind: "metadata", Project: p.Name, Name: k, To: md[k], ref: ref})
+			continue
+		}
+		changes = append(changes, metadataChange(ref, k, "", md[k], false))
+	}
+	for _, mb := range p.Members {
+		if mb.User == me {
+			if roleID := roles[mb.Role]; roleID != 1 {
+				changes = append(changes, creatorMemberChange(ref, mb.User, roleID))
+			}
+			continue
+		}
+		changes = append(changes, newMemberChange(ref, mb))
+	}
+	for _, l := range p.Labels {
+		changes = append(changes, newLabelChange(ref, l))
+	}
+	return changes
+}
+
+// creatorMemberChange changes the role of the creator of a project created by
+// the plan, whose member ID is known after the creation.
+func creatorMemberChange(ref *projectRef, name string, roleID int) *change {
+	return &change{
+		Action:  "update",
+		Kind:    "member",
+		Project: ref.name,
+		ref:     ref,
+		Name:    name,
+		From:    roleName(1),
+		To:      roleName(roleID),
+		run: func(c *harbor.Client) error {
+			mid, err := utils.MemberID(c, ref.id, 0, name)
+			if err != nil {
+				return err
+			}
+			return c.UpdateProjectMember(ref.id, mid, roleID)
+		},
+	}
+}
+
+// metadataChange sets metadata key of the project to value, it is new unless
+// found.
+func metadataChange(ref *projectRef, key, old, value string, found bool) *change {
+	ch := &change{Action: "update", Kind: "metadata", Project: ref.name, Name: key, From: old, To: value, ref: ref}
+	if !found {
+		ch.Action = "create"
+	}
+	ch.run = func(c *harbor.Client) error {
+		if found {
+			return c.UpdateProjectMetadataByName(ref.id, key, value)
+		}
+		return c.AddProjectMetadata(ref.id, map[string]string{key: value})
+	}
+	return ch
+}
+
+func planMembers(c *harbor.Client, p *manifestProject, ref *projectRef, me string) ([]*change, error) {
+	members, err := c.ListProjectMembers(ref.id, "")
+	if err != nil {
+		return nil, err
+	}
+	current := map[string]*harbor.ProjectMember{}
+	for _, m := range members {
+		if m.EntityType == "u" {
+			current[m.EntityName] = m
+		}
+	}
+
+	var changes []*change
+	listed := map[string]bool{}
+	for _, mb := range p.Members {
+		listed[mb.User] = true
+		m, found := current[mb.User]
+		if !found {
+			changes = append(changes, newMemberChange(ref, mb))
+			continue
+		}
+		roleID := roles[mb.Role]
+		if m.RoleID == roleID {
+			continue
+		}
+		mid := m.ID
+		changes = append(changes, &change{
+			Action:  "update",
+			Kind:    "member",
+			Project: p.Name,
+			ref:     ref,
+			Name:    mb.User,
+			From:    roleName(m.RoleID),
+			To:      roleName(roleID),
+			run: func(c *harbor.Client) error {
+				return c.UpdateProjectMember(ref.id, mid, roleID)
+			},
+		})
+	}
+
+	for _, name := range sortedMemberNames(current) {
+		if listed[name] || name == me {
+			continue
+		}
+		m := current[name]
+		changes = append(changes, &change{
+			Action:  "delete",
+			Kind:    "member",
+			Project: p.Name,
+			ref:     ref,
+			Name:    name,
+			From:    roleName(m.RoleID),
+			run: func(c *harbor.Client) error {
+				return c.DeleteProjectMember(ref.id, m.ID)
+			},
+			memberID: m.ID,
+		})
+	}
+	return changes, nil
+}
+
+func newMemberChange(ref *projectRef, mb *manifestMember) *change {
+	roleID := roles[mb.Role]
+	return &change{
+		Action:  "create",
+		Kind:    "member",
+		Project: ref.name,
+		ref:     ref,
+		Name:    mb.User,
+		To:      roleName(roleID),
+		run: func(c *harbor.Client) error {
+			return c.CreateProjectMember(ref.id, &harbor.ProjectMemberReq{
+				RoleID:     roleID,
+				MemberUser: &harbor.MemberUser{Username: mb.User},
+			})
+		},
+	}
+}
+
+// planLabels compares the project labels, the color of a label is left alone
+// unless it is given.
+func planLabels(c *harbor.Client, p *manifestProject, ref *projectRef) ([]*change, error) {
+	current := map[string]*harbor.Label{}
+	it := c.IterLabels(&harbor.LabelListOptions{Scope: "p", ProjectID: ref.id})
+	for it.Next() {
+		current[it.Label().Name] = it.Label()
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	var changes []*change
+	listed := map[string]bool{}
+	for _, l := range p.Labels {
+		listed[l.Name] = true
+		old, found := current[l.Name]
+		if !found {
+			changes = append(changes, newLabelChange(ref, l))
+			continue
+		}
+		want := *old
+		want.Description = l.Description
+		if l.Color != "" {
+			want.Color = l.Color
+		}
+		if want == *old {
+			continue
+		}
+		changes = append(changes, &change{
+			Action:  "update",
+			Kind:    "label",
+			Project: p.Name,
+			ref:     ref,
+			Name:    l.Name,
+			From:    labelString(old.Description, old.Color),
+			To:      labelString(want.Description, want.Color),
+			run: func(c *harbor.Client) error {
+				return c.UpdateLabel(want.ID, &want)
+			},
+		})
+	}
+
+	var names []string
+	for name := range current {
+		if !listed[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		l := current[name]
+		changes = append(changes, &change{
+			Action:  "delete",
+			Kind:    "label",
+			Project: p.Name,
+			ref:     ref,
+			Name:    name,
+			From:    labelString(l.Description, l.Color),
+			run: func(c *harbor.Client) error {
+				return c.DeleteLabel(l.ID)
+			},
+			labelID: l.ID,
+		})
+	}
+	return changes, nil
+}
+
+func newLabelChange(ref *projectRef, l *manifestLabel) *change {
+	color := l.Color
+	if color == "" {
+		color = "#000000"
+	}
+	return &change{
+		Action:  "create",
+		Kind:    "label",
+		Project: ref.name,
+		ref:     ref,
+		Name:    l.Name,
+		To:      labelString(l.Description, color),
+		run: func(c *harbor.Client) error {
+			return c.CreateLabel(&harbor.Label{
+				Name:        l.Name,
+				Description: l.Description,
+				Color:       color,
+				Scope:       "p",
+				ProjectID:   ref.id,
+			})
+		},
+	}
+}
+
+// labelString describes a label by its description and color.
+func labelString(description, color string) string {
+	return strings.TrimSpace(description + " " + color)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedMemberNames(m map[string]*harbor.ProjectMember) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type applyManifest struct {
+	File string `short:"f" long:"file" description:"(REQUIRED) The YAML manifest of projects." required:"yes"`
+	utils.Confirm
+}
+
+var applyCmd applyManifest
+
+func (x *applyManifest) Execute(args []string) error {
+	m, err := loadManifest(x.File)
+	if err != nil {
+		return err
+	}
+	// the changes applied are printed even if a change fails
+	var applyErr error
+	err = utils.Run(func(c *harbor.Client) (interface{}, error) {
+		changes, err := plan(c, m)
+		if err != nil {
+			return nil, err
+		}
+		// deletions are confirmed before anything is changed
+		for _, ch := range changes {
+			var err error
+			switch {
+			case ch.labelID != 0:
+				err = x.Confirm.Label(c, ch.labelID)
+			case ch.memberID != 0:
+				err = x.Confirm.Member(c, ch.ref.id, ch.memberID)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		applied := []*change{}
+		for _, ch := range changes {
+			if ch.Kind != "project" && ch.ref.id == 0 {
+				// the project is not created by --dry-run
+				continue
+			}
+			if ch.run != nil {
+				if err := ch.run(c); err != nil {
+					applyErr = fmt.Errorf("%s %s %s of project %s: %w", ch.Action, ch.Kind, ch.Name, ch.Project, err)
+					break
+				}
+			}
+			applied = append(applied, ch)
+		}
+		return applied, nil
+	})
+	if err != nil {
+		return err
+	}
+	return applyErr
+}
+
+type diffManifest struct {
+	File string `short:"f" long:"file" description:"(REQUIRED) The YAML manifest of projects." required:"yes"`
+}
+
+var diffCmd diffManifest
+
+func (x *diffManifest) Execute(args []string) error {
+	m, err := loadManifest(x.File)
+	if err != nil {
+		return err
+	}
+	return utils.Run(func(c *harbor.Client) (interface{}, error) {
+		return plan(c, m)
+	})
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/harbortest"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+const testManifest = `
+projects:
+- name: team-a
+  public: true
+  metadata:
+    auto_scan: true
+  members:
+  - user: alice
+    role: developer
+  - user: bob
+    role: developer
+  labels:
+  - name: release
+    description: Released images.
+    color: "#00FF00"
+- name: team-b
+  metadata:
+    enable_content_trust: true
+  members:
+  - user: carol
+    role: guest
+  labels:
+  - name: qa
+`
+
+func TestApplyDiff(t *testing.T) {
+	ct := newCmdTest(t)
+	for _, name := range []string{"alice", "bob", "carol"} {
+		ct.srv.AddUser(name, "Passw0rd", false)
+	}
+	ct.srv.AddProject("team-a", false, harbortest.AdminUsername)
+	ct.srv.AddMember("team-a", "bob", harbortest.RoleGuest)
+	ct.srv.AddMember("team-a", "carol", harbortest.RoleGuest)
+	ct.srv.AddLabel("old", "team-a")
+	ct.srv.AddLabel("stable", "")
+
+	file := filepath.Join(ct.dir, "manifest.yaml")
+	if err := ioutil.WriteFile(file, []byte(testManifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []change{
+		{Action: "create", Kind: "metadata", Project: "team-a", Name: "auto_scan", To: "true"},
+		{Action: "update", Kind: "metadata", Project: "team-a", Name: "public", From: "false", To: "true"},
+		{Action: "create", Kind: "member", Project: "team-a", Name: "alice", To: "developer"},
+		{Action: "update", Kind: "member", Project: "team-a", Name: "bob", From: "guest", To: "developer"},
+		{Action: "delete", Kind: "member", Project: "team-a", Name: "carol", From: "guest"},
+		{Action: "create", Kind: "label", Project: "team-a", Name: "release", To: "Released images. #00FF00"},
+		{Action: "delete", Kind: "label", Project: "team-a", Name: "old", From: "#FFFFFF"},
+		{Action: "create", Kind: "project", Project: "team-b", Name: "team-b", To: "private"},
+		{Action: "create", Kind: "metadata", Project: "team-b", Name: "enable_content_trust", To: "true"},
+		{Action: "create", Kind: "member", Project: "team-b", Name: "carol", To: "guest"},
+		{Action: "create", Kind: "label", Project: "team-b", Name: "qa", To: "#000000"},
+	}
+	var got []change
+	ct.mustRun(&diffCmd, &got, "diff", "-f", file)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diff:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	// nothing is changed by --dry-run, even if the project is not created
+	ct.mustRun(&applyCmd, nil, "--dry-run", "apply", "-f", file)
+	if ct.srv.Project("team-b") != nil || ct.srv.Label("old") == nil {
+		t.Fatal("apply --dry-run changed harbor")
+	}
+
+	// the label deleted has to be confirmed
+	ct.wantErr(utils.ErrAborted, &applyCmd, "apply", "-f", file)
+	if ct.srv.Project("team-b") != nil {
+		t.Fatal("apply changed harbor before the confirmation")
+	}
+
+	got = nil
+	ct.mustRun(&applyCmd, &got, "apply", "--yes", "-f", file)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("apply:\ngot  %+v\nwant %+v", got, want)
+	}
+	a, b := ct.srv.Project("team-a"), ct.srv.Project("team-b")
+	if a.Metadata["public"] != "true" || a.Metadata["auto_scan"] != "true" {
+		t.Errorf("metadata of team-a: %v", a.Metadata)
+	}
+	if b == nil || b.Metadata["public"] != "false" || b.Metadata["enable_content_trust"] != "true" {
+		t.Fatalf("team-b: %+v", b)
+	}
+	roles := map[string]string{}
+	for _, m := range ct.srv.Members("team-a") {
+		roles[m.EntityName] = m.RoleName
+	}
+	if !reflect.DeepEqual(roles, map[string]string{harbortest.AdminUsername: "projectAdmin", "alice": "developer", "bob": "developer"}) {
+		t.Errorf("members of team-a: %v", roles)
+	}
+	if l := ct.srv.Label("qa"); l == nil || l.Scope != "p" || l.ProjectID != b.ProjectID {
+		t.Errorf("label qa: %+v", l)
+	}
+	if ct.srv.Label("old") != nil || ct.srv.Label("stable") == nil {
+		t.Error("apply deleted the wrong labels")
+	}
+
+	// applied already
+	got = nil
+	ct.mustRun(&diffCmd, &got, "diff", "-f", file)
+	if len(got) != 0 {
+		t.Errorf("diff after apply: got %+v", got)
+	}
+	if out := ct.mustRun(&applyCmd, &got, "apply", "-f", file); len(got) != 0 {
+		t.Errorf("apply again: got %s", out)
+	}
+}
+
+func TestApplyPartial(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.AddUser("alice", "Passw0rd", false)
+	ct.srv.AddProject("team-a", false, harbortest.AdminUsername)
+	ct.srv.AddMember("team-a", "alice", harbortest.RoleGuest)
+
+	// the creator of team-b is a projectAdmin of it already, nobody does not
+	// exist
+	file := filepath.Join(ct.dir, "manifest.yaml")
+	if err := ioutil.WriteFile(file, []byte(`
+projects:
+- name: team-a
+  members: []
+- name: team-b
+  members:
+  - user: admin
+    role: projectAdmin
+  - user: alice
+    role: developer
+  - user: nobody
+    role: guest
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the member removed has to be confirmed
+	ct.wantErr(utils.ErrAborted, &applyCmd, "apply", "-f", file)
+	if len(ct.srv.Members("team-a")) != 2 {
+		t.Fatal("apply removed the member before the confirmation")
+	}
+
+	out, err := ct.run(&applyCmd, "apply", "--yes", "-f", file)
+	if !errors.Is(err, harbor.ErrNotFound) || !strings.Contains(err.Error(), "nobody") {
+		t.Fatalf("apply: got error %v", err)
+	}
+	var got []change
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("decode output %q: %v", out, err)
+	}
+	want := []change{
+		{Action: "delete", Kind: "member", Project: "team-a", Name: "alice", From: "guest"},
+		{Action: "create", Kind: "project", Project: "team-b", Name: "team-b", To: "private"},
+		{Action: "create", Kind: "member", Project: "team-b", Name: "alice", To: "developer"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("apply:\ngot  %+v\nwant %+v", got, want)
+	}
+	if n := len(ct.srv.Members("team-b")); n != 2 {
+		t.Errorf("team-b has %d members, want 2", n)
+	}
+}
+
+func TestApplyInvalidManifest(t *testing.T) {
+	ct := newCmdTest(t)
+
+	for _, m := range []string{
+		"projects:\n- public: true\n",
+		"projects:\n- name: a\n- name: a\n",
+		"projects:\n- name: a\n  members:\n  - user: alice\n    role: owner\n",
+		"projects:\n- name: a\n  public: true\n  metadata:\n    public: false\n",
+		"projects:\n- name: a\n  owner: alice\n",
+	} {
+		file := filepath.Join(ct.dir, "manifest.yaml")
+		if err := ioutil.WriteFile(file, []byte(m), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ct.run(&diffCmd, "diff", "-f", file); utils.ExitCode(err) != utils.ExitUsage {
+			t.Errorf("%q: got error %v", m, err)
+		}
+	}
+}
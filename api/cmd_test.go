@@ -0,0 +1,173 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/harbortest"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+// cmdTest runs commands against a harbortest.Server, as the system admin
+// unless as is called.
+type cmdTest struct {
+	t   *testing.T
+	srv *harbortest.Server
+	dir string // where config.yaml and the others are
+	// stderr is what the last command printed into utils.Stderr, e.g. the
+	// requests of --dry-run
+	stderr bytes.Buffer
+}
+
+func newCmdTest(t *testing.T) *cmdTest {
+	t.Helper()
+
+	srv := harbortest.NewServer()
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	t.Setenv(utils.EnvConfig, filepath.Join(dir, "config.yaml"))
+	t.Setenv(utils.EnvSessionStore, utils.SessionStoreFile)
+	t.Setenv(utils.EnvURL, srv.URL)
+	t.Setenv(utils.Parser.FindOptionByLongName("retries").EnvDefaultKey, "0")
+
+	// nothing is confirmed unless a test answers
+	stdin := utils.Stdin
+	utils.Stdin = strings.NewReader("")
+	t.Cleanup(func() { utils.Stdin = stdin })
+
+	ct := &cmdTest{t: t, srv: srv, dir: dir}
+	ct.as(harbortest.AdminUsername, harbortest.AdminPassword)
+	return ct
+}
+
+// as makes the following commands log in as username, or anonymously if
+// username is empty.
+func (ct *cmdTest) as(username, password string) {
+	ct.t.Setenv(utils.EnvUsername, username)
+	ct.t.Setenv(utils.EnvPassword, password)
+}
+
+// run runs a command with args, cmd is the flag struct of the command, which
+// is reset at first since go-flags keeps the values of the last run.
+func (ct *cmdTest) run(cmd interface{}, args ...string) (string, error) {
+	ct.t.Helper()
+
+	resetFlags(reflect.ValueOf(cmd).Elem())
+	resetFlags(reflect.ValueOf(&utils.Opts).Elem())
+
+	var buf bytes.Buffer
+	ct.stderr.Reset()
+	stdout, stderr := utils.Stdout, utils.Stderr
+	utils.Stdout, utils.Stderr = &buf, &ct.stderr
+	defer func() { utils.Stdout, utils.Stderr = stdout, stderr }()
+
+	_, err := utils.ParseArgs(args)
+	return buf.String(), err
+}
+
+// mustRun is the same as run, but fails the test on error, and decodes the
+// JSON output into out unless it is nil.
+func (ct *cmdTest) mustRun(cmd, out interface{}, args ...string) string {
+	ct.t.Helper()
+
+	s, err := ct.run(cmd, args...)
+	if err != nil {
+		ct.t.Fatalf("%v: %v", args, err)
+	}
+	if out != nil {
+		if err := json.Unmarshal([]byte(s), out); err != nil {
+			ct.t.Fatalf("%v: decode output %q: %v", args, s, err)
+		}
+	}
+	return s
+}
+
+// wantErr runs a command which is expected to fail with target.
+func (ct *cmdTest) wantErr(target error, cmd interface{}, args ...string) {
+	ct.t.Helper()
+
+	_, err := ct.run(cmd, args...)
+	if !errors.Is(err, target) {
+		ct.t.Fatalf("%v: got error %v, want %v", args, err, target)
+	}
+}
+
+// resetFlags sets the fields of a flag struct to their defaults. go-flags
+// does not do it for an option once given on the command line.
+func resetFlags(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		f, sf := v.Field(i), v.Type().Field(i)
+		if !f.CanSet() {
+			continue
+		}
+		if sf.Anonymous && f.Kind() == reflect.Struct {
+			resetFlags(f)
+			continue
+		}
+
+		f.Set(reflect.Zero(f.Type()))
+		def, ok := sf.Tag.Lookup("default")
+		if !ok {
+			continue
+		}
+		switch {
+		case f.Type() == reflect.TypeOf(time.Duration(0)):
+			d, _ := time.ParseDuration(def)
+			f.SetInt(int64(d))
+		case f.Kind() == reflect.String:
+			f.SetString(def)
+		case f.Kind() == reflect.Int:
+			n, _ := strconv.Atoi(def)
+			f.SetInt(int64(n))
+		case f.Kind() == reflect.Bool:
+			f.SetBool(def == "true")
+		}
+	}
+}
+
+// TestOutputShorthand makes sure -o after the command name is the global
+// output format, not an option of the command once shortened as -o.
+func TestOutputShorthand(t *testing.T) {
+	ct := newCmdTest(t)
+	id := strconv.Itoa(ct.srv.AddUser("dev", "Dev12345", false).UserID)
+
+	tests := []struct {
+		cmd  interface{}
+		args []string
+	}{
+		{&prjsList, []string{"prjs_list", "-o", "table"}},
+		{&prjLogsGet, []string{"prj_logs_get", "-j", "1", "-o", "table"}},
+		{&logs, []string{"logs", "-o", "table"}},
+		{&usrUpdatePassword, []string{"user_update_password", "-i", id, "--old_password", "Dev12345", "-n", "New12345", "-o", "table"}},
+	}
+	for _, tt := range tests {
+		ct.mustRun(tt.cmd, nil, tt.args...)
+		if utils.Opts.Output != "table" {
+			t.Errorf("%v: got output %q, want table", tt.args, utils.Opts.Output)
+		}
+	}
+	if prjsList.Owner != "" || prjLogsGet.Operation != "" || logs.Operation != "" {
+		t.Errorf("-o is taken as an option of the command: owner %q, operations %q and %q",
+			prjsList.Owner, prjLogsGet.Operation, logs.Operation)
+	}
+
+	// other values still go to the options of the commands
+	var prjs []*harbor.Project
+	ct.mustRun(&prjsList, &prjs, "prjs_list", "-o", "admin", "-ojson")
+	if prjsList.Owner != "admin" || utils.Opts.Output != "json" || len(prjs) != 1 {
+		t.Errorf("prjs_list -o admin: got owner %q, output %q, %d projects", prjsList.Owner, utils.Opts.Output, len(prjs))
+	}
+	ct.mustRun(&logs, nil, "logs", "-o", "pull", "-o", "jsonpath={[*].operation}")
+	if logs.Operation != "pull" || utils.Opts.Output != "jsonpath={[*].operation}" {
+		t.Errorf("logs -o pull: got operation %q, output %q", logs.Operation, utils.Opts.Output)
+	}
+}
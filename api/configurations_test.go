@@ -1 +1,48 @@
 package api
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+func TestConfigurations(t *testing.T) {
+	ct := newCmdTest(t)
+
+	var cfg map[string]*harbor.ConfigItem
+	ct.mustRun(&scGet, &cfg, "configurations_get")
+	if cfg["auth_mode"] == nil || cfg["auth_mode"].Value != "db_auth" {
+		t.Fatalf("configurations_get: got %v", cfg)
+	}
+
+	// configurations_create sends the settings of config.yaml
+	yml := "auth_mode: ldap_auth\nemail_port: 465\nself_registration: false\n"
+	if err := ioutil.WriteFile(filepath.Join(ct.dir, "config.yaml"), []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ct.mustRun(&scCreate, nil, "configurations_create")
+	cfg = nil
+	ct.mustRun(&scGet, &cfg, "configurations_get")
+	if cfg["auth_mode"].Value != "ldap_auth" || cfg["email_port"].Value != float64(465) {
+		t.Errorf("configurations_get after configurations_create: auth_mode %v, email_port %v",
+			cfg["auth_mode"].Value, cfg["email_port"].Value)
+	}
+
+	ct.mustRun(&scReset, nil, "configurations_reset")
+	cfg = nil
+	ct.mustRun(&scGet, &cfg, "configurations_get")
+	if cfg["auth_mode"].Value != "db_auth" {
+		t.Errorf("configurations_get after configurations_reset: got %v", cfg["auth_mode"].Value)
+	}
+}
+
+func TestConfigurationsForbidden(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.AddUser("dev", "Dev12345", false)
+
+	ct.as("dev", "Dev12345")
+	ct.wantErr(harbor.ErrForbidden, &scGet, "configurations_get")
+	ct.wantErr(harbor.ErrForbidden, &scReset, "configurations_reset")
+}
@@ -0,0 +1,486 @@
+package api
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func init() {
+	utils.Parser.AddCommand("export",
+		"Export the configuration of harbor, for backup.",
+		"Export projects with their metadata, members and labels, global labels, users (without passwords), user groups, replication targets and policies, and system configurations into a directory, or an archive if path ends with .tar.gz or .tgz. Secrets are redacted, or encrypted by $HARBOR_EXPORT_PASSPHRASE with --encrypt. Images are not exported.",
+		&exportCmd)
+	utils.Parser.AddCommand("import",
+		"Import the configuration exported by export.",
+		"Recreate what export has exported into path on this harbor, remapping the IDs. What exists already, matched by name, is left alone, and the rest refers to it.",
+		&importCmd)
+}
+
+// exportVersion is the version of the export format, import refuses the
+// newer ones.
+const exportVersion = 1
+
+// The files of an export, one per kind.
+const (
+	exportInfoFile     = "export.json"
+	projectsFile       = "projects.json"
+	labelsFile         = "labels.json"
+	usersFile          = "users.json"
+	userGroupsFile     = "usergroups.json"
+	targetsFile        = "targets.json"
+	policiesFile       = "policies.json"
+	configurationsFile = "configurations.json"
+)
+
+// Secrets are kept in an export in either way.
+const (
+	secretsRedacted  = "redacted"
+	secretsEncrypted = "encrypted"
+
+	redacted        = "REDACTED"
+	encryptedPrefix = "encrypted:"
+)
+
+// exportInfo describes an export, it is export.json.
+type exportInfo struct {
+	Version       int    `json:"version"`
+	HarborVersion string `json:"harbor_version,omitempty"`
+	ExportedAt    string `json:"exported_at"`
+	Secrets       string `json:"secrets"`        // redacted or encrypted
+	Salt          string `json:"salt,omitempty"` // of the key encrypting the secrets
+	// Skipped are the kinds not supported by the harbor exported, which are
+	// left empty.
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// exportedProject is a project with its metadata, members and labels. The IDs
+// are the ones of the harbor exported, which import remaps.
+type exportedProject struct {
+	ID       int                     `json:"id"`
+	Name     string                  `json:"name"`
+	Metadata map[string]string       `json:"metadata"`
+	Members  []*harbor.ProjectMember `json:"members"`
+	Labels   []*harbor.Label         `json:"labels"`
+}
+
+// export is the logical state of a harbor.
+type export struct {
+	Info           exportInfo
+	Projects       []*exportedProject
+	Labels         []*harbor.Label // global
+	Users          []*harbor.User
+	UserGroups     []*harbor.UserGroup
+	Targets        []*harbor.Target
+	Policies       []*harbor.ReplicationPolicy
+	Configurations map[string]*harbor.ConfigItem
+}
+
+// files returns the files of e, by name.
+func (e *export) files() (map[string][]byte, error) {
+	files := map[string][]byte{}
+	for name, v := range map[string]interface{}{
+		exportInfoFile:     &e.Info,
+		projectsFile:       e.Projects,
+		labelsFile:         e.Labels,
+		usersFile:          e.Users,
+		userGroupsFile:     e.UserGroups,
+		targetsFile:        e.Targets,
+		policiesFile:       e.Policies,
+		configurationsFile: e.Configurations,
+	} {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		files[name] = append(data, '\n')
+	}
+	return files, nil
+}
+
+// loadExport reads the export at path, which is checked to be of a known
+// version.
+func loadExport(path string) (*export, error) {
+	files, err := readExportFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := files[exportInfoFile]
+	if !ok {
+		return nil, utils.Usagef("%s is not an export, %s is missing", path, exportInfoFile)
+	}
+
+	var e export
+	if err := json.Unmarshal(data, &e.Info); err != nil {
+		return nil, fmt.Errorf("%s of %s: %v", exportInfoFile, path, err)
+	}
+	if e.Info.Version < 1 || e.Info.Version > exportVersion {
+		return nil, utils.Usagef("%s is of export version %d, only up to %d is supported", path, e.Info.Version, exportVersion)
+	}
+	for name, v := range map[string]interface{}{
+		projectsFile:       &e.Projects,
+		labelsFile:         &e.Labels,
+		usersFile:          &e.Users,
+		userGroupsFile:     &e.UserGroups,
+		targetsFile:        &e.Targets,
+		policiesFile:       &e.Policies,
+		configurationsFile: &e.Configurations,
+	} {
+		data, ok := files[name]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(data, v); err != nil {
+			return nil, fmt.Errorf("%s of %s: %v", name, path, err)
+		}
+	}
+	return &e, nil
+}
+
+// isArchive tells whether path is a .tar.gz archive rather than a directory.
+func isArchive(path string) bool {
+	return strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz")
+}
+
+// writeExportFiles writes files into the directory or the archive at path.
+func writeExportFiles(path string, files map[string][]byte) error {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if !isArchive(path) {
+		if err := os.MkdirAll(path, 0700); err != nil {
+			return err
+		}
+		for _, name := range names {
+			if err := ioutil.WriteFile(filepath.Join(path, name), files[name], 0600); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	now := time.Now()
+	for _, name := range names {
+		hdr := &tar.Header{Name: name, Mode: 0600, Size: int64(len(files[name])), ModTime: now}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0600)
+}
+
+// readExportFiles reads the files of the directory or the archive at path.
+func readExportFiles(path string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	if !isArchive(path) {
+		entries, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, fi := range entries {
+			if fi.IsDir() || !strings.HasSuffix(fi.Name(), ".json") {
+				continue
+			}
+			data, err := ioutil.ReadFile(filepath.Join(path, fi.Name()))
+			if err != nil {
+				return nil, err
+			}
+			files[fi.Name()] = data
+		}
+		return files, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		files[filepath.Base(hdr.Name)] = data
+	}
+	return files, nil
+}
+
+// secretBox redacts, encrypts and decrypts the secrets of an export. Every
+// secret is encrypted by AES-GCM with a nonce of its own, under the key
+// derived from the passphrase and the salt of the export.
+type secretBox struct {
+	aead cipher.AEAD // nil if the secrets are redacted
+}
+
+func newSecretBox(passphrase string, salt []byte) (*secretBox, error) {
+	aead, err := utils.PassphraseGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	return &secretBox{aead: aead}, nil
+}
+
+// seal returns secret redacted or encrypted, empty is kept as it is.
+func (b *secretBox) seal(secret string) (string, error) {
+	if secret == "" {
+		return "", nil
+	}
+	if b.aead == nil {
+		return redacted, nil
+	}
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(b.aead.Seal(nonce, nonce, []byte(secret), nil)), nil
+}
+
+// open returns the secret sealed, ok is false if it is redacted.
+func (b *secretBox) open(sealed string) (secret string, ok bool, err error) {
+	if sealed == redacted {
+		return "", false, nil
+	}
+	if !strings.HasPrefix(sealed, encryptedPrefix) {
+		return sealed, true, nil
+	}
+	if b.aead == nil {
+		return "", false, fmt.Errorf("$%s is required by the encrypted secrets", utils.EnvExportPassphrase)
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(sealed, encryptedPrefix))
+	if err != nil || len(data) < b.aead.NonceSize() {
+		return "", false, fmt.Errorf("corrupted secret %q", sealed)
+	}
+	n := b.aead.NonceSize()
+	plain, err := b.aead.Open(nil, data[:n], data[n:], nil)
+	if err != nil {
+		return "", false, fmt.Errorf("can not decrypt the secrets, wrong $%s?", utils.EnvExportPassphrase)
+	}
+	return string(plain), true, nil
+}
+
+// isSecretConfig tells whether the configuration key holds a secret.
+func isSecretConfig(key string) bool {
+	return strings.Contains(key, "password") || strings.Contains(key, "secret")
+}
+
+type exportHarbor struct {
+	Encrypt bool `long:"encrypt" description:"Encrypt the secrets by $HARBOR_EXPORT_PASSPHRASE, instead of redacting them."`
+	Args    struct {
+		Path string `positional-arg-name:"path" description:"The directory to export into, or the archive if it ends with .tar.gz or .tgz."`
+	} `positional-args:"yes" required:"yes"`
+}
+
+var exportCmd exportHarbor
+
+// exportSummary is printed by export.
+type exportSummary struct {
+	Path       string   `json:"path"`
+	Version    int      `json:"version"`
+	Secrets    string   `json:"secrets"`
+	Projects   int      `json:"projects"`
+	Labels     int      `json:"labels"`
+	Users      int      `json:"users"`
+	UserGroups int      `json:"usergroups"`
+	Targets    int      `json:"targets"`
+	Policies   int      `json:"policies"`
+	Skipped    []string `json:"skipped,omitempty"`
+}
+
+func (x *exportHarbor) Execute(args []string) error {
+	e := &export{Info: exportInfo{Version: exportVersion, Secrets: secretsRedacted}}
+	box := &secretBox{}
+	if x.Encrypt {
+		passphrase := os.Getenv(utils.EnvExportPassphrase)
+		if passphrase == "" {
+			return utils.Usagef("$%s is required by --encrypt", utils.EnvExportPassphrase)
+		}
+		salt := make([]byte, 16)
+		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+			return err
+		}
+		var err error
+		if box, err = newSecretBox(passphrase, salt); err != nil {
+			return err
+		}
+		e.Info.Secrets, e.Info.Salt = secretsEncrypted, base64.StdEncoding.EncodeToString(salt)
+	}
+
+	return utils.Run(func(c *harbor.Client) (interface{}, error) {
+		if err := exportHarborState(c, e, box); err != nil {
+			return nil, err
+		}
+		files, err := e.files()
+		if err != nil {
+			return nil, err
+		}
+		if err := writeExportFiles(x.Args.Path, files); err != nil {
+			return nil, err
+		}
+		return &exportSummary{
+			Path:       x.Args.Path,
+			Version:    e.Info.Version,
+			Secrets:    e.Info.Secrets,
+			Projects:   len(e.Projects),
+			Labels:     len(e.Labels),
+			Users:      len(e.Users),
+			UserGroups: len(e.UserGroups),
+			Targets:    len(e.Targets),
+			Policies:   len(e.Policies),
+			Skipped:    e.Info.Skipped,
+		}, nil
+	})
+}
+
+// exportHarborState reads the state of harbor into e.
+func exportHarborState(c *harbor.Client, e *export, box *secretBox) error {
+	info, err := c.GetSystemInfo()
+	if err != nil {
+		return err
+	}
+	e.Info.HarborVersion = info.HarborVersion
+	e.Info.ExportedAt = time.Now().UTC().Format(time.RFC3339)
+
+	e.Projects = []*exportedProject{}
+	it := c.IterProjects(nil)
+	for it.Next() {
+		p := it.Project()
+		prj := &exportedProject{ID: p.ProjectID, Name: p.Name, Metadata: p.Metadata}
+		if prj.Members, err = c.ListProjectMembers(p.ProjectID, ""); err != nil {
+			return err
+		}
+		if prj.Labels, err = listLabels(c, &harbor.LabelListOptions{Scope: "p", ProjectID: p.ProjectID}); err != nil {
+			return err
+		}
+		e.Projects = append(e.Projects, prj)
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+
+	if e.Labels, err = listLabels(c, &harbor.LabelListOptions{Scope: "g"}); err != nil {
+		return err
+	}
+
+	e.Users = []*harbor.User{}
+	users := c.IterUsers(nil)
+	for users.Next() {
+		u := users.User()
+		u.Password, u.Salt, u.ResetUUID = "", "", ""
+		e.Users = append(e.Users, u)
+	}
+	if err := users.Err(); err != nil {
+		return err
+	}
+
+	e.UserGroups = []*harbor.UserGroup{}
+	groups, err := c.ListUserGroups()
+	if err := e.skip("usergroups", err); err != nil {
+		return err
+	}
+	e.UserGroups = append(e.UserGroups, groups...)
+
+	e.Targets = []*harbor.Target{}
+	targets, err := c.ListTargets("")
+	if err := e.skip("targets", err); err != nil {
+		return err
+	}
+	for _, t := range targets {
+		if t.Password, err = box.seal(t.Password); err != nil {
+			return err
+		}
+	}
+	e.Targets = append(e.Targets, targets...)
+
+	e.Policies = []*harbor.ReplicationPolicy{}
+	for page := 1; ; page++ {
+		policies, err := c.ListReplicationPolicies(&harbor.ReplicationPolicyListOptions{Page: page, PageSize: harbor.MaxPageSize})
+		if err != nil {
+			if err := e.skip("policies", err); err != nil {
+				return err
+			}
+			break
+		}
+		e.Policies = append(e.Policies, policies...)
+		if len(policies) < harbor.MaxPageSize {
+			break
+		}
+	}
+
+	if e.Configurations, err = c.GetConfigurations(); err != nil {
+		return err
+	}
+	for k, item := range e.Configurations {
+		if s, ok := item.Value.(string); ok && isSecretConfig(k) {
+			if item.Value, err = box.seal(s); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// skip records kind as skipped if err tells it is not supported by the
+// harbor version, other errors are returned.
+func (e *export) skip(kind string, err error) error {
+	if errors.Is(err, harbor.ErrUnsupported) {
+		e.Info.Skipped = append(e.Info.Skipped, kind)
+		return nil
+	}
+	return err
+}
+
+// listLabels returns all the labels of opt.
+func listLabels(c *harbor.Client, opt *harbor.LabelListOptions) ([]*harbor.Label, error) {
+	labels := []*harbor.Label{}
+	it := c.IterLabels(opt)
+	for it.Next() {
+		labels = append(labels, it.Label())
+	}
+	return labels, it.Err()
+}
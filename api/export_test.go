@@ -0,0 +1,208 @@
+package api
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/harbortest"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func TestExportImport(t *testing.T) {
+	for _, path := range []string{"backup", "backup.tar.gz"} {
+		t.Run(path, func(t *testing.T) {
+			testExportImport(t, path)
+		})
+	}
+}
+
+func testExportImport(t *testing.T, path string) {
+	src := newCmdTest(t)
+	src.srv.AddUser("dev", "Dev12345", false)
+	src.srv.AddUser("ops", "Ops12345", true)
+	prj := src.srv.AddProject("prj", true, harbortest.AdminUsername)
+	src.srv.AddMember("prj", "dev", harbortest.RoleDeveloper)
+	src.srv.AddLabel("qa", "prj")
+	stable := src.srv.AddLabel("stable", "")
+	src.srv.AddTarget("remote", "https://remote.mydomain.com")
+	policy := src.srv.AddPolicy("to-remote", "prj", "remote")
+	src.mustRun(&ugCreate, nil, "usergroup_create", "-n", "devs", "-t", "1", "-l", "cn=devs,dc=mydomain,dc=com")
+	src.mustRun(&apiCall, nil, "api", "PUT", "/api/policies/replication/"+strconv.Itoa(policy.ID), "--input", writeJSON(t, src.dir, `{
+		"name": "to-remote",
+		"projects": [{"project_id": `+strconv.Itoa(prj.ProjectID)+`, "name": "prj"}],
+		"targets": [{"id": `+strconv.Itoa(policy.Targets[0].ID)+`}],
+		"trigger": {"kind": "Manual"},
+		"filters": [{"kind": "label", "value": `+strconv.Itoa(stable.ID)+`}]
+	}`))
+	src.mustRun(&apiCall, nil, "api", "PUT", "/api/configurations", "-f", "email_password=mail-secret", "-f", "email_host=smtp.example.com")
+
+	out := filepath.Join(src.dir, path)
+	t.Setenv(utils.EnvExportPassphrase, "")
+	if _, err := src.run(&exportCmd, "export", "--encrypt", out); utils.ExitCode(err) != utils.ExitUsage {
+		t.Fatalf("export --encrypt without the passphrase: got error %v", err)
+	}
+	t.Setenv(utils.EnvExportPassphrase, "s3cret")
+	var summary exportSummary
+	src.mustRun(&exportCmd, &summary, "export", "--encrypt", out)
+	if summary.Projects != 2 || summary.Users != 3 || summary.Policies != 1 || summary.Secrets != "encrypted" {
+		t.Errorf("export: got %+v", summary)
+	}
+	if path == "backup" {
+		data, err := ioutil.ReadFile(filepath.Join(out, configurationsFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), "mail-secret") || !strings.Contains(string(data), encryptedPrefix) {
+			t.Errorf("the secret is not encrypted:\n%s", data)
+		}
+	}
+
+	dst := newCmdTest(t)
+	// take the IDs, so that the new ones differ
+	dst.srv.AddProject("other", false, harbortest.AdminUsername)
+	dst.srv.AddTarget("other", "https://other.mydomain.com")
+	dst.srv.AddLabel("other", "")
+	t.Setenv(utils.EnvExportPassphrase, "wrong")
+	if _, err := dst.run(&importCmd, "import", out); err == nil || !strings.Contains(err.Error(), "wrong") {
+		t.Fatalf("import with a wrong passphrase: got error %v", err)
+	}
+	t.Setenv(utils.EnvExportPassphrase, "s3cret")
+
+	dst.mustRun(&importCmd, nil, "--dry-run", "import", out)
+	if dst.srv.Project("prj") != nil || dst.srv.User("dev") != nil {
+		t.Fatal("import --dry-run changed harbor")
+	}
+
+	var results []*imported
+	dst.mustRun(&importCmd, &results, "import", out)
+	got := map[string]*imported{}
+	for _, r := range results {
+		got[r.Kind+" "+r.Name] = r
+	}
+	if r := got["project library"]; r == nil || r.Action != "exists" {
+		t.Errorf("library: got %+v", r)
+	}
+	if r := got["user dev"]; r == nil || r.Action != "created" || r.Password == "" {
+		t.Errorf("user dev: got %+v", r)
+	}
+	if r := got["member prj/dev"]; r == nil || r.Action != "created" {
+		t.Errorf("member prj/dev: got %+v", r)
+	}
+
+	p := dst.srv.Project("prj")
+	if p == nil || p.Metadata["public"] != "true" {
+		t.Fatalf("project prj: got %+v", p)
+	}
+	if p.ProjectID == prj.ProjectID {
+		t.Fatal("the IDs are the same, the remapping is not tested")
+	}
+	if u := dst.srv.User("ops"); u == nil || !u.HasAdminRole {
+		t.Errorf("user ops: got %+v", u)
+	}
+	members := map[string]string{}
+	for _, m := range dst.srv.Members("prj") {
+		members[m.EntityName] = m.RoleName
+	}
+	if members["dev"] != "developer" {
+		t.Errorf("members of prj: %v", members)
+	}
+	if l := dst.srv.Label("qa"); l == nil || l.ProjectID != p.ProjectID {
+		t.Errorf("label qa: got %+v", l)
+	}
+
+	var ps []*harbor.ReplicationPolicy
+	dst.mustRun(&apiCall, &ps, "api", "GET", "/api/policies/replication", "-f", "name=to-remote")
+	if len(ps) != 1 {
+		t.Fatalf("policies: got %+v", ps)
+	}
+	newStable, remote := dst.srv.Label("stable"), dst.srv.Target("remote")
+	if ps[0].Projects[0].ProjectID != p.ProjectID || ps[0].Targets[0].ID != remote.ID ||
+		ps[0].Filters[0].Value != float64(newStable.ID) || ps[0].ReplicateExistingImageNow {
+		t.Errorf("policy is not remapped: %+v %+v %+v", ps[0].Projects[0], ps[0].Targets[0], ps[0].Filters[0])
+	}
+
+	var cfg map[string]*harbor.ConfigItem
+	dst.mustRun(&apiCall, &cfg, "api", "GET", "/api/configurations")
+	if cfg["email_password"] == nil || cfg["email_password"].Value != "mail-secret" || cfg["email_host"].Value != "smtp.example.com" {
+		t.Errorf("configurations: got %v %v", cfg["email_password"], cfg["email_host"])
+	}
+
+	// imported already, nothing is created again
+	results = nil
+	dst.mustRun(&importCmd, &results, "import", out)
+	for _, r := range results {
+		if r.Action == "created" {
+			t.Errorf("imported again: %+v", r)
+		}
+	}
+}
+
+func TestExportRedacted(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.mustRun(&apiCall, nil, "api", "PUT", "/api/configurations", "-f", "ldap_search_password=ldap-secret")
+
+	out := filepath.Join(ct.dir, "backup")
+	ct.mustRun(&exportCmd, nil, "export", out)
+	data, err := ioutil.ReadFile(filepath.Join(out, configurationsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "ldap-secret") || !strings.Contains(string(data), redacted) {
+		t.Errorf("the secret is not redacted:\n%s", data)
+	}
+
+	// the newer versions are refused
+	info := filepath.Join(out, exportInfoFile)
+	if err := ioutil.WriteFile(info, []byte(`{"version": 2}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ct.run(&importCmd, "import", out); utils.ExitCode(err) != utils.ExitUsage {
+		t.Errorf("import of version 2: got error %v", err)
+	}
+}
+
+func TestExportImportUnsupported(t *testing.T) {
+	src := newCmdTest(t)
+	src.srv.AddTarget("remote", "https://remote.mydomain.com")
+	src.srv.AddPolicy("to-remote", "library", "remote")
+	out := filepath.Join(src.dir, "backup")
+	src.mustRun(&exportCmd, nil, "export", out)
+
+	// Harbor 1.8 replaced the replication targets and policies
+	dst := newCmdTest(t)
+	dst.srv.HarborVersion = "v1.8.0-3f8b2c1d"
+	var results []*imported
+	dst.mustRun(&importCmd, &results, "import", out)
+	got := map[string]*imported{}
+	for _, r := range results {
+		got[r.Kind+" "+r.Name] = r
+	}
+	for _, k := range []string{"target remote", "policy to-remote"} {
+		if r := got[k]; r == nil || r.Action != "skipped" || !strings.Contains(r.Note, "not supported") {
+			t.Errorf("%s: got %+v", k, r)
+		}
+	}
+	if dst.srv.Target("remote") != nil {
+		t.Error("the target is imported")
+	}
+
+	var summary exportSummary
+	dst.mustRun(&exportCmd, &summary, "export", filepath.Join(dst.dir, "backup"))
+	if strings.Join(summary.Skipped, ",") != "targets,policies" || summary.Projects != 1 {
+		t.Errorf("export: got %+v", summary)
+	}
+}
+
+// writeJSON writes data into a file of dir and returns its path.
+func writeJSON(t *testing.T, dir, data string) string {
+	t.Helper()
+	file := filepath.Join(dir, "body.json")
+	if err := ioutil.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
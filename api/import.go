@@ -0,0 +1,561 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+type importHarbor struct {
+	UserPassword string `long:"user-password" description:"The initial password of the users created, a random one is generated for each of them and printed by default."`
+	Args         struct {
+		Path string `positional-arg-name:"path" description:"The directory or the archive (.tar.gz or .tgz) written by export."`
+	} `positional-args:"yes" required:"yes"`
+}
+
+var importCmd importHarbor
+
+// imported is what import has done to something of the export.
+type imported struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Action   string `json:"action"` // created, updated, exists or skipped
+	OldID    int    `json:"old_id,omitempty"`
+	NewID    int    `json:"new_id,omitempty"`
+	Password string `json:"password,omitempty"` // the random one of the user created
+	Note     string `json:"note,omitempty"`
+}
+
+func (x *importHarbor) Execute(args []string) error {
+	e, err := loadExport(x.Args.Path)
+	if err != nil {
+		return err
+	}
+	box := &secretBox{}
+	if e.Info.Secrets == secretsEncrypted {
+		passphrase := os.Getenv(utils.EnvExportPassphrase)
+		if passphrase == "" {
+			return utils.Usagef("$%s is required by the encrypted secrets of %s", utils.EnvExportPassphrase, x.Args.Path)
+		}
+		salt, err := base64.StdEncoding.DecodeString(e.Info.Salt)
+		if err != nil {
+			return fmt.Errorf("%s of %s: invalid salt", exportInfoFile, x.Args.Path)
+		}
+		if box, err = newSecretBox(passphrase, salt); err != nil {
+			return err
+		}
+	}
+
+	return utils.Run(func(c *harbor.Client) (interface{}, error) {
+		me, err := c.GetCurrentUser()
+		if err != nil {
+			return nil, err
+		}
+		im := &importer{
+			c:        c,
+			box:      box,
+			password: x.UserPassword,
+			me:       me.Username,
+			results:  []*imported{},
+			projects: map[int]int{},
+			labels:   map[int]int{},
+			groups:   map[int]int{},
+			targets:  map[int]int{},
+		}
+		for _, step := range []func(*export) error{
+			im.configurations,
+			im.users,
+			im.userGroups,
+			im.globalLabels,
+			im.projectsOf,
+			im.targetsOf,
+			im.policies,
+		} {
+			if err := step(e); err != nil {
+				return im.results, err
+			}
+		}
+		return im.results, nil
+	})
+}
+
+// importer imports an export, mapping the IDs of the harbor exported to the
+// ones of this harbor.
+type importer struct {
+	c        *harbor.Client
+	box      *secretBox
+	password string // of the users created, random if empty
+	me       string // the current user, who is a member of the projects created already
+
+	results []*imported
+
+	projects map[int]int
+	labels   map[int]int
+	groups   map[int]int
+	targets  map[int]int
+}
+
+func (im *importer) report(kind, name, action string, oldID, newID int, note string) *imported {
+	r := &imported{Kind: kind, Name: name, Action: action, OldID: oldID, NewID: newID, Note: note}
+	im.results = append(im.results, r)
+	return r
+}
+
+// unsupported reports something skipped if err tells its kind is not
+// supported by the harbor version.
+func (im *importer) unsupported(kind, name string, oldID int, err error) bool {
+	if !errors.Is(err, harbor.ErrUnsupported) {
+		return false
+	}
+	im.report(kind, name, "skipped", oldID, 0, err.Error())
+	return true
+}
+
+// newID returns the ID of something just created, looked up by its name. It
+// is 0 by --dry-run, which creates nothing.
+func newID(id int, err error) (int, error) {
+	if errors.Is(err, harbor.ErrNotFound) && (utils.Opts.DryRun || utils.Opts.PrintCurl) {
+		return 0, nil
+	}
+	return id, err
+}
+
+// configurations updates the editable configurations, but the redacted
+// secrets.
+func (im *importer) configurations(e *export) error {
+	if len(e.Configurations) == 0 {
+		return nil
+	}
+	values := map[string]interface{}{}
+	note := ""
+	for _, k := range sortedConfigKeys(e.Configurations) {
+		item := e.Configurations[k]
+		if !item.Editable {
+			continue
+		}
+		if s, ok := item.Value.(string); ok && isSecretConfig(k) {
+			secret, ok, err := im.box.open(s)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				note = "the redacted secrets are left alone"
+				continue
+			}
+			item.Value = secret
+		}
+		values[k] = item.Value
+	}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	var cfg harbor.Configurations
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return err
+	}
+	if err := im.c.UpdateConfigurations(&cfg); err != nil {
+		return fmt.Errorf("import configurations: %w", err)
+	}
+	im.report("configurations", "", "updated", 0, 0, note)
+	return nil
+}
+
+// users creates the users, unless they come from LDAP.
+func (im *importer) users(e *export) error {
+	if item := e.Configurations["auth_mode"]; item != nil && item.Value != "db_auth" {
+		for _, u := range e.Users {
+			im.report("user", u.Username, "skipped", u.UserID, 0, fmt.Sprintf("users come from %v", item.Value))
+		}
+		return nil
+	}
+
+	for _, u := range e.Users {
+		id, err := utils.UserID(im.c, 0, u.Username)
+		if err == nil {
+			im.report("user", u.Username, "exists", u.UserID, id, "")
+			continue
+		}
+		if !errors.Is(err, harbor.ErrNotFound) {
+			return err
+		}
+
+		password, generated := im.password, ""
+		if password == "" {
+			if password, err = randomPassword(); err != nil {
+				return err
+			}
+			generated = password
+		}
+		err = im.c.CreateUser(&harbor.User{
+			Username: u.Username,
+			Email:    u.Email,
+			Realname: u.Realname,
+			Comment:  u.Comment,
+			Password: password,
+		})
+		if err != nil {
+			return fmt.Errorf("import user %s: %w", u.Username, err)
+		}
+		if id, err = newID(utils.UserID(im.c, 0, u.Username)); err != nil {
+			return err
+		}
+		if u.HasAdminRole && id != 0 {
+			if err := im.c.UpdateUserRole(id, true); err != nil {
+				return fmt.Errorf("import user %s: %w", u.Username, err)
+			}
+		}
+		im.report("user", u.Username, "created", u.UserID, id, "").Password = generated
+	}
+	return nil
+}
+
+// randomPassword returns a password satisfying harbor, which asks for 8 to 20
+// characters with at least an uppercase letter, a lowercase letter and a
+// digit.
+func randomPassword() (string, error) {
+	b := make([]byte, 12)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b) + "Aa1", nil
+}
+
+func (im *importer) userGroups(e *export) error {
+	groupID := func(name string) (int, error) {
+		groups, err := im.c.ListUserGroups()
+		if err != nil {
+			return 0, err
+		}
+		for _, g := range groups {
+			if g.GroupName == name {
+				return g.ID, nil
+			}
+		}
+		return 0, fmt.Errorf("user group %q: %w", name, harbor.ErrNotFound)
+	}
+
+	for _, g := range e.UserGroups {
+		id, err := groupID(g.GroupName)
+		if err == nil {
+			im.groups[g.ID] = id
+			im.report("usergroup", g.GroupName, "exists", g.ID, id, "")
+			continue
+		}
+		if im.unsupported("usergroup", g.GroupName, g.ID, err) {
+			continue
+		}
+		if !errors.Is(err, harbor.ErrNotFound) {
+			return err
+		}
+
+		err = im.c.CreateUserGroup(&harbor.UserGroup{GroupName: g.GroupName, GroupType: g.GroupType, LdapGroupDN: g.LdapGroupDN})
+		if err != nil {
+			return fmt.Errorf("import user group %s: %w", g.GroupName, err)
+		}
+		if id, err = newID(groupID(g.GroupName)); err != nil {
+			return err
+		}
+		im.groups[g.ID] = id
+		im.report("usergroup", g.GroupName, "created", g.ID, id, "")
+	}
+	return nil
+}
+
+func (im *importer) globalLabels(e *export) error {
+	return im.labelsOf(e.Labels, "", 0)
+}
+
+// labelsOf imports the labels of the project projectID, or the global labels
+// if projectID is 0.
+func (im *importer) labelsOf(labels []*harbor.Label, project string, projectID int) error {
+	opt := &harbor.LabelListOptions{Scope: "g"}
+	if projectID != 0 {
+		opt = &harbor.LabelListOptions{Scope: "p", ProjectID: projectID}
+	}
+	labelID := func(name string) (int, error) {
+		existing, err := listLabels(im.c, opt)
+		if err != nil {
+			return 0, err
+		}
+		for _, l := range existing {
+			if l.Name == name {
+				return l.ID, nil
+			}
+		}
+		return 0, fmt.Errorf("label %q: %w", name, harbor.ErrNotFound)
+	}
+
+	name := func(l *harbor.Label) string {
+		if project == "" {
+			return l.Name
+		}
+		return project + "/" + l.Name
+	}
+	for _, l := range labels {
+		id, err := labelID(l.Name)
+		if err == nil {
+			im.labels[l.ID] = id
+			im.report("label", name(l), "exists", l.ID, id, "")
+			continue
+		}
+		if !errors.Is(err, harbor.ErrNotFound) {
+			return err
+		}
+
+		err = im.c.CreateLabel(&harbor.Label{
+			Name:        l.Name,
+			Description: l.Description,
+			Color:       l.Color,
+			Scope:       opt.Scope,
+			ProjectID:   projectID,
+		})
+		if err != nil {
+			return fmt.Errorf("import label %s: %w", name(l), err)
+		}
+		if id, err = newID(labelID(l.Name)); err != nil {
+			return err
+		}
+		im.labels[l.ID] = id
+		im.report("label", name(l), "created", l.ID, id, "")
+	}
+	return nil
+}
+
+// projectsOf imports the projects with their metadata, members and labels.
+// Only the labels of a project existing already are imported.
+func (im *importer) projectsOf(e *export) error {
+	for _, p := range e.Projects {
+		id, err := utils.ProjectID(im.c, 0, p.Name)
+		if err == nil {
+			im.projects[p.ID] = id
+			im.report("project", p.Name, "exists", p.ID, id, "")
+			if err := im.labelsOf(p.Labels, p.Name, id); err != nil {
+				return err
+			}
+			continue
+		}
+		if !errors.Is(err, harbor.ErrNotFound) {
+			return err
+		}
+
+		md := p.Metadata
+		req := &harbor.ProjectReq{
+			ProjectName:                                p.Name,
+			EnableContentTrust:                         md["enable_content_trust"] == "true",
+			PreventVulnerableImagesFromRunning:         md["prevent_vul"] == "true",
+			PreventVulnerableImagesFromRunningSeverity: md["severity"],
+			AutomaticallyScanImagesOnPush:              md["auto_scan"] == "true",
+		}
+		if md["public"] == "true" {
+			req.Public = 1
+		}
+		if err := im.c.CreateProject(req); err != nil {
+			return fmt.Errorf("import project %s: %w", p.Name, err)
+		}
+		if id, err = newID(utils.ProjectID(im.c, 0, p.Name)); err != nil {
+			return err
+		}
+		im.projects[p.ID] = id
+		im.report("project", p.Name, "created", p.ID, id, "")
+		if id == 0 {
+			// not created by --dry-run
+			continue
+		}
+
+		others := map[string]string{}
+		for k, v := range md {
+			if !projectReqMetadata[k] {
+				others[k] = v
+			}
+		}
+		if len(others) > 0 {
+			if err := im.c.AddProjectMetadata(id, others); err != nil {
+				return fmt.Errorf("import metadata of project %s: %w", p.Name, err)
+			}
+		}
+		if err := im.members(p, id); err != nil {
+			return err
+		}
+		if err := im.labelsOf(p.Labels, p.Name, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// members adds the members of p to the project projectID just created. The
+// users missing, e.g. the LDAP users never logged in, are skipped.
+func (im *importer) members(p *exportedProject, projectID int) error {
+	for _, m := range p.Members {
+		name := p.Name + "/" + m.EntityName
+		req := &harbor.ProjectMemberReq{RoleID: m.RoleID}
+		switch m.EntityType {
+		case "u":
+			if m.EntityName == im.me {
+				// the creator is a member already
+				continue
+			}
+			req.MemberUser = &harbor.MemberUser{Username: m.EntityName}
+		case "g":
+			gid, ok := im.groups[m.EntityID]
+			if !ok {
+				im.report("member", name, "skipped", m.ID, 0, "the user group is not exported")
+				continue
+			}
+			req.MemberGroup = &harbor.MemberGroup{ID: gid}
+		default:
+			im.report("member", name, "skipped", m.ID, 0, "unknown entity type "+m.EntityType)
+			continue
+		}
+
+		err := im.c.CreateProjectMember(projectID, req)
+		switch {
+		case errors.Is(err, harbor.ErrNotFound):
+			im.report("member", name, "skipped", m.ID, 0, err.Error())
+		case err != nil:
+			return fmt.Errorf("import member %s: %w", name, err)
+		default:
+			im.report("member", name, "created", m.ID, 0, roleName(m.RoleID))
+		}
+	}
+	return nil
+}
+
+// targetsOf imports the replication targets, the redacted passwords are left
+// empty.
+func (im *importer) targetsOf(e *export) error {
+	for _, t := range e.Targets {
+		id, err := utils.TargetID(im.c, 0, t.Name)
+		if err == nil {
+			im.targets[t.ID] = id
+			im.report("target", t.Name, "exists", t.ID, id, "")
+			continue
+		}
+		if im.unsupported("target", t.Name, t.ID, err) {
+			continue
+		}
+		if !errors.Is(err, harbor.ErrNotFound) {
+			return err
+		}
+
+		password, ok, err := im.box.open(t.Password)
+		if err != nil {
+			return fmt.Errorf("import target %s: %w", t.Name, err)
+		}
+		note := ""
+		if !ok {
+			note = "the password is redacted, set it by replication target update"
+		}
+		err = im.c.CreateTarget(&harbor.Target{
+			Name:     t.Name,
+			Endpoint: t.Endpoint,
+			Username: t.Username,
+			Password: password,
+			Type:     t.Type,
+			Insecure: t.Insecure,
+		})
+		if err != nil {
+			return fmt.Errorf("import target %s: %w", t.Name, err)
+		}
+		if id, err = newID(utils.TargetID(im.c, 0, t.Name)); err != nil {
+			return err
+		}
+		im.targets[t.ID] = id
+		im.report("target", t.Name, "created", t.ID, id, note)
+	}
+	return nil
+}
+
+// policies imports the replication policies, referring to the projects,
+// targets and labels by their new IDs. Existing images are not replicated by
+// the import.
+func (im *importer) policies(e *export) error {
+	for _, p := range e.Policies {
+		projectID := 0
+		if len(p.Projects) > 0 {
+			projectID = im.projects[p.Projects[0].ProjectID]
+		}
+		id, err := utils.PolicyID(im.c, 0, p.Name, projectID)
+		if err == nil {
+			im.report("policy", p.Name, "exists", p.ID, id, "")
+			continue
+		}
+		if im.unsupported("policy", p.Name, p.ID, err) {
+			continue
+		}
+		if !errors.Is(err, harbor.ErrNotFound) {
+			return err
+		}
+
+		policy, missing := im.remapPolicy(p)
+		if missing != "" {
+			im.report("policy", p.Name, "skipped", p.ID, 0, missing+" is not imported")
+			continue
+		}
+		if err := im.c.CreateReplicationPolicy(policy); err != nil {
+			return fmt.Errorf("import policy %s: %w", p.Name, err)
+		}
+		if id, err = newID(utils.PolicyID(im.c, 0, p.Name, projectID)); err != nil {
+			return err
+		}
+		im.report("policy", p.Name, "created", p.ID, id, "")
+	}
+	return nil
+}
+
+// remapPolicy returns a copy of p referring to the new IDs, or what is
+// missing.
+func (im *importer) remapPolicy(p *harbor.ReplicationPolicy) (*harbor.ReplicationPolicy, string) {
+	cp := *p
+	cp.ID, cp.CreationTime, cp.UpdateTime, cp.ErrorJobCount = 0, "", "", 0
+	cp.ReplicateExistingImageNow = false
+
+	cp.Projects = nil
+	for _, prj := range p.Projects {
+		id, ok := im.projects[prj.ProjectID]
+		if !ok || id == 0 {
+			return nil, "project " + prj.Name
+		}
+		cp.Projects = append(cp.Projects, &harbor.Project{ProjectID: id, Name: prj.Name})
+	}
+	cp.Targets = nil
+	for _, t := range p.Targets {
+		id, ok := im.targets[t.ID]
+		if !ok || id == 0 {
+			return nil, "target " + t.Name
+		}
+		cp.Targets = append(cp.Targets, &harbor.Target{ID: id, Name: t.Name})
+	}
+	cp.Filters = nil
+	for _, f := range p.Filters {
+		nf := *f
+		if old, ok := f.Value.(float64); ok && f.Kind == "label" {
+			id, ok := im.labels[int(old)]
+			if !ok || id == 0 {
+				return nil, fmt.Sprintf("label %d", int(old))
+			}
+			nf.Value = id
+		}
+		cp.Filters = append(cp.Filters, &nf)
+	}
+	return &cp, ""
+}
+
+func sortedConfigKeys(cfg map[string]*harbor.ConfigItem) []string {
+	keys := make([]string, 0, len(cfg))
+	for k := range cfg {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1 +1,60 @@
 package api
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+func TestReplicationJobs(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.AddTarget("backup", "https://10.0.0.1")
+	p := ct.srv.AddPolicy("sync", "library", "backup")
+	policyID := strconv.Itoa(p.ID)
+	running := ct.srv.AddJob(p.ID, "library/busybox", "running")
+	finished := ct.srv.AddJob(p.ID, "library/nginx", "finished")
+	for i := 0; i < 10; i++ {
+		ct.srv.AddJob(p.ID, "library/alpine", "error")
+	}
+
+	var jobs []*harbor.ReplicationJob
+	ct.mustRun(&rplistbyfilter, &jobs, "jobs_repl_list_by_filters", "-i", policyID, "--all")
+	if len(jobs) != 12 {
+		t.Errorf("jobs_repl_list_by_filters --all: got %d jobs, want 12", len(jobs))
+	}
+
+	jobs = nil
+	ct.mustRun(&rplistbyfilter, &jobs, "jobs_repl_list_by_filters", "-i", policyID, "-t", "finished")
+	if len(jobs) != 1 || jobs[0].ID != finished.ID {
+		t.Errorf("jobs_repl_list_by_filters -t finished: got %+v", jobs)
+	}
+
+	jobs = nil
+	ct.mustRun(&rplistbyfilter, &jobs, "jobs_repl_list_by_filters", "-i", policyID, "-r", "library/alpine")
+	if len(jobs) != 10 {
+		t.Errorf("jobs_repl_list_by_filters -r library/alpine: got %d jobs, want 10", len(jobs))
+	}
+	if _, err := ct.run(&rplistbyfilter, "jobs_repl_list_by_filters", "-i", policyID, "-t", "done"); err == nil {
+		t.Error("jobs_repl_list_by_filters accepts an invalid status")
+	}
+
+	out := ct.mustRun(&repllogbyid, nil, "jobs_repl_log_get_by_jid", "-i", strconv.Itoa(finished.ID))
+	if !strings.Contains(out, "library/nginx") {
+		t.Errorf("jobs_repl_log_get_by_jid: got %q", out)
+	}
+
+	// a running job can not be deleted until it is stopped
+	ct.wantErr(harbor.ErrBadRequest, &repljobdelbyid, "jobs_repl_job_del_by_jid", "-i", strconv.Itoa(running.ID))
+	ct.mustRun(&replstopbypolicy, nil, "jobs_repl_stop_by_policy", "-i", policyID, "-s", "stop")
+	if j := ct.srv.Job(running.ID); j.Status != "stopped" {
+		t.Errorf("jobs_repl_stop_by_policy: job is %s", j.Status)
+	}
+	ct.mustRun(&repljobdelbyid, nil, "jobs_repl_job_del_by_jid", "-i", strconv.Itoa(running.ID))
+	if ct.srv.Job(running.ID) != nil {
+		t.Error("jobs_repl_job_del_by_jid did not delete the job")
+	}
+
+	ct.wantErr(harbor.ErrNotFound, &scanlogbyid, "jobs_scan_log_get_by_jid", "-i", "1")
+}
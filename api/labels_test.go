@@ -0,0 +1,90 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/harbortest"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func TestLabels(t *testing.T) {
+	ct := newCmdTest(t)
+	prj := ct.srv.AddProject("prj", false, harbortest.AdminUsername)
+
+	ct.mustRun(&labelcreate, nil, "label_create", "-n", "stable", "-d", "stable images")
+	ct.wantErr(harbor.ErrConflict, &labelcreate, "label_create", "-n", "stable", "-d", "again")
+	ct.mustRun(&labelcreate, nil, "label_create", "-n", "stable", "-d", "of prj", "-s", "p", "-p", strconv.Itoa(prj.ProjectID))
+
+	var labels []*harbor.Label
+	ct.mustRun(&labelslist, &labels, "labels_list", "-s", "g")
+	if len(labels) != 1 || labels[0].Description != "stable images" {
+		t.Fatalf("labels_list -s g: got %+v", labels)
+	}
+	id := strconv.Itoa(labels[0].ID)
+
+	labels = nil
+	ct.mustRun(&labelslist, &labels, "labels_list", "-s", "p", "-i", strconv.Itoa(prj.ProjectID), "--all")
+	if len(labels) != 1 || labels[0].Description != "of prj" {
+		t.Errorf("labels_list -s p: got %+v", labels)
+	}
+
+	ct.mustRun(&labelupdate, nil, "label_update", "-i", id, "-n", "release", "-d", "released", "-c", "#FF0000")
+	var l harbor.Label
+	ct.mustRun(&labelget, &l, "label_get_by_id", "-i", id)
+	if l.Name != "release" || l.Color != "#FF0000" {
+		t.Errorf("label_get_by_id after label_update: got %+v", l)
+	}
+
+	ct.mustRun(&labeldel, nil, "label_del_by_id", "--yes", "-i", id)
+	ct.wantErr(harbor.ErrNotFound, &labelget, "label_get_by_id", "-i", id)
+}
+
+func TestLabelByName(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.AddProject("prj", false, harbortest.AdminUsername)
+	ct.srv.AddProject("other", false, harbortest.AdminUsername)
+	global := ct.srv.AddLabel("stable", "")
+	private := ct.srv.AddLabel("qa", "prj")
+	ct.srv.AddLabel("qa", "other")
+
+	var l harbor.Label
+	ct.mustRun(&labelget, &l, "label_get_by_id", "--label", "stable")
+	if l.ID != global.ID {
+		t.Errorf("label_get_by_id --label stable: got %+v", l)
+	}
+
+	// project labels of different projects may have the same name
+	_, err := ct.run(&labelget, "label_get_by_id", "--label", "qa")
+	if !errors.Is(err, utils.ErrAmbiguous) || utils.ExitCode(err) != utils.ExitUsage ||
+		!strings.Contains(err.Error(), "project prj") || !strings.Contains(err.Error(), "project other") {
+		t.Errorf("label_get_by_id --label qa: got error %v", err)
+	}
+	l = harbor.Label{}
+	ct.mustRun(&labelget, &l, "label_get_by_id", "--label", "qa", "--project", "prj")
+	if l.ID != private.ID {
+		t.Errorf("label_get_by_id --label qa --project prj: got %+v", l)
+	}
+
+	ct.wantErr(harbor.ErrNotFound, &labelget, "label_get_by_id", "--label", "stab")
+	ct.wantErr(harbor.ErrNotFound, &labelget, "label_get_by_id", "--label", "qa", "--project", "none")
+
+	ct.wantErr(utils.ErrAmbiguous, &labeldel, "label_del_by_id", "--yes", "--label", "qa")
+	ct.mustRun(&labeldel, nil, "label_del_by_id", "--yes", "--label", "qa", "--project", "prj")
+	if l := ct.srv.Label("qa"); l == nil || l.ID == private.ID {
+		t.Errorf("label_del_by_id --label qa --project prj: left %+v", l)
+	}
+}
+
+func TestLabelsForbidden(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.AddUser("dev", "Dev12345", false)
+	id := strconv.Itoa(ct.srv.AddLabel("stable", "").ID)
+
+	ct.as("dev", "Dev12345")
+	ct.wantErr(harbor.ErrForbidden, &labelcreate, "label_create", "-n", "mine", "-d", "global")
+	ct.wantErr(harbor.ErrForbidden, &labeldel, "label_del_by_id", "--yes", "-i", id)
+}
@@ -1 +1,61 @@
 package api
+
+import (
+	"testing"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/harbortest"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func TestLoginLogout(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.as("", "")
+
+	ct.wantErr(harbor.ErrUnauthorized, &li, "login", "-u", harbortest.AdminUsername, "-p", "wrong")
+	ct.mustRun(&li, nil, "login", "-u", harbortest.AdminUsername, "-p", harbortest.AdminPassword)
+	s, err := utils.SessionLoad()
+	if err != nil || s == nil || s.Username != harbortest.AdminUsername {
+		t.Fatalf("session after login: %+v, %v", s, err)
+	}
+
+	// later commands use the saved session
+	var u harbor.User
+	ct.mustRun(&usrCurrent, &u, "whoami")
+	if u.Username != harbortest.AdminUsername {
+		t.Errorf("whoami after login: got %+v", u)
+	}
+
+	ct.mustRun(&lo, nil, "logout")
+	if s, err := utils.SessionLoad(); err != nil || s != nil {
+		t.Errorf("session after logout: %+v, %v", s, err)
+	}
+	ct.wantErr(harbor.ErrUnauthorized, &lo, "logout")
+}
+
+func TestSessionExpired(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.PushImage("library/busybox", "v1", "sha256:1111")
+	ct.mustRun(&li, nil, "login", "-u", harbortest.AdminUsername, "-p", harbortest.AdminPassword)
+	old, err := utils.SessionLoad()
+	if err != nil || old == nil {
+		t.Fatalf("session after login: %+v, %v", old, err)
+	}
+
+	// logged in again by $HARBOR_USERNAME and $HARBOR_PASSWORD, and the
+	// request is sent again with its body
+	ct.srv.ExpireSessions()
+	ct.mustRun(&repoUpdate, nil, "repo_desp_update", "-n", "library/busybox", "-d", "tiny")
+	if r := ct.srv.Repository("library/busybox"); r.Description != "tiny" {
+		t.Errorf("repo_desp_update after the session expired: got %q", r.Description)
+	}
+	s, err := utils.SessionLoad()
+	if err != nil || s == nil || s.ID == old.ID {
+		t.Errorf("session after logging in again: %+v, %v, the old one %+v", s, err, old)
+	}
+
+	// no way to log in again
+	ct.srv.ExpireSessions()
+	ct.as("", "")
+	ct.wantErr(harbor.ErrUnauthorized, &repoUpdate, "repo_desp_update", "-n", "library/busybox", "-d", "again")
+}
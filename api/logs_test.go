@@ -1 +1,51 @@
 package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/harbortest"
+)
+
+func TestLogs(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.AddUser("dev", "Dev12345", false)
+	ct.srv.AddProject("private", false, harbortest.AdminUsername)
+	ct.srv.PushImage("library/busybox", "v1", "sha256:1111")
+	ct.srv.PushImage("library/busybox", "v2", "sha256:2222")
+	ct.srv.PushImage("private/busybox", "v1", "sha256:3333")
+
+	var got []*harbor.AccessLog
+	ct.mustRun(&logs, &got, "logs")
+	if len(got) != 3 || got[0].RepoName != "private/busybox" {
+		t.Errorf("logs: got %+v, want 3 logs, newest first", got)
+	}
+
+	got = nil
+	ct.mustRun(&logs, &got, "logs", "-r", "library/busybox", "-t", "v2", "-o", "push")
+	if len(got) != 1 {
+		t.Errorf("logs of library/busybox:v2: got %+v", got)
+	}
+
+	if _, err := ct.run(&logs, "logs", "-o", "tag"); err == nil {
+		t.Error("logs accepts an invalid operation")
+	}
+
+	// nothing of the projects dev is not a member of
+	got = nil
+	ct.as("dev", "Dev12345")
+	ct.mustRun(&logs, &got, "logs", "--all")
+	if len(got) != 0 {
+		t.Errorf("logs of dev: got %+v", got)
+	}
+
+	// nothing at all, not even [], if the first page fails
+	ct.as("", "")
+	for _, format := range []string{"json", "yaml"} {
+		out, err := ct.run(&logs, "logs", "--all", "--output", format)
+		if !errors.Is(err, harbor.ErrUnauthorized) || out != "" {
+			t.Errorf("logs --all -o %s of anonymous: got %q, %v", format, out, err)
+		}
+	}
+}
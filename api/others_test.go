@@ -1 +1,24 @@
 package api
+
+import (
+	"testing"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+func TestSyncRegistry(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.mustRun(&syncregistry, nil, "syncregistry")
+
+	ct.srv.AddUser("dev", "Dev12345", false)
+	ct.as("dev", "Dev12345")
+	ct.wantErr(harbor.ErrForbidden, &syncregistry, "syncregistry")
+}
+
+func TestEmailPing(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.mustRun(&emailping, nil, "email_ping", "--email_host", "smtp.mydomain.com", "-t", "465", "-s")
+
+	ct.as("", "")
+	ct.wantErr(harbor.ErrUnauthorized, &emailping, "email_ping")
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func init() {
+	utils.Parser.AddCommand("api",
+		"Make an authenticated request to any harbor API endpoint.",
+		"Send a request of METHOD to path (e.g. /api/projects, or /api/v2.0/projects of harbor 2.x) with the context, session and TLS settings, and print the response, JSON is pretty printed. The path is sent as it is, so that any endpoint can be reached, even the ones no command is written for. Fields given by -f and -F are added to the query of GET and HEAD requests, or sent as a JSON object otherwise, unless the body is given by --input.",
+		&apiCall)
+}
+
+type apiPassthrough struct {
+	Fields      []string `short:"f" long:"field" description:"Add a string field key=value, can be given more than once."`
+	TypedFields []string `short:"F" long:"typed-field" description:"Add a typed field key=value, where true, false, null and numbers are sent as JSON literals, can be given more than once."`
+	Input       string   `long:"input" description:"The file of the JSON body to send, or '-' for stdin."`
+	Include     bool     `short:"i" long:"include" description:"Print the status line and headers of the response as well."`
+	Paginate    bool     `long:"paginate" description:"Fetch all the pages of a list endpoint, and print the items of them as one array."`
+	Args        struct {
+		Method string `positional-arg-name:"METHOD" description:"GET, POST, PUT, DELETE, HEAD and so on."`
+		Path   string `positional-arg-name:"path" description:"The path of the endpoint, with the optional query, e.g. /api/projects?name=library."`
+	} `positional-args:"yes" required:"yes"`
+}
+
+var apiCall apiPassthrough
+
+func (x *apiPassthrough) Execute(args []string) error {
+	method := strings.ToUpper(x.Args.Method)
+	path, query, err := splitPath(x.Args.Path)
+	if err != nil {
+		return err
+	}
+	fields, err := parseFields(x.Fields, x.TypedFields)
+	if err != nil {
+		return err
+	}
+
+	var body []byte
+	if x.Input != "" {
+		if body, err = readInput(x.Input); err != nil {
+			return err
+		}
+	}
+	if len(fields) > 0 {
+		if body != nil || method == http.MethodGet || method == http.MethodHead {
+			for _, k := range sortedFieldKeys(fields) {
+				query.Add(k, fieldString(fields[k]))
+			}
+		} else if body, err = json.Marshal(fields); err != nil {
+			return err
+		}
+	}
+	if x.Paginate && method != http.MethodGet {
+		return utils.Usagef("--paginate works with GET only")
+	}
+
+	return utils.Run(func(c *harbor.Client) (interface{}, error) {
+		if x.Paginate {
+			return paginate(c, path, query)
+		}
+
+		resp, data, err := c.Do(method, path, query, body)
+		if resp != nil && x.Include {
+			printHeader(resp)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(data)) == 0 {
+			return nil, nil
+		}
+		if json.Valid(data) {
+			return json.RawMessage(data), nil
+		}
+		return data, nil
+	})
+}
+
+// splitPath splits the query out of path, which may omit the leading slash.
+func splitPath(p string) (string, url.Values, error) {
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	u, err := url.Parse(p)
+	if err != nil {
+		return "", nil, utils.Usagef("invalid path %q: %v", p, err)
+	}
+	return u.Path, u.Query(), nil
+}
+
+// parseFields parses the key=value of -f as strings and of -F as JSON
+// literals (if they are).
+func parseFields(fields, typed []string) (map[string]interface{}, error) {
+	ret := map[string]interface{}{}
+	for i, list := range [][]string{fields, typed} {
+		for _, f := range list {
+			j := strings.Index(f, "=")
+			if j <= 0 {
+				return nil, utils.Usagef("invalid field %q, should be key=value", f)
+			}
+			key, value := f[:j], f[j+1:]
+			ret[key] = value
+			if i == 0 {
+				continue
+			}
+			switch value {
+			case "true":
+				ret[key] = true
+			case "false":
+				ret[key] = false
+			case "null":
+				ret[key] = nil
+			default:
+				if _, err := strconv.ParseFloat(value, 64); err == nil {
+					ret[key] = json.Number(value)
+				}
+			}
+		}
+	}
+	return ret, nil
+}
+
+func sortedFieldKeys(fields map[string]interface{}) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func fieldString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// readInput reads the JSON body from file, or stdin if file is "-".
+func readInput(file string) ([]byte, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = ioutil.ReadAll(utils.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(data) {
+		return nil, utils.Usagef("--input %s is not valid JSON", file)
+	}
+	return data, nil
+}
+
+// paginate fetches all the pages of the list endpoint at path.
+func paginate(c *harbor.Client, path string, query url.Values) (interface{}, error) {
+	page, _ := strconv.Atoi(query.Get("page"))
+	size, _ := strconv.Atoi(query.Get("page_size"))
+	p := c.NewRawPager(path, query, page, size)
+
+	items := []json.RawMessage{}
+	for {
+		var batch []json.RawMessage
+		if !p.Next(&batch) {
+			break
+		}
+		items = append(items, batch...)
+	}
+	return items, p.Err()
+}
+
+// printHeader prints the status line and headers of resp, like curl -i.
+func printHeader(resp *http.Response) {
+	fmt.Fprintf(utils.Stdout, "%s %s\n", resp.Proto, resp.Status)
+	keys := make([]string, 0, len(resp.Header))
+	for k := range resp.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range resp.Header[k] {
+			if strings.EqualFold(k, "Set-Cookie") {
+				v = "REDACTED"
+			}
+			fmt.Fprintf(utils.Stdout, "%s: %s\n", k, v)
+		}
+	}
+	fmt.Fprintln(utils.Stdout)
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/harbortest"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func TestAPIPassthrough(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.AddProject("prj", false, harbortest.AdminUsername)
+
+	var prjs []*harbor.Project
+	out := ct.mustRun(&apiCall, &prjs, "api", "GET", "/api/projects?name=prj")
+	if len(prjs) != 1 || prjs[0].Name != "prj" {
+		t.Errorf("api GET /api/projects?name=prj: got %+v", prjs)
+	}
+	if !strings.HasPrefix(out, "[\n  {\n    \"project_id\"") {
+		t.Errorf("api GET: not pretty printed in the order of the response:\n%s", out)
+	}
+
+	prjs = nil
+	ct.mustRun(&apiCall, &prjs, "api", "get", "api/projects", "-f", "name=library")
+	if len(prjs) != 1 || prjs[0].Name != "library" {
+		t.Errorf("api get api/projects -f name=library: got %+v", prjs)
+	}
+
+	// HEAD /api/projects has no command
+	if out := ct.mustRun(&apiCall, nil, "api", "HEAD", "/api/projects", "-f", "project_name=prj"); out != "" {
+		t.Errorf("api HEAD: got %q", out)
+	}
+	ct.wantErr(harbor.ErrNotFound, &apiCall, "api", "HEAD", "/api/projects", "-f", "project_name=none")
+	ct.wantErr(harbor.ErrNotFound, &apiCall, "api", "GET", "/api/projects/100")
+
+	// the fields are sent as a JSON object
+	ct.mustRun(&apiCall, nil, "api", "POST", "/api/projects", "-f", "project_name=new", "-F", "public=1")
+	if p := ct.srv.Project("new"); p == nil || p.Metadata["public"] != "true" {
+		t.Errorf("api POST -f: got %+v", p)
+	}
+
+	input := filepath.Join(ct.dir, "project.json")
+	if err := ioutil.WriteFile(input, []byte(`{"project_name": "from-file", "public": 0}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ct.mustRun(&apiCall, nil, "api", "POST", "/api/projects", "--input", input)
+	if ct.srv.Project("from-file") == nil {
+		t.Error("api POST --input did not create the project")
+	}
+	ct.wantErr(harbor.ErrConflict, &apiCall, "api", "POST", "/api/projects", "--input", input)
+	invalid := filepath.Join(ct.dir, "invalid.json")
+	if err := ioutil.WriteFile(invalid, []byte(`{"project_name": `), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out = ct.mustRun(&apiCall, nil, "api", "-i", "GET", "/api/projects/1")
+	if !strings.HasPrefix(out, "HTTP/1.1 200 OK\n") || !strings.Contains(out, "\n\n{\n") {
+		t.Errorf("api -i: got\n%s", out)
+	}
+
+	for _, args := range [][]string{
+		{"api", "GET"},
+		{"api", "POST", "/api/projects", "--paginate"},
+		{"api", "POST", "/api/projects", "-f", "project_name"},
+		{"api", "POST", "/api/projects", "--input", invalid},
+	} {
+		if _, err := ct.run(&apiCall, args...); utils.ExitCode(err) != utils.ExitUsage {
+			t.Errorf("%v: got error %v", args, err)
+		}
+	}
+}
+
+func TestAPIPassthroughPaginate(t *testing.T) {
+	ct := newCmdTest(t)
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		ct.srv.AddProject(name, true, harbortest.AdminUsername)
+	}
+
+	var prjs []json.RawMessage
+	ct.mustRun(&apiCall, &prjs, "api", "GET", "/api/projects?page_size=2")
+	if len(prjs) != 2 {
+		t.Errorf("api GET without --paginate: got %d projects, want 2", len(prjs))
+	}
+
+	prjs = nil
+	ct.mustRun(&apiCall, &prjs, "api", "GET", "/api/projects?page_size=2", "--paginate")
+	if len(prjs) != 6 {
+		t.Errorf("api GET --paginate: got %d projects, want 6", len(prjs))
+	}
+}
@@ -1,123 +1,160 @@
 package api
 
 import (
+	"errors"
 	"fmt"
-	"strconv"
 
+	"github.com/moooofly/harbor-go-client/harbor"
 	"github.com/moooofly/harbor-go-client/utils"
 )
 
 func init() {
-	utils.Parser.AddCommand("policy_update_by_id",
-		"Modify name, description, target and enablement of a policy. (not support yet)",
+	utils.AddCommand("replication policy update", "policy_update_by_id",
+		"Modify name, description, target and trigger of a policy.",
 		"This endpoint let user update policy's name, description, target and enablement.",
 		&poUpdateByID)
-	utils.Parser.AddCommand("policy_get_by_id",
+	utils.AddCommand("replication policy get", "policy_get_by_id",
 		"Get a policy.",
 		"This endpoint let user search a policy by specific ID.",
 		&poGetByID)
-	utils.Parser.AddCommand("policy_create",
-		"Create a policy. (not support yet)",
+	utils.AddCommand("replication policy create", "policy_create",
+		"Create a policy.",
 		"This endpoint let user creates a policy, and if it is enabled, the replication will be triggered right now.",
 		&poCreate)
-	utils.Parser.AddCommand("policies_list",
+	utils.AddCommand("replication policy list", "policies_list",
 		"Filter policies by name and project_id.",
 		"This endpoint let user filter policies by name and project_id, if name and project_id are nil, list returns all policies.",
 		&poList)
 }
 
 type policyUpdateByID struct {
-	ID int `short:"i" long:"id" description:"(REQUIRED) policy ID" required:"yes"`
-	// add more
+	ID          int    `short:"i" long:"id" description:"policy ID, required unless --policy is given."`
+	Policy      string `long:"policy" description:"The name of policy, instead of --id."`
+	Project     string `long:"project" description:"The name of project, to look up --policy among the policies of this project only." complete:"project"`
+	Name        string `short:"n" long:"name" description:"The new name of policy."`
+	Description string `short:"d" long:"description" description:"The new description of policy."`
+	TargetID    int    `short:"t" long:"target_id" description:"The ID of the new target to replicate to." complete:"target_id"`
+	Target      string `long:"target" description:"The name of the new target to replicate to, instead of --target_id." complete:"target"`
+	Trigger     string `long:"trigger" description:"When the replication is triggered from now on." choice:"Manual" choice:"Immediate"`
 }
 
 var poUpdateByID policyUpdateByID
 
 func (x *policyUpdateByID) Execute(args []string) error {
-	PutPolicyUpdateByID(utils.URLGen("/api/policies"))
-	return nil
-}
-
-// PutPolicyUpdateByID let user update policy name, description, target and enablement.
-//
-//  params:
-//
-//  format:
-//
-// e.g.
-func PutPolicyUpdateByID(baseURL string) {
-	// TODO(moooofly): Here are main steps that Harbor UI does
-	//
-	// 1. By "GET /api/policies/replication/<id>" to get replication rule info by specific ID
-	// 2. By "PUT /api/policies/replication/<id>" to update replication rule info by specific ID
-	// 3. By "GET /api/policies/replication" to get all replication rule info to checkout if update succeeds
+	// the same steps as Harbor UI: get the policy, change what is given and put
+	// it back
+	return utils.Run(func(c *harbor.Client) (interface{}, error) {
+		id, err := policyID(c, x.ID, x.Policy, x.Project)
+		if err != nil {
+			return nil, err
+		}
+		p, err := c.GetReplicationPolicy(id)
+		if err != nil {
+			return nil, err
+		}
+		if x.Name != "" {
+			p.Name = x.Name
+		}
+		if x.Description != "" {
+			p.Description = x.Description
+		}
+		if x.TargetID != 0 || x.Target != "" {
+			tid, err := utils.TargetID(c, x.TargetID, x.Target)
+			if err != nil {
+				return nil, err
+			}
+			p.Targets = []*harbor.Target{{ID: tid}}
+		}
+		if x.Trigger != "" {
+			p.Trigger = &harbor.ReplicationTrigger{Kind: x.Trigger}
+		}
+		if err := c.UpdateReplicationPolicy(id, p); err != nil {
+			return nil, err
+		}
+		return c.GetReplicationPolicy(id)
+	})
 }
 
 type policyGetByID struct {
-	ID int `short:"i" long:"id" description:"(REQUIRED) policy ID" required:"yes"`
+	ID      int    `short:"i" long:"id" description:"policy ID, required unless --policy is given."`
+	Policy  string `long:"policy" description:"The name of policy, instead of --id."`
+	Project string `long:"project" description:"The name of project, to look up --policy among the policies of this project only." complete:"project"`
 }
 
 var poGetByID policyGetByID
 
 func (x *policyGetByID) Execute(args []string) error {
-	GetPolicyByID(utils.URLGen("/api/policies"))
-	return nil
+	return utils.Run(func(c *harbor.Client) (interface{}, error) {
+		id, err := policyID(c, x.ID, x.Policy, x.Project)
+		if err != nil {
+			return nil, err
+		}
+		return c.GetReplicationPolicy(id)
+	})
 }
 
-// GetPolicyByID let user search replication policy by specific ID.
-//
-// params:
-//   id - (REQUIRED) policy ID
-//
-// format:
-//   GET /policies/replication/{id}
-//
-// e.g. curl -X GET --header 'Accept: text/plain' 'https://localhost/api/policies/replication/1'
-func GetPolicyByID(baseURL string) {
-	targetURL := baseURL + "/replication/" + strconv.Itoa(poGetByID.ID)
-	fmt.Println("==> GET", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
+// policyID resolves the policy of --id, or --policy looked up in the project
+// of --project if given.
+func policyID(c *harbor.Client, id int, policy, project string) (int, error) {
+	projectID := 0
+	if project != "" {
+		pid, err := utils.ProjectID(c, 0, project)
+		if err != nil {
+			return 0, err
+		}
+		projectID = pid
 	}
-
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return utils.PolicyID(c, id, policy, projectID)
 }
 
 type policyCreate struct {
+	Name              string `short:"n" long:"name" description:"(REQUIRED) The name of policy." required:"yes"`
+	Description       string `short:"d" long:"description" description:"The description of policy." default:""`
+	ProjectID         int    `short:"j" long:"project_id" description:"The ID of project to replicate, required unless --project is given." complete:"project_id"`
+	Project           string `long:"project" description:"The name of project to replicate, instead of --project_id." complete:"project"`
+	TargetID          int    `short:"t" long:"target_id" description:"The ID of target to replicate to, required unless --target is given." complete:"target_id"`
+	Target            string `long:"target" description:"The name of target to replicate to, instead of --target_id." complete:"target"`
+	Trigger           string `long:"trigger" description:"When the replication is triggered." default:"Manual" choice:"Manual" choice:"Immediate"`
+	ReplicateExisting bool   `long:"replicate_existing_image_now" description:"Replicate the existing images on creation."`
+	ReplicateDeletion bool   `long:"replicate_deletion" description:"Replicate the deletions of images too."`
 }
 
 var poCreate policyCreate
 
 func (x *policyCreate) Execute(args []string) error {
-	PostPolicyCreate(utils.URLGen("/api/policies"))
-	return nil
-}
-
-// PostPolicyCreate let user creates a policy, and if it is enabled, the replication will be triggered right now.
-//
-//  params:
-//
-//  format:
-//
-// e.g.
-func PostPolicyCreate(baseURL string) {
-	// TODO(moooofly): Here are main steps that Harbor UI does
-	//
-	// 1. By "GET /api/policies/replication?name=<xxx>" to check if replication rule with name <xxx> already exists
-	// 2. By "GET /api/projects?name=<yyy>" to get source project info to be replicated
-	// 3. By "GET /api/targets?name=<zzz>" to get endpoint info to replicate to
-	// 4. By "POST /api/policies/replication" to create replication rule based on above info and some other info
+	// the same steps as Harbor UI: check the name, look up the project and the
+	// target, then create the policy
+	return utils.Run(func(c *harbor.Client) (interface{}, error) {
+		projectID, err := utils.ProjectID(c, x.ProjectID, x.Project)
+		if err != nil {
+			return nil, err
+		}
+		switch _, err := utils.PolicyID(c, 0, x.Name, projectID); {
+		case err == nil:
+			return nil, fmt.Errorf("policy %q of project %d: %w", x.Name, projectID, harbor.ErrConflict)
+		case !errors.Is(err, harbor.ErrNotFound):
+			return nil, err
+		}
+		targetID, err := utils.TargetID(c, x.TargetID, x.Target)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, c.CreateReplicationPolicy(&harbor.ReplicationPolicy{
+			Name:                      x.Name,
+			Description:               x.Description,
+			Projects:                  []*harbor.Project{{ProjectID: projectID}},
+			Targets:                   []*harbor.Target{{ID: targetID}},
+			Trigger:                   &harbor.ReplicationTrigger{Kind: x.Trigger},
+			ReplicateExistingImageNow: x.ReplicateExisting,
+			ReplicateDeletion:         x.ReplicateDeletion,
+		})
+	})
 }
 
 type policiesList struct {
 	Name      string `short:"n" long:"name" description:"The replication's policy name." default:""`
-	ProjectID int    `short:"j" long:"project_id" description:"The ID of project." default:"0"`
+	ProjectID int    `short:"j" long:"project_id" description:"The ID of project." default:"0" complete:"project_id"`
 	Page      int    `short:"p" long:"page" description:"The page nubmer, default is 1." default:"1"`
 	PageSize  int    `short:"s" long:"page_size" description:"The size of per page, default is 10, maximum is 100." default:"10"`
 }
@@ -125,37 +162,12 @@ type policiesList struct {
 var poList policiesList
 
 func (x *policiesList) Execute(args []string) error {
-	GetPoliciesList(utils.URLGen("/api/policies"))
-	return nil
-}
-
-// GetPoliciesList let user list filters policies by name and project_id, if name and project_id are nil, list returns all policies.
-//
-// params:
-//   name       - The replication's policy name.
-//   project_id - The ID of project.
-//   page       - The page nubmer, default is 1.
-//   page_size  - The size of per page, default is 10, maximum is 100.
-//
-// format:
-//   GET /policies/replication
-//
-// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/policies/replication?name=repl_policy_name&project_id=86&page=1&page_size=10'
-func GetPoliciesList(baseURL string) {
-	targetURL := baseURL + "/replication?name=" + poList.Name +
-		"&project_id=" + strconv.Itoa(poList.ProjectID) +
-		"&page=" + strconv.Itoa(poList.Page) +
-		"&page_size=" + strconv.Itoa(poList.PageSize)
-	fmt.Println("==> GET", targetURL)
-
-	// Read beegosessionID from .cookie.yaml
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
-
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return utils.Run(func(c *harbor.Client) (interface{}, error) {
+		return c.ListReplicationPolicies(&harbor.ReplicationPolicyListOptions{
+			Name:      x.Name,
+			ProjectID: x.ProjectID,
+			Page:      x.Page,
+			PageSize:  x.PageSize,
+		})
+	})
 }
@@ -1 +1,85 @@
 package api
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func TestPolicies(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.AddTarget("backup", "https://10.0.0.1")
+	p := ct.srv.AddPolicy("sync-library", "library", "backup")
+	other := ct.srv.AddProject("other", true, "admin")
+	ct.srv.AddPolicy("sync-other", other.Name, "backup")
+
+	var policies []*harbor.ReplicationPolicy
+	ct.mustRun(&poList, &policies, "policies_list")
+	if len(policies) != 2 {
+		t.Errorf("policies_list: got %d policies, want 2", len(policies))
+	}
+
+	policies = nil
+	ct.mustRun(&poList, &policies, "policies_list", "-j", strconv.Itoa(other.ProjectID))
+	if len(policies) != 1 || policies[0].Name != "sync-other" {
+		t.Errorf("policies_list -j: got %+v", policies)
+	}
+
+	var got harbor.ReplicationPolicy
+	ct.mustRun(&poGetByID, &got, "policy_get_by_id", "-i", strconv.Itoa(p.ID))
+	if got.Name != "sync-library" || len(got.Targets) != 1 || got.Targets[0].Name != "backup" {
+		t.Errorf("policy_get_by_id: got %+v", got)
+	}
+	ct.wantErr(harbor.ErrNotFound, &poGetByID, "policy_get_by_id", "-i", "100")
+
+	got = harbor.ReplicationPolicy{}
+	ct.mustRun(&poGetByID, &got, "policy_get_by_id", "--policy", "sync-other")
+	if got.ID == p.ID || got.Name != "sync-other" {
+		t.Errorf("policy_get_by_id --policy: got %+v", got)
+	}
+	ct.wantErr(harbor.ErrNotFound, &poGetByID, "policy_get_by_id", "--policy", "sync")
+	ct.wantErr(harbor.ErrNotFound, &poGetByID, "policy_get_by_id", "--policy", "sync-other", "--project", "library")
+
+	// the same name in two projects
+	ct.srv.AddPolicy("sync-other", "library", "backup")
+	ct.wantErr(utils.ErrAmbiguous, &poGetByID, "policy_get_by_id", "--policy", "sync-other")
+	got = harbor.ReplicationPolicy{}
+	ct.mustRun(&poGetByID, &got, "policy_get_by_id", "--policy", "sync-other", "--project", "other")
+	if len(got.Projects) != 1 || got.Projects[0].Name != "other" {
+		t.Errorf("policy_get_by_id --policy --project: got %+v", got)
+	}
+}
+
+func TestPolicyCreateAndUpdate(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.AddTarget("backup", "https://10.0.0.1")
+	ct.srv.AddTarget("dr", "https://10.0.0.2")
+
+	ct.mustRun(&poCreate, nil, "policy_create", "-n", "sync-library", "-d", "nightly", "--project", "library", "--target", "backup")
+	var got harbor.ReplicationPolicy
+	ct.mustRun(&poGetByID, &got, "policy_get_by_id", "--policy", "sync-library")
+	if got.Description != "nightly" || len(got.Projects) != 1 || got.Projects[0].ProjectID != ct.srv.Project("library").ProjectID ||
+		len(got.Targets) != 1 || got.Targets[0].ID != ct.srv.Target("backup").ID || got.Trigger == nil || got.Trigger.Kind != "Manual" {
+		t.Errorf("policy_create: got %+v", got)
+	}
+	ct.wantErr(harbor.ErrConflict, &poCreate, "policy_create", "-n", "sync-library", "--project", "library", "--target", "dr")
+	ct.wantErr(harbor.ErrNotFound, &poCreate, "policy_create", "-n", "sync-none", "--project", "none", "--target", "dr")
+	ct.wantErr(harbor.ErrNotFound, &poCreate, "policy_create", "-n", "sync-none", "--project", "library", "--target", "none")
+
+	got = harbor.ReplicationPolicy{}
+	ct.mustRun(&poUpdateByID, &got, "policy_update_by_id", "--policy", "sync-library", "--target", "dr", "--trigger", "Immediate")
+	if got.Name != "sync-library" || got.Description != "nightly" || len(got.Targets) != 1 ||
+		got.Targets[0].ID != ct.srv.Target("dr").ID || got.Trigger == nil || got.Trigger.Kind != "Immediate" {
+		t.Errorf("policy_update_by_id: got %+v", got)
+	}
+
+	id := strconv.Itoa(got.ID)
+	got = harbor.ReplicationPolicy{}
+	ct.mustRun(&poUpdateByID, &got, "policy_update_by_id", "-i", id, "-n", "sync-dr", "-d", "hourly")
+	if got.Name != "sync-dr" || got.Description != "hourly" || got.Targets[0].ID != ct.srv.Target("dr").ID {
+		t.Errorf("policy_update_by_id -i: got %+v", got)
+	}
+	ct.wantErr(harbor.ErrNotFound, &poUpdateByID, "policy_update_by_id", "--policy", "sync-library", "-d", "never")
+}
@@ -1 +1,279 @@
 package api
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/harbortest"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func TestProjectCreateGetDelete(t *testing.T) {
+	ct := newCmdTest(t)
+
+	ct.mustRun(&prjCreate, nil, "prj_create", "-n", "prj", "-k", "0", "-a")
+	p := ct.srv.Project("prj")
+	if p == nil {
+		t.Fatal("project is not created")
+	}
+	if p.Metadata["public"] != "false" || p.Metadata["auto_scan"] != "true" {
+		t.Errorf("metadata of the new project: %v", p.Metadata)
+	}
+	ct.wantErr(harbor.ErrConflict, &prjCreate, "prj_create", "-n", "prj", "-k", "0")
+
+	id := strconv.Itoa(p.ProjectID)
+	var got harbor.Project
+	ct.mustRun(&prjGet, &got, "prj_get", "-j", id)
+	if got.Name != "prj" || got.OwnerName != harbortest.AdminUsername {
+		t.Errorf("prj_get: got %+v", got)
+	}
+
+	ct.mustRun(&prjUpdate, nil, "prj_update", "-j", id, "-k", "1")
+	if p := ct.srv.Project("prj"); p.Metadata["public"] != "true" {
+		t.Errorf("prj_update did not make it public: %v", p.Metadata)
+	}
+
+	ct.mustRun(&prjDel, nil, "prj_del", "--yes", "-j", id)
+	if ct.srv.Project("prj") != nil {
+		t.Error("prj_del did not delete the project")
+	}
+	ct.wantErr(harbor.ErrNotFound, &prjGet, "prj_get", "-j", id)
+}
+
+func TestProjectByName(t *testing.T) {
+	ct := newCmdTest(t)
+	p := ct.srv.AddProject("prj", false, harbortest.AdminUsername)
+	ct.srv.AddProject("prj2", false, harbortest.AdminUsername)
+	ct.srv.AddUser("dev", "Dev12345", false)
+	ct.srv.AddMember("prj", "dev", harbortest.RoleDeveloper)
+
+	// harbor matches the name partially, prj2 must not get in the way
+	var got harbor.Project
+	ct.mustRun(&prjGet, &got, "prj_get", "--project", "prj")
+	if got.ProjectID != p.ProjectID {
+		t.Errorf("prj_get --project: got %+v", got)
+	}
+	var members []*harbor.ProjectMember
+	ct.mustRun(&prjMembersGet, &members, "prj_members_get", "--project", "prj", "-n", "dev")
+	if len(members) != 1 {
+		t.Errorf("prj_members_get --project: got %+v", members)
+	}
+
+	ct.wantErr(harbor.ErrNotFound, &prjGet, "prj_get", "--project", "pr")
+	for _, args := range [][]string{
+		{"prj_get"},
+		{"prj_get", "-j", strconv.Itoa(p.ProjectID), "--project", "prj"},
+	} {
+		if _, err := ct.run(&prjGet, args...); utils.ExitCode(err) != utils.ExitUsage {
+			t.Errorf("%v: got error %v", args, err)
+		}
+	}
+
+	ct.mustRun(&prjDel, nil, "prj_del", "--yes", "--project", "prj2")
+	if ct.srv.Project("prj2") != nil || ct.srv.Project("prj") == nil {
+		t.Error("prj_del --project prj2 did not delete prj2 only")
+	}
+}
+
+func TestProjectCommandTree(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.AddUser("dev", "Dev12345", false)
+
+	ct.mustRun(&prjCreate, nil, "project", "create", "-n", "prj", "-k", "1")
+	var got harbor.Project
+	ct.mustRun(&prjGet, &got, "project", "get", "--project", "prj")
+	if got.Metadata["public"] != "true" {
+		t.Errorf("project get: got %+v", got)
+	}
+	ct.mustRun(&prjMemberCreate, nil, "project", "member", "create", "-j", strconv.Itoa(got.ProjectID), "-r", "2", "-n", "dev")
+	var members []*harbor.ProjectMember
+	ct.mustRun(&prjMembersGet, &members, "project", "member", "list", "--project", "prj", "-n", "dev")
+	if len(members) != 1 || members[0].RoleName != "developer" {
+		t.Errorf("project member list: got %+v", members)
+	}
+
+	// a group without a command is a usage error
+	if _, err := ct.run(&prjGet, "project", "member"); utils.ExitCode(err) != utils.ExitUsage {
+		t.Errorf("project member: got error %v", err)
+	}
+
+	ct.mustRun(&prjDel, nil, "project", "delete", "--yes", "--project", "prj")
+	if ct.srv.Project("prj") != nil {
+		t.Error("project delete did not delete the project")
+	}
+}
+
+func TestProjectDeleteForbidden(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.AddUser("dev", "Dev12345", false)
+	ct.srv.AddProject("prj", false, harbortest.AdminUsername)
+	ct.srv.AddMember("prj", "dev", harbortest.RoleDeveloper)
+
+	ct.as("dev", "Dev12345")
+	ct.wantErr(harbor.ErrForbidden, &prjDel, "prj_del", "--yes", "-j", strconv.Itoa(ct.srv.Project("prj").ProjectID))
+
+	ct.as("", "")
+	ct.wantErr(harbor.ErrUnauthorized, &prjDel, "prj_del", "--yes", "-j", strconv.Itoa(ct.srv.Project("prj").ProjectID))
+}
+
+// TestProjectDeleteConfirmation counts the tags of all the repositories of
+// the project in the confirmation, more than a page of them.
+func TestProjectDeleteConfirmation(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.AddProject("prj", false, harbortest.AdminUsername)
+	for i := 0; i < harbor.MaxPageSize+20; i++ {
+		ct.srv.PushImage(fmt.Sprintf("prj/app%d", i), "v1", fmt.Sprintf("sha256:%d", i))
+	}
+	ct.srv.PushImage("prj/app0", "v2", "sha256:v2")
+
+	_, err := ct.run(&prjDel, "prj_del", "--project", "prj")
+	if !errors.Is(err, utils.ErrAborted) || !strings.Contains(err.Error(), "(120 repositories, 121 tags,") {
+		t.Errorf("prj_del: got %v, want 120 repositories and 121 tags", err)
+	}
+}
+
+func TestProjectsList(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.AddUser("dev", "Dev12345", false)
+	ct.srv.AddProject("private", false, harbortest.AdminUsername)
+	ct.srv.AddProject("mine", false, "dev")
+	for i := 0; i < 12; i++ {
+		ct.srv.AddProject("many"+strconv.Itoa(i), true, harbortest.AdminUsername)
+	}
+
+	var prjs []*harbor.Project
+	ct.mustRun(&prjsList, &prjs, "prjs_list")
+	if len(prjs) != 10 {
+		t.Errorf("prjs_list: got %d projects, want the first page of 10", len(prjs))
+	}
+
+	prjs = nil
+	ct.mustRun(&prjsList, &prjs, "prjs_list", "--all")
+	if len(prjs) != 15 {
+		t.Errorf("prjs_list --all: got %d projects, want 15", len(prjs))
+	}
+
+	prjs = nil
+	ct.mustRun(&prjsList, &prjs, "prjs_list", "--all", "-p", "2")
+	if len(prjs) != 15 {
+		t.Errorf("prjs_list --all -p 2: got %d projects, want 15", len(prjs))
+	}
+
+	prjs = nil
+	ct.mustRun(&prjsList, &prjs, "prjs_list", "-n", "many", "--limit", "5")
+	if len(prjs) != 5 {
+		t.Errorf("prjs_list --limit 5: got %d projects", len(prjs))
+	}
+
+	prjs = nil
+	ct.as("dev", "Dev12345")
+	ct.mustRun(&prjsList, &prjs, "prjs_list", "-k", "false")
+	if len(prjs) != 1 || prjs[0].Name != "mine" {
+		t.Errorf("private projects of dev: got %v", prjs)
+	}
+}
+
+func TestProjectMembers(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.AddUser("dev", "Dev12345", false)
+	p := ct.srv.AddProject("prj", false, harbortest.AdminUsername)
+	id := strconv.Itoa(p.ProjectID)
+
+	ct.mustRun(&prjMemberCreate, nil, "prj_member_create", "-j", id, "-r", "3", "-n", "dev")
+	ct.wantErr(harbor.ErrConflict, &prjMemberCreate, "prj_member_create", "-j", id, "-r", "3", "-n", "dev")
+	ct.wantErr(harbor.ErrNotFound, &prjMemberCreate, "prj_member_create", "-j", id, "-r", "3", "-n", "nobody")
+
+	var members []*harbor.ProjectMember
+	ct.mustRun(&prjMembersGet, &members, "prj_members_get", "-j", id, "-n", "dev")
+	if len(members) != 1 || members[0].RoleName != "guest" {
+		t.Fatalf("prj_members_get: got %+v", members)
+	}
+	mid := strconv.Itoa(members[0].ID)
+
+	// a guest can not change members
+	ct.as("dev", "Dev12345")
+	ct.wantErr(harbor.ErrForbidden, &prjMemberUpdate, "prj_member_update", "-j", id, "-m", mid, "-r", "1")
+
+	ct.as(harbortest.AdminUsername, harbortest.AdminPassword)
+	ct.mustRun(&prjMemberUpdate, nil, "prj_member_update", "-j", id, "-m", mid, "-r", "2")
+	var m harbor.ProjectMember
+	ct.mustRun(&prjMemberGet, &m, "prj_member_get", "-j", id, "-m", mid)
+	if m.RoleID != harbortest.RoleDeveloper {
+		t.Errorf("prj_member_update: got role %d", m.RoleID)
+	}
+
+	m = harbor.ProjectMember{}
+	ct.mustRun(&prjMemberGet, &m, "prj_member_get", "--project", "prj", "--member", "dev")
+	if strconv.Itoa(m.ID) != mid {
+		t.Errorf("prj_member_get --project --member: got %+v", m)
+	}
+	ct.wantErr(harbor.ErrNotFound, &prjMemberDel, "prj_member_del", "--project", "prj", "--member", "de")
+
+	ct.mustRun(&prjMemberDel, nil, "prj_member_del", "--project", "prj", "--member", "dev")
+	if n := len(ct.srv.Members("prj")); n != 1 {
+		t.Errorf("prj_member_del: %d members left, want 1", n)
+	}
+}
+
+func TestProjectMetadata(t *testing.T) {
+	ct := newCmdTest(t)
+	id := strconv.Itoa(ct.srv.AddProject("prj", false, harbortest.AdminUsername).ProjectID)
+
+	ct.mustRun(&prjMetadataAdd, nil, "prj_metadata_add", "-j", id, "-k", "1", "-s", "high")
+	var meta map[string]string
+	ct.mustRun(&prjMetadataGet, &meta, "prj_metadata_get", "-j", id)
+	if meta["public"] != "true" || meta["severity"] != "high" {
+		t.Errorf("prj_metadata_get: got %v", meta)
+	}
+
+	ct.mustRun(&prjMetadataUpdateByName, nil, "prj_metadata_update_by_name", "-j", id, "-m", "severity", "-v", "low")
+	meta = nil
+	ct.mustRun(&prjMetadataGetByName, &meta, "prj_metadata_get_by_name", "-j", id, "-m", "severity")
+	if meta["severity"] != "low" {
+		t.Errorf("prj_metadata_get_by_name: got %v", meta)
+	}
+
+	ct.mustRun(&prjMetadataDelByName, nil, "prj_metadata_del_by_name", "--project", "prj", "-m", "severity")
+	ct.wantErr(harbor.ErrNotFound, &prjMetadataGetByName, "prj_metadata_get_by_name", "-j", id, "-m", "severity")
+}
+
+func TestProjectLogs(t *testing.T) {
+	ct := newCmdTest(t)
+	id := strconv.Itoa(ct.srv.AddProject("prj", false, harbortest.AdminUsername).ProjectID)
+	ct.srv.PushImage("prj/busybox", "v1", "sha256:1111")
+	ct.srv.PushImage("library/busybox", "v1", "sha256:2222")
+
+	var logs []*harbor.AccessLog
+	ct.mustRun(&prjLogsGet, &logs, "prj_logs_get", "-j", id, "-o", "push")
+	if len(logs) != 1 || logs[0].RepoName != "prj/busybox" {
+		t.Errorf("prj_logs_get: got %+v", logs)
+	}
+}
+
+func TestProjectDeleteDryRun(t *testing.T) {
+	ct := newCmdTest(t)
+	p := ct.srv.AddProject("prj", false, harbortest.AdminUsername)
+	id := strconv.Itoa(p.ProjectID)
+
+	if out := ct.mustRun(&prjDel, nil, "--dry-run", "prj_del", "-j", id); out != "" {
+		t.Errorf("prj_del --dry-run: got %q printed as the result", out)
+	}
+	out := ct.stderr.String()
+	if ct.srv.Project("prj") == nil {
+		t.Fatal("prj_del --dry-run deleted the project")
+	}
+	url := ct.srv.URL + "/api/projects/" + id
+	for _, want := range []string{
+		"DELETE " + url + "\n",
+		"Cookie: beegosessionID=REDACTED\n",
+		"curl -X DELETE --header 'Accept: application/json' --header 'Cookie: beegosessionID=REDACTED' '" + url + "' -i\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("prj_del --dry-run: %q not found in\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package api
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+func TestReplicationTrigger(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.AddTarget("backup", "https://10.0.0.1")
+	p := ct.srv.AddPolicy("sync", "library", "backup")
+	ct.srv.PushImage("library/busybox", "v1", "sha256:1111")
+	ct.srv.PushImage("library/nginx", "v1", "sha256:2222")
+
+	ct.mustRun(&replTriByID, nil, "replication_trigger_by_id", "-i", strconv.Itoa(p.ID))
+	for id := 1; id <= 2; id++ {
+		if j := ct.srv.Job(id); j == nil || j.PolicyID != p.ID || j.Status != "pending" {
+			t.Errorf("job %d: got %+v", id, j)
+		}
+	}
+
+	ct.wantErr(harbor.ErrNotFound, &replTriByID, "replication_trigger_by_id", "-i", "100")
+}
@@ -1 +1,156 @@
 package api
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/harbortest"
+)
+
+func TestReposList(t *testing.T) {
+	ct := newCmdTest(t)
+	id := strconv.Itoa(ct.srv.AddProject("prj", false, harbortest.AdminUsername).ProjectID)
+	ct.srv.PushImage("prj/busybox", "v1", "sha256:1111")
+	ct.srv.PushImage("prj/nginx", "v1", "sha256:2222")
+	ct.srv.PushImage("library/busybox", "v1", "sha256:3333")
+
+	var repos []*harbor.Repository
+	ct.mustRun(&reposList, &repos, "repos_list", "-j", id)
+	if len(repos) != 2 {
+		t.Errorf("repos_list: got %d repositories, want 2", len(repos))
+	}
+
+	repos = nil
+	ct.mustRun(&reposList, &repos, "repos_list", "-j", id, "-n", "nginx")
+	if len(repos) != 1 || repos[0].Name != "prj/nginx" {
+		t.Errorf("repos_list -n nginx: got %+v", repos)
+	}
+
+	ct.as("", "")
+	ct.wantErr(harbor.ErrUnauthorized, &reposList, "repos_list", "-j", id)
+}
+
+func TestReposTop(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.AddProject("prj", false, harbortest.AdminUsername)
+	ct.srv.PushImage("prj/busybox", "v1", "sha256:1111")
+	ct.srv.PushImage("library/busybox", "v1", "sha256:2222")
+
+	ct.as("", "")
+	var repos []*harbor.Repository
+	ct.mustRun(&reposTop, &repos, "repos_top", "-c", "5")
+	if len(repos) != 1 || repos[0].Name != "library/busybox" {
+		t.Errorf("repos_top of anonymous: got %+v", repos)
+	}
+}
+
+func TestRepoDescriptionAndDelete(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.AddUser("guest", "Guest123", false)
+	ct.srv.AddMember("library", "guest", harbortest.RoleGuest)
+	ct.srv.PushImage("library/busybox", "v1", "sha256:1111")
+
+	ct.mustRun(&repoUpdate, nil, "repo_desp_update", "-n", "library/busybox", "-d", "tiny")
+	if r := ct.srv.Repository("library/busybox"); r.Description != "tiny" {
+		t.Errorf("repo_desp_update: got %q", r.Description)
+	}
+
+	ct.as("guest", "Guest123")
+	ct.wantErr(harbor.ErrForbidden, &repoDel, "repo_del", "--yes", "-n", "library/busybox")
+
+	ct.as(harbortest.AdminUsername, harbortest.AdminPassword)
+	ct.mustRun(&repoDel, nil, "repo_del", "--yes", "-n", "library/busybox")
+	if ct.srv.Repository("library/busybox") != nil {
+		t.Error("repo_del did not delete the repository")
+	}
+	ct.wantErr(harbor.ErrNotFound, &repoDel, "repo_del", "--yes", "-n", "library/busybox")
+}
+
+func TestRepoLabels(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.PushImage("library/busybox", "v1", "sha256:1111")
+	l := ct.srv.AddLabel("stable", "")
+	id := strconv.Itoa(l.ID)
+
+	ct.mustRun(&repoLabelAdd, nil, "repo_label_add", "-n", "library/busybox", "-i", id)
+	ct.wantErr(harbor.ErrConflict, &repoLabelAdd, "repo_label_add", "-n", "library/busybox", "-i", id)
+
+	var labels []*harbor.Label
+	ct.mustRun(&repoLabelsGet, &labels, "repo_labels_get", "-n", "library/busybox")
+	if len(labels) != 1 || labels[0].Name != "stable" {
+		t.Errorf("repo_labels_get: got %+v", labels)
+	}
+
+	ct.mustRun(&repoLabelDel, nil, "repo_label_del", "-n", "library/busybox", "-i", id)
+	labels = nil
+	ct.mustRun(&repoLabelsGet, &labels, "repo_labels_get", "-n", "library/busybox")
+	if len(labels) != 0 {
+		t.Errorf("repo_label_del: got %+v", labels)
+	}
+}
+
+func TestRepoImageLabels(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.PushImage("library/busybox", "v1", "sha256:1111")
+	id := strconv.Itoa(ct.srv.AddLabel("protected", "library").ID)
+	other := ct.srv.AddProject("other", false, harbortest.AdminUsername)
+	otherID := strconv.Itoa(ct.srv.AddLabel("other", other.Name).ID)
+
+	ct.mustRun(&repoImageLabelAdd, nil, "repo_image_label_add", "-n", "library/busybox", "-t", "v1", "-i", id)
+	ct.wantErr(harbor.ErrBadRequest, &repoImageLabelAdd, "repo_image_label_add", "-n", "library/busybox", "-t", "v1", "-i", otherID)
+
+	var labels []*harbor.Label
+	ct.mustRun(&repoImageLabelsGet, &labels, "repo_image_labels_get", "-n", "library/busybox", "-t", "v1")
+	if len(labels) != 1 || labels[0].Name != "protected" {
+		t.Errorf("repo_image_labels_get: got %+v", labels)
+	}
+
+	ct.mustRun(&repoImageLabelDel, nil, "repo_image_label_del", "-n", "library/busybox", "-t", "v1", "-i", id)
+	ct.wantErr(harbor.ErrNotFound, &repoImageLabelDel, "repo_image_label_del", "-n", "library/busybox", "-t", "v1", "-i", id)
+}
+
+func TestRepoManifestAndSignatures(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.PushImage("library/busybox", "v1", "sha256:1111")
+
+	var m harbor.Manifest
+	ct.mustRun(&repoImageManifestsGet, &m, "repo_image_manifests_get", "-n", "library/busybox", "-t", "v1")
+	if m.Config == "" || m.Manifest == nil {
+		t.Errorf("repo_image_manifests_get: got %+v", m)
+	}
+	ct.wantErr(harbor.ErrNotFound, &repoImageManifestsGet, "repo_image_manifests_get", "-n", "library/busybox", "-t", "v2")
+
+	var sigs []*harbor.Signature
+	ct.mustRun(&repoSignatureGet, &sigs, "repo_signature_get", "-n", "library/busybox")
+	if len(sigs) != 0 {
+		t.Errorf("repo_signature_get: got %+v", sigs)
+	}
+}
+
+func TestRepoImageScan(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.AddUser("guest", "Guest123", false)
+	ct.srv.AddMember("library", "guest", harbortest.RoleGuest)
+	ct.srv.PushImage("library/busybox", "v1", "sha256:1111")
+	ct.srv.AddVulnerability("sha256:1111", &harbor.VulnerabilityItem{ID: "CVE-2018-0001", Severity: 5, Package: "busybox", Version: "1.28"})
+
+	var vulns []*harbor.VulnerabilityItem
+	ct.mustRun(&repoImageVulDetailsGet, &vulns, "repo_image_vul_details_get", "-n", "library/busybox", "-t", "v1")
+	if len(vulns) != 0 {
+		t.Errorf("repo_image_vul_details_get before the scan: got %+v", vulns)
+	}
+
+	ct.as("guest", "Guest123")
+	ct.wantErr(harbor.ErrForbidden, &repoImageScan, "repo_image_scan", "-n", "library/busybox", "-t", "v1")
+
+	ct.as(harbortest.AdminUsername, harbortest.AdminPassword)
+	ct.mustRun(&repoImageScan, nil, "repo_image_scan", "-n", "library/busybox", "-t", "v1")
+	ct.wantErr(harbor.ErrNotFound, &repoImageScan, "repo_image_scan", "-n", "library/busybox", "-t", "v2")
+
+	vulns = nil
+	ct.mustRun(&repoImageVulDetailsGet, &vulns, "repo_image_vul_details_get", "-n", "library/busybox", "-t", "v1")
+	if len(vulns) != 1 || vulns[0].ID != "CVE-2018-0001" || vulns[0].Severity != 5 {
+		t.Errorf("repo_image_vul_details_get: got %+v", vulns)
+	}
+}
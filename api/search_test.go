@@ -1 +1,28 @@
 package api
+
+import (
+	"testing"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/harbortest"
+)
+
+func TestSearch(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.AddProject("private", false, harbortest.AdminUsername)
+	ct.srv.PushImage("library/busybox", "v1", "sha256:1111")
+	ct.srv.PushImage("private/busybox", "v1", "sha256:2222")
+
+	var r harbor.SearchResult
+	ct.mustRun(&searching, &r, "search", "-q", "busybox")
+	if len(r.Repository) != 2 {
+		t.Errorf("search as admin: got %+v", r.Repository)
+	}
+
+	ct.as("", "")
+	r = harbor.SearchResult{}
+	ct.mustRun(&searching, &r, "search", "-q", "busybox")
+	if len(r.Repository) != 1 || r.Repository[0].RepositoryName != "library/busybox" {
+		t.Errorf("search as anonymous: got %+v", r.Repository)
+	}
+}
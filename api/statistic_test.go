@@ -1 +1,39 @@
 package api
+
+import (
+	"testing"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/harbortest"
+)
+
+func TestStatistics(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.AddUser("dev", "Dev12345", false)
+	ct.srv.AddProject("private", false, harbortest.AdminUsername)
+	ct.srv.AddProject("mine", false, "dev")
+	ct.srv.PushImage("library/busybox", "v1", "sha256:1111")
+	ct.srv.PushImage("private/busybox", "v1", "sha256:2222")
+
+	var st harbor.Statistics
+	ct.mustRun(&stats, &st, "statistics")
+	want := harbor.Statistics{
+		PrivateProjectCount: 2, PrivateRepoCount: 1,
+		PublicProjectCount: 1, PublicRepoCount: 1,
+		TotalProjectCount: 3, TotalRepoCount: 2,
+	}
+	if st != want {
+		t.Errorf("statistics of admin: got %+v, want %+v", st, want)
+	}
+
+	ct.as("dev", "Dev12345")
+	st = harbor.Statistics{}
+	ct.mustRun(&stats, &st, "statistics")
+	want = harbor.Statistics{PrivateProjectCount: 1, PublicProjectCount: 1, PublicRepoCount: 1}
+	if st != want {
+		t.Errorf("statistics of dev: got %+v, want %+v", st, want)
+	}
+
+	ct.as("", "")
+	ct.wantErr(harbor.ErrUnauthorized, &stats, "statistics")
+}
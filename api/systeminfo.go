@@ -1,21 +1,20 @@
 package api
 
 import (
-	"fmt"
-
+	"github.com/moooofly/harbor-go-client/harbor"
 	"github.com/moooofly/harbor-go-client/utils"
 )
 
 func init() {
-	utils.Parser.AddCommand("sysinfo_general",
+	utils.AddCommand("system info", "sysinfo_general",
 		"Get general system info.",
 		"This API is for retrieving general system info, this can be called by anonymous request.",
 		&sysGeneral)
-	utils.Parser.AddCommand("sysinfo_volumes",
+	utils.AddCommand("system volumes", "sysinfo_volumes",
 		"Get system volume info (total/free size).",
 		"This endpoint is for retrieving system volume info that only provides for admin user.",
 		&sysVolumes)
-	utils.Parser.AddCommand("sysinfo_rootcert",
+	utils.AddCommand("system rootcert", "sysinfo_rootcert",
 		"Get default root certificate under OVA deployment.",
 		"This endpoint is for downloading a default root certificate that only provides for admin user under OVA deployment.",
 		&sysRootCert)
@@ -27,8 +26,9 @@ type sysInfoGeneral struct {
 var sysGeneral sysInfoGeneral
 
 func (x *sysInfoGeneral) Execute(args []string) error {
-	GetSysGeneral(utils.URLGen("/api/systeminfo"))
-	return nil
+	return utils.Run(func(c *harbor.Client) (interface{}, error) {
+		return c.GetSystemInfo()
+	})
 }
 
 type sysInfoVolumes struct {
@@ -37,8 +37,9 @@ type sysInfoVolumes struct {
 var sysVolumes sysInfoVolumes
 
 func (x *sysInfoVolumes) Execute(args []string) error {
-	GetSysVolumes(utils.URLGen("/api/systeminfo/volumes"))
-	return nil
+	return utils.Run(func(c *harbor.Client) (interface{}, error) {
+		return c.GetSystemVolumes()
+	})
 }
 
 type sysInfoRootCert struct {
@@ -47,54 +48,7 @@ type sysInfoRootCert struct {
 var sysRootCert sysInfoRootCert
 
 func (x *sysInfoRootCert) Execute(args []string) error {
-	GetSysRootCert(utils.URLGen("/api/systeminfo/getcert"))
-	return nil
-}
-
-// GetSysGeneral is for retrieving general system info, this can be called by anonymous request.
-//
-// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/systeminfo'
-func GetSysGeneral(baseURL string) {
-	targetURL := baseURL
-	fmt.Println("==> GET", targetURL)
-
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn").
-		End(utils.PrintStatus)
-}
-
-// GetSysVolumes is for retrieving system volume info that only provides for admin user.
-//
-// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/systeminfo/volumes'
-func GetSysVolumes(baseURL string) {
-	targetURL := baseURL
-	fmt.Println("==> GET", targetURL)
-
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("error:", err)
-		return
-	}
-
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
-}
-
-// GetSysRootCert is for downloading a default root certificate that only provides for admin user under OVA deployment.
-//
-// e.g. curl -X GET --header 'Accept: text/plain' 'https://localhost/api/systeminfo/getcert'
-func GetSysRootCert(baseURL string) {
-	targetURL := baseURL
-	fmt.Println("==> GET", targetURL)
-
-	c, err := utils.CookieLoad()
-	if err != nil {
-		fmt.Println("error:", err)
-		return
-	}
-
-	utils.Request.Get(targetURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		End(utils.PrintStatus)
+	return utils.Run(func(c *harbor.Client) (interface{}, error) {
+		return c.GetRootCert()
+	})
 }
@@ -1 +1,30 @@
 package api
+
+import (
+	"testing"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/harbortest"
+)
+
+func TestSystemInfo(t *testing.T) {
+	ct := newCmdTest(t)
+
+	ct.as("", "")
+	var info harbor.SystemInfo
+	ct.mustRun(&sysGeneral, &info, "sysinfo_general")
+	if info.HarborVersion != harbortest.Version || info.AuthMode != "db_auth" {
+		t.Errorf("sysinfo_general: got %+v", info)
+	}
+	ct.wantErr(harbor.ErrUnauthorized, &sysVolumes, "sysinfo_volumes")
+
+	ct.as(harbortest.AdminUsername, harbortest.AdminPassword)
+	var v harbor.SystemVolumes
+	ct.mustRun(&sysVolumes, &v, "sysinfo_volumes")
+	if v.Storage.Total == 0 || v.Storage.Free > v.Storage.Total {
+		t.Errorf("sysinfo_volumes: got %+v", v)
+	}
+
+	// no CA certificate over HTTP
+	ct.wantErr(harbor.ErrNotFound, &sysRootCert, "sysinfo_rootcert")
+}
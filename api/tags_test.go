@@ -1 +1,125 @@
 package api
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/harbortest"
+	"github.com/moooofly/harbor-go-client/utils"
+)
+
+func TestTags(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.PushImage("library/busybox", "v1", "sha256:1111")
+	ct.srv.PushImage("library/busybox", "latest", "sha256:1111")
+	ct.srv.PushImage("library/busybox", "v2", "sha256:2222")
+
+	var tags []*harbor.Tag
+	ct.mustRun(&tagslist, &tags, "tags_list", "-n", "library/busybox")
+	if len(tags) != 3 {
+		t.Errorf("tags_list: got %d tags, want 3", len(tags))
+	}
+
+	var tag harbor.Tag
+	ct.mustRun(&tagget, &tag, "tag_get", "-n", "library/busybox", "-t", "v2")
+	if tag.Digest != "sha256:2222" {
+		t.Errorf("tag_get: got %+v", tag)
+	}
+	ct.wantErr(harbor.ErrNotFound, &tagget, "tag_get", "-n", "library/busybox", "-t", "v3")
+
+	// all the tags of the same digest go together, so only by --force
+	ct.wantErr(utils.ErrProtected, &tagdel, "tag_del", "--yes", "-n", "library/busybox", "-t", "v1")
+	if got := ct.srv.Tags("library/busybox"); len(got) != 3 {
+		t.Fatalf("tags after refused tag_del: got %v", got)
+	}
+	// latest is protected, which v1 would delete on Harbor 1.x
+	yml := "protected:\n  repositories:\n  - library/busybox:latest\n"
+	config := filepath.Join(ct.dir, "config.yaml")
+	if err := ioutil.WriteFile(config, []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := ct.run(&tagdel, "tag_del", "--yes", "-n", "library/busybox", "-t", "v1")
+	if !errors.Is(err, utils.ErrProtected) || !strings.Contains(err.Error(), "tag latest matches library/busybox:latest") {
+		t.Fatalf("tag_del of a digest shared by a protected tag: got %v", err)
+	}
+	if err := os.Remove(config); err != nil {
+		t.Fatal(err)
+	}
+	ct.mustRun(&tagdel, nil, "tag_del", "--yes", "--force", "-n", "library/busybox", "-t", "v1")
+	if got := ct.srv.Tags("library/busybox"); len(got) != 1 || got[0] != "v2" {
+		t.Errorf("tags after tag_del: got %v, want [v2]", got)
+	}
+
+	ct.as("", "")
+	ct.wantErr(harbor.ErrUnauthorized, &tagdel, "tag_del", "--yes", "-n", "library/busybox", "-t", "v2")
+}
+
+func TestTagDeleteConfirmation(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.PushImage("library/busybox", "v1", "sha256:1111")
+	ct.srv.PushImage("library/busybox", "v2", "sha256:2222")
+
+	// no answer at all, e.g. stdin is not a terminal
+	ct.wantErr(utils.ErrAborted, &tagdel, "tag_del", "-n", "library/busybox", "-t", "v1")
+
+	utils.Stdin = strings.NewReader("n\n")
+	ct.wantErr(utils.ErrAborted, &tagdel, "tag_del", "-n", "library/busybox", "-t", "v1")
+	if got := ct.srv.Tags("library/busybox"); len(got) != 2 {
+		t.Fatalf("tags after declining tag_del: got %v", got)
+	}
+
+	utils.Stdin = strings.NewReader("y\n")
+	ct.mustRun(&tagdel, nil, "tag_del", "-n", "library/busybox", "-t", "v1")
+	if got := ct.srv.Tags("library/busybox"); len(got) != 1 || got[0] != "v2" {
+		t.Errorf("tags after confirming tag_del: got %v, want [v2]", got)
+	}
+}
+
+func TestDeleteProtected(t *testing.T) {
+	ct := newCmdTest(t)
+	yml := "protected:\n  repositories:\n  - library/*\n  labels:\n  - protected\n"
+	if err := ioutil.WriteFile(filepath.Join(ct.dir, "config.yaml"), []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	prj := ct.srv.AddProject("prj", false, harbortest.AdminUsername)
+	ct.srv.PushImage("library/busybox", "v1", "sha256:1111")
+	ct.srv.PushImage("prj/app", "v1", "sha256:2222")
+	ct.srv.PushImage("prj/app", "v2", "sha256:3333")
+	l := ct.srv.AddLabel("protected", "")
+	ct.srv.LabelImage("prj/app", "v1", l.ID)
+
+	for _, args := range [][]string{
+		{"tag_del", "--yes", "-n", "library/busybox", "-t", "v1"},
+		{"repo_del", "--yes", "-n", "library/busybox"},
+		{"tag_del", "--yes", "-n", "prj/app", "-t", "v1"},
+		{"repo_del", "--yes", "-n", "prj/app"},
+	} {
+		cmd := interface{}(&tagdel)
+		if args[0] == "repo_del" {
+			cmd = &repoDel
+		}
+		ct.wantErr(utils.ErrProtected, cmd, args...)
+	}
+	ct.wantErr(utils.ErrProtected, &prjDel, "prj_del", "--yes", "-j", strconv.Itoa(ct.srv.Project("library").ProjectID))
+	ct.wantErr(utils.ErrProtected, &labeldel, "label_del_by_id", "--yes", "-i", strconv.Itoa(l.ID))
+	if got := ct.srv.Tags("prj/app"); len(got) != 2 {
+		t.Fatalf("tags of prj/app: got %v", got)
+	}
+
+	// tags not labelled are not protected
+	ct.mustRun(&tagdel, nil, "tag_del", "--yes", "-n", "prj/app", "-t", "v2")
+
+	// --force still asks for the confirmation
+	ct.wantErr(utils.ErrAborted, &tagdel, "tag_del", "--force", "-n", "prj/app", "-t", "v1")
+	ct.mustRun(&repoDel, nil, "repo_del", "--force", "--yes", "-n", "prj/app")
+	ct.mustRun(&prjDel, nil, "prj_del", "--yes", "-j", strconv.Itoa(prj.ProjectID))
+	if ct.srv.Repository("prj/app") != nil || ct.srv.Project("prj") != nil {
+		t.Error("prj/app and prj are not deleted")
+	}
+}
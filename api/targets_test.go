@@ -1 +1,67 @@
 package api
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/harbortest"
+)
+
+func TestTargets(t *testing.T) {
+	ct := newCmdTest(t)
+
+	ct.mustRun(&tping, nil, "targets_ping", "-e", "https://10.0.0.1", "-u", "admin", "-p", "Harbor12345", "-x")
+	ct.mustRun(&tc, nil, "targets_create", "-e", "https://10.0.0.1", "-n", "backup", "-u", "admin", "-p", "Harbor12345", "-x")
+	ct.wantErr(harbor.ErrConflict, &tc, "targets_create", "-e", "https://10.0.0.2", "-n", "backup", "-u", "admin", "-p", "Harbor12345", "-x")
+
+	var targets []*harbor.Target
+	ct.mustRun(&tl, &targets, "targets_list", "-n", "back")
+	if len(targets) != 1 || targets[0].Password != "" {
+		t.Fatalf("targets_list: got %+v", targets)
+	}
+	id := strconv.Itoa(targets[0].ID)
+
+	ct.mustRun(&tpingByID, nil, "targets_ping_by_tid", "-i", id)
+	ct.mustRun(&tuByID, nil, "targets_update_by_tid", "-i", id, "-e", "https://10.0.0.3", "-n", "backup2", "-u", "admin", "-p", "x", "-x")
+	var target harbor.Target
+	ct.mustRun(&tgByID, &target, "targets_get_by_tid", "-i", id)
+	if target.Name != "backup2" || target.Endpoint != "https://10.0.0.3" {
+		t.Errorf("targets_get_by_tid after targets_update_by_tid: got %+v", target)
+	}
+	target = harbor.Target{}
+	ct.mustRun(&tgByID, &target, "targets_get_by_tid", "--target", "backup2")
+	if strconv.Itoa(target.ID) != id {
+		t.Errorf("targets_get_by_tid --target: got %+v", target)
+	}
+	ct.wantErr(harbor.ErrNotFound, &tgByID, "targets_get_by_tid", "--target", "backup")
+
+	// a target in use can not be deleted
+	ct.srv.AddPolicy("sync", "library", "backup2")
+	var policies []*harbor.ReplicationPolicy
+	ct.mustRun(&tpoliciesByID, &policies, "targets_policies_by_tid", "-i", id)
+	if len(policies) != 1 || policies[0].Name != "sync" {
+		t.Errorf("targets_policies_by_tid: got %+v", policies)
+	}
+	ct.wantErr(harbor.ErrBadRequest, &tdByID, "targets_delete_by_tid", "-i", id)
+
+	ct.srv.AddTarget("unused", "https://10.0.0.4")
+	unused := strconv.Itoa(ct.srv.Target("unused").ID)
+	ct.wantErr(harbor.ErrNotFound, &tdByID, "targets_delete_by_tid", "--target", "unuse")
+	ct.mustRun(&tdByID, nil, "targets_delete_by_tid", "--target", "unused")
+	ct.wantErr(harbor.ErrNotFound, &tgByID, "targets_get_by_tid", "-i", unused)
+}
+
+func TestTargetsForbidden(t *testing.T) {
+	ct := newCmdTest(t)
+	ct.srv.AddUser("dev", "Dev12345", false)
+
+	ct.as("dev", "Dev12345")
+	ct.wantErr(harbor.ErrForbidden, &tl, "targets_list")
+
+	ct.as("", "")
+	ct.wantErr(harbor.ErrUnauthorized, &tl, "targets_list")
+
+	ct.as(harbortest.AdminUsername, harbortest.AdminPassword)
+	ct.mustRun(&tl, nil, "targets_list")
+}
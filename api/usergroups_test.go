@@ -0,0 +1,31 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+func TestUserGroups(t *testing.T) {
+	ct := newCmdTest(t)
+
+	ct.mustRun(&ugCreate, nil, "usergroup_create", "-n", "devs", "-l", "cn=devs,dc=mydomain,dc=com")
+	ct.wantErr(harbor.ErrConflict, &ugCreate, "usergroup_create", "-n", "devs")
+
+	var groups []*harbor.UserGroup
+	ct.mustRun(&ugList, &groups, "usergroups_list")
+	if len(groups) != 1 || groups[0].GroupName != "devs" {
+		t.Fatalf("usergroups_list: got %+v", groups)
+	}
+
+	ct.mustRun(&ugUpdate, nil, "usergroup_update", "-i", "1", "-n", "ops")
+	var g harbor.UserGroup
+	ct.mustRun(&ugGet, &g, "usergroup_get", "--group", "ops")
+	if g.ID != 1 || g.GroupName != "ops" {
+		t.Errorf("usergroup_get after usergroup_update: got %+v", g)
+	}
+
+	ct.wantErr(harbor.ErrNotFound, &ugDel, "usergroup_del", "--yes", "--group", "devs")
+	ct.mustRun(&ugDel, nil, "usergroup_del", "--yes", "--group", "ops")
+	ct.wantErr(harbor.ErrNotFound, &ugGet, "usergroup_get", "-i", "1")
+}
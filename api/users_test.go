@@ -1 +1,111 @@
 package api
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/harbortest"
+)
+
+func TestUserCreateGetDelete(t *testing.T) {
+	ct := newCmdTest(t)
+
+	ct.mustRun(&usrCreate, nil, "user_create", "--user_id", "0", "--username", "dev",
+		"--password", "Dev12345", "--email", "dev@mydomain.com", "--has_admin_role", "0")
+	ct.wantErr(harbor.ErrConflict, &usrCreate, "user_create", "--user_id", "0", "--username", "dev",
+		"--password", "Dev12345", "--email", "dev@mydomain.com", "--has_admin_role", "0")
+
+	u := ct.srv.User("dev")
+	if u == nil || u.HasAdminRole {
+		t.Fatalf("user_create: got %+v", u)
+	}
+	id := strconv.Itoa(u.UserID)
+
+	var got harbor.User
+	ct.mustRun(&usrGet, &got, "user_get", "-i", id)
+	if got.Email != "dev@mydomain.com" {
+		t.Errorf("user_get: got %+v", got)
+	}
+	got = harbor.User{}
+	ct.mustRun(&usrGet, &got, "user_get", "--user", "dev")
+	if strconv.Itoa(got.UserID) != id {
+		t.Errorf("user_get --user: got %+v", got)
+	}
+	ct.wantErr(harbor.ErrNotFound, &usrGet, "user_get", "--user", "de")
+
+	ct.mustRun(&usrUpdate, nil, "user_update", "-i", id, "-e", "new@mydomain.com", "-r", "Dev", "-m", "hi")
+	if u := ct.srv.User("dev"); u.Email != "new@mydomain.com" || u.Realname != "Dev" {
+		t.Errorf("user_update: got %+v", u)
+	}
+
+	ct.mustRun(&usrUpdateRole, nil, "user_update_role", "-i", id, "-r", "1")
+	if u := ct.srv.User("dev"); !u.HasAdminRole {
+		t.Error("user_update_role did not make an admin")
+	}
+
+	ct.wantErr(harbor.ErrNotFound, &usrDelete, "user_delete", "--yes", "--user", "de")
+	ct.mustRun(&usrDelete, nil, "user_delete", "--yes", "--user", "dev")
+	if ct.srv.User("dev") != nil {
+		t.Error("user_delete did not delete the user")
+	}
+	ct.wantErr(harbor.ErrNotFound, &usrGet, "user_get", "-i", id)
+}
+
+func TestUserPassword(t *testing.T) {
+	ct := newCmdTest(t)
+	id := strconv.Itoa(ct.srv.AddUser("dev", "Dev12345", false).UserID)
+
+	ct.as("dev", "Dev12345")
+	ct.wantErr(harbor.ErrForbidden, &usrUpdatePassword, "user_update_password", "-i", id, "-o", "wrong", "-n", "New12345")
+	ct.mustRun(&usrUpdatePassword, nil, "user_update_password", "-i", id, "-o", "Dev12345", "-n", "New12345")
+
+	ct.wantErr(harbor.ErrUnauthorized, &usrCurrent, "whoami")
+	ct.as("dev", "New12345")
+	var u harbor.User
+	ct.mustRun(&usrCurrent, &u, "whoami")
+	if u.Username != "dev" {
+		t.Errorf("whoami: got %+v", u)
+	}
+}
+
+func TestUsersSearch(t *testing.T) {
+	ct := newCmdTest(t)
+	for i := 0; i < 11; i++ {
+		ct.srv.AddUser("dev"+strconv.Itoa(i), "Dev12345", false)
+	}
+
+	var users []*harbor.User
+	ct.mustRun(&usrSearch, &users, "users_search", "-u", "dev", "--all")
+	if len(users) != 11 {
+		t.Errorf("users_search --all: got %d users, want 11", len(users))
+	}
+
+	users = nil
+	ct.mustRun(&usrSearch, &users, "users_search", "-u", "dev10")
+	if len(users) != 1 {
+		t.Errorf("users_search -u dev10: got %+v", users)
+	}
+
+	ct.as("dev0", "Dev12345")
+	ct.wantErr(harbor.ErrForbidden, &usrSearch, "users_search")
+	ct.wantErr(harbor.ErrForbidden, &usrGet, "user_get", "-i", strconv.Itoa(ct.srv.User(harbortest.AdminUsername).UserID))
+}
+
+func TestUserCreatePrintCurl(t *testing.T) {
+	ct := newCmdTest(t)
+
+	ct.mustRun(&usrCreate, nil, "--print-curl", "user_create", "--user_id", "0", "--username", "dev",
+		"--password", "Dev12345", "--email", "dev@mydomain.com", "--has_admin_role", "0")
+	if ct.srv.User("dev") != nil {
+		t.Fatal("user_create --print-curl created the user")
+	}
+	out := ct.stderr.String()
+	if !strings.HasPrefix(out, "curl -X POST ") || strings.Count(out, "\n") != 1 ||
+		!strings.Contains(out, `"password":"REDACTED"`) || strings.Contains(out, "Dev12345") ||
+		!strings.Contains(out, `"username":"dev"`) ||
+		!strings.HasSuffix(out, "'"+ct.srv.URL+"/api/users' -i\n") {
+		t.Errorf("user_create --print-curl: got %q", out)
+	}
+}
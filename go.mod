@@ -1,20 +1,11 @@
 module github.com/moooofly/harbor-go-client
 
+go 1.27
+
 require (
-	github.com/elazarl/goproxy v0.0.0-20181003060214-f58a169a71a5 // indirect
-	github.com/gopherjs/gopherjs v0.0.0-20181017120253-0766667cb4d1 // indirect
 	github.com/jessevdk/go-flags v1.4.0
-	github.com/jtolds/gls v4.2.1+incompatible // indirect
-	github.com/moul/http2curl v0.0.0-20170919181001-9ac6cf4d929b // indirect
-	github.com/parnurzeal/gorequest v0.2.15
-	github.com/pkg/errors v0.0.0-20171018195549-f15c970de5b7 // indirect
-	github.com/smartystreets/assertions v0.0.0-20180927180507-b2de0cb4f26d // indirect
-	github.com/smartystreets/goconvey v0.0.0-20180222194500-ef6db91d284a // indirect
-	golang.org/x/net v0.0.0-20171129192339-a8b929477797 // indirect
 	golang.org/x/sys v0.0.0-20171130163741-8b4580aae2a0
 	gopkg.in/yaml.v2 v2.2.1
 )
 
-replace golang.org/x/net v0.0.0-20171129192339-a8b929477797 => github.com/golang/net v0.0.0-20181101160248-e11730110bbd
-
 replace golang.org/x/sys v0.0.0-20171130163741-8b4580aae2a0 => github.com/golang/sys v0.0.0-20181031143558-9b800f95dbbc
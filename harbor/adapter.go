@@ -0,0 +1,114 @@
+package harbor
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// adapter translates the Harbor 1.5 API, which all the methods of Client are
+// written against, into one API generation.
+type adapter interface {
+	// route maps a request (path is relative to BaseURL) to this
+	// generation, or returns an *UnsupportedError.
+	route(method, path string, query url.Values) (string, url.Values, error)
+
+	// The endpoints below return different objects since Harbor 2.0.
+	listRepositories(c *Client, opt *RepositoryListOptions) ([]*Repository, error)
+	listTags(c *Client, repoName string) ([]*Tag, error)
+	getTag(c *Client, repoName, tag string) (*Tag, error)
+}
+
+// newAdapter returns the adapter of api, harborVersion is used to tell the
+// minor versions of 1.6 to 1.10 apart (1.10 if unknown).
+func newAdapter(api APIVersion, harborVersion string) (adapter, error) {
+	switch api {
+	case API15:
+		return &v15Adapter{}, nil
+	case API16:
+		minor := 10
+		if _, m, err := parseVersion(harborVersion); err == nil {
+			minor = m
+		}
+		return &v16Adapter{harborVersion: harborVersion, minor: minor}, nil
+	case API20:
+		return &v20Adapter{harborVersion: harborVersion}, nil
+	}
+	return nil, fmt.Errorf("unknown harbor API version %q", api)
+}
+
+// v15Adapter is for Harbor 1.5 and earlier, nothing to translate.
+type v15Adapter struct{}
+
+func (a *v15Adapter) route(method, path string, query url.Values) (string, url.Values, error) {
+	return path, query, nil
+}
+
+func (a *v15Adapter) listRepositories(c *Client, opt *RepositoryListOptions) ([]*Repository, error) {
+	q := url.Values{}
+	q.Set("project_id", strconv.Itoa(opt.ProjectID))
+	setIfNotEmpty(q, "q", opt.Q)
+	if opt.LabelID > 0 {
+		q.Set("label_id", strconv.Itoa(opt.LabelID))
+	}
+
+	var repos []*Repository
+	err := c.call("GET", "/api/repositories", pageQuery(q, opt.Page, opt.PageSize), nil, &repos)
+	return repos, err
+}
+
+func (a *v15Adapter) listTags(c *Client, repoName string) ([]*Tag, error) {
+	var tags []*Tag
+	err := c.call("GET", "/api/repositories/"+repoName+"/tags", nil, nil, &tags)
+	return tags, err
+}
+
+func (a *v15Adapter) getTag(c *Client, repoName, tag string) (*Tag, error) {
+	var t Tag
+	if err := c.call("GET", "/api/repositories/"+repoName+"/tags/"+tag, nil, nil, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// v16Adapter is for Harbor 1.6 to 1.10. The login pages moved under /c/ in
+// 1.7, and replication was rewritten (around registries) in 1.8.
+type v16Adapter struct {
+	v15Adapter
+	harborVersion string
+	minor         int
+}
+
+// replicationPaths are the endpoints of replication before Harbor 1.8.
+var replicationPaths = []string{
+	"/api/policies/replication",
+	"/api/targets",
+	"/api/jobs/replication",
+	"/api/replications",
+}
+
+func (a *v16Adapter) route(method, path string, query url.Values) (string, url.Values, error) {
+	if a.minor >= 7 && (path == "/login" || path == "/log_out") {
+		return "/c" + path, query, nil
+	}
+	if a.minor >= 8 && hasPathPrefix(path, replicationPaths...) {
+		return "", nil, &UnsupportedError{
+			Feature:       "replication policies, targets and jobs",
+			HarborVersion: a.harborVersion,
+			APIVersion:    API16,
+			Hint:          "replaced by registries and /api/replication/* since harbor 1.8",
+		}
+	}
+	return path, query, nil
+}
+
+// hasPathPrefix reports whether path is one of prefixes, or under one of them.
+func hasPathPrefix(path string, prefixes ...string) bool {
+	for _, p := range prefixes {
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
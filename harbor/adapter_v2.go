@@ -0,0 +1,283 @@
+package harbor
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// v20Adapter is for Harbor 2.x, whose API lives under /api/v2.0. Tags are
+// attributes of artifacts there, and repositories are nested in projects.
+type v20Adapter struct {
+	harborVersion string
+}
+
+// v20Removed are the endpoints of Harbor 1.5 without a counterpart in
+// Harbor 2.x.
+var v20Removed = []struct {
+	prefix  string
+	feature string
+	hint    string
+}{
+	{"/api/policies/replication", "replication policies", "replaced by /api/v2.0/replication/policies"},
+	{"/api/targets", "replication targets", "replaced by registries"},
+	{"/api/jobs/replication", "replication jobs", "replaced by /api/v2.0/replication/executions"},
+	{"/api/replications", "replication", "replaced by /api/v2.0/replication/executions"},
+	{"/api/jobs/scan", "scan job logs", "replaced by the scan reports of artifacts"},
+	{"/api/logs", "access logs", "replaced by /api/v2.0/audit-logs"},
+	{"/api/repositories/top", "top repositories", ""},
+	{"/api/internal/syncregistry", "registry sync", ""},
+}
+
+func (a *v20Adapter) unsupported(feature, hint string) error {
+	return &UnsupportedError{Feature: feature, HarborVersion: a.harborVersion, APIVersion: API20, Hint: hint}
+}
+
+func (a *v20Adapter) route(method, path string, query url.Values) (string, url.Values, error) {
+	switch {
+	case path == "/login" || path == "/log_out":
+		return "/c" + path, query, nil
+	case hasPathPrefix(path, "/api/v2.0"):
+		// already translated, e.g. by listTags
+		return path, query, nil
+	}
+
+	for _, r := range v20Removed {
+		if hasPathPrefix(path, r.prefix) {
+			return "", nil, a.unsupported(r.feature, r.hint)
+		}
+	}
+
+	if strings.HasPrefix(path, "/api/projects/") && strings.HasSuffix(path, "/logs") {
+		return "", nil, a.unsupported("project logs", "replaced by /api/v2.0/projects/{project_name}/logs")
+	}
+	if hasPathPrefix(path, "/api/repositories") {
+		p, err := a.routeRepository(method, strings.TrimPrefix(path, "/api/repositories"))
+		return p, query, err
+	}
+	if strings.HasPrefix(path, "/api/") {
+		return "/api/v2.0" + strings.TrimPrefix(path, "/api"), query, nil
+	}
+	return path, query, nil
+}
+
+// routeRepository maps /api/repositories/{repo_name}[/tags/{tag}[/labels[/{id}]|/scan]]
+// to /api/v2.0/projects/{project}/repositories/{repo}[/artifacts/{tag}[...]],
+// but deleting a tag, which is /artifacts/{tag}/tags/{tag}.
+func (a *v20Adapter) routeRepository(method, rest string) (string, error) {
+	rest = strings.TrimPrefix(rest, "/")
+	if rest == "" {
+		// listRepositories does it
+		return "", a.unsupported("listing repositories without a project", "")
+	}
+
+	var repoName, tagPart string
+	if i := strings.Index(rest, "/tags/"); i >= 0 {
+		repoName, tagPart = rest[:i], rest[i+len("/tags/"):]
+	} else {
+		repoName = rest
+		for _, sub := range []string{"/tags", "/labels", "/signatures"} {
+			if strings.HasSuffix(rest, sub) || strings.Contains(rest, sub+"/") {
+				switch sub {
+				case "/tags":
+					return "", a.unsupported("listing tags by path", "")
+				case "/labels":
+					return "", a.unsupported("labels of repositories", "labels are attached to artifacts")
+				default:
+					return "", a.unsupported("notary signatures", "")
+				}
+			}
+		}
+	}
+
+	base, err := v20RepositoryPath(repoName)
+	if err != nil {
+		return "", err
+	}
+	if tagPart == "" {
+		return base, nil
+	}
+
+	tag, sub := tagPart, ""
+	if i := strings.Index(tagPart, "/"); i >= 0 {
+		tag, sub = tagPart[:i], tagPart[i+1:]
+	}
+	switch {
+	case sub == "" && method == "DELETE":
+		// deleting the artifact would delete all its tags
+		return base + "/artifacts/" + url.PathEscape(tag) + "/tags/" + url.PathEscape(tag), nil
+	case sub == "":
+		return base + "/artifacts/" + url.PathEscape(tag), nil
+	case hasPathPrefix(sub, "labels") && method != "GET":
+		return base + "/artifacts/" + url.PathEscape(tag) + "/" + sub, nil
+	case hasPathPrefix(sub, "labels"):
+		return "", a.unsupported("listing labels of a tag", "labels are returned with the tag")
+	case sub == "scan" && method == "POST":
+		return base + "/artifacts/" + url.PathEscape(tag) + "/scan", nil
+	case sub == "manifest":
+		return "", a.unsupported("manifests of tags", "")
+	case sub == "vulnerability/details":
+		return "", a.unsupported("vulnerabilities of tags", "they are an addition of the artifact")
+	}
+	return "", a.unsupported("/api/repositories/"+rest, "")
+}
+
+// v20RepositoryPath returns the path of repoName, e.g. "library/a/b" is
+// /api/v2.0/projects/library/repositories/a%252Fb (encoded twice, as Harbor
+// 2.x requires).
+func v20RepositoryPath(repoName string) (string, error) {
+	i := strings.Index(repoName, "/")
+	if i <= 0 || i == len(repoName)-1 {
+		return "", fmt.Errorf("invalid repository name %q, expect <project>/<repository>", repoName)
+	}
+	project, repo := repoName[:i], repoName[i+1:]
+	return "/api/v2.0/projects/" + url.PathEscape(project) + "/repositories/" +
+		url.PathEscape(url.PathEscape(repo)), nil
+}
+
+// v20Repository is a repository of Harbor 2.x.
+type v20Repository struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	ProjectID     int    `json:"project_id"`
+	Description   string `json:"description"`
+	PullCount     int    `json:"pull_count"`
+	ArtifactCount int    `json:"artifact_count"`
+	CreationTime  string `json:"creation_time"`
+	UpdateTime    string `json:"update_time"`
+}
+
+func (a *v20Adapter) listRepositories(c *Client, opt *RepositoryListOptions) ([]*Repository, error) {
+	if opt.LabelID > 0 {
+		return nil, a.unsupported("filtering repositories by label", "")
+	}
+
+	prj, err := c.GetProject(opt.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	q := url.Values{}
+	if opt.Q != "" {
+		q.Set("q", "name=~"+opt.Q)
+	}
+
+	var v2repos []*v20Repository
+	err = c.call("GET", "/api/v2.0/projects/"+url.PathEscape(prj.Name)+"/repositories",
+		pageQuery(q, opt.Page, opt.PageSize), nil, &v2repos)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]*Repository, 0, len(v2repos))
+	for _, r := range v2repos {
+		// artifact_count is not the number of tags, an artifact has any
+		tags, err := a.listTags(c, r.Name)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, &Repository{
+			ID:           r.ID,
+			Name:         r.Name,
+			ProjectID:    r.ProjectID,
+			Description:  r.Description,
+			PullCount:    r.PullCount,
+			TagsCount:    len(tags),
+			CreationTime: r.CreationTime,
+			UpdateTime:   r.UpdateTime,
+		})
+	}
+	return repos, nil
+}
+
+// v20Artifact is an artifact of Harbor 2.x, which is what a manifest is in
+// Harbor 1.x.
+type v20Artifact struct {
+	Digest     string `json:"digest"`
+	Size       int64  `json:"size"`
+	PushTime   string `json:"push_time"`
+	ExtraAttrs struct {
+		Architecture string `json:"architecture"`
+		OS           string `json:"os"`
+		Author       string `json:"author"`
+		Created      string `json:"created"`
+	} `json:"extra_attrs"`
+	Tags []struct {
+		Name     string `json:"name"`
+		PushTime string `json:"push_time"`
+		PullTime string `json:"pull_time"`
+		Signed   bool   `json:"signed"`
+	} `json:"tags"`
+	Labels []*Label `json:"labels"`
+}
+
+// tags returns a Tag for every tag of the artifact.
+func (art *v20Artifact) tags() []*Tag {
+	created := art.ExtraAttrs.Created
+	if created == "" {
+		created = art.PushTime
+	}
+
+	var tags []*Tag
+	for _, t := range art.Tags {
+		tags = append(tags, &Tag{
+			Digest:       art.Digest,
+			Name:         t.Name,
+			Size:         art.Size,
+			Architecture: art.ExtraAttrs.Architecture,
+			OS:           art.ExtraAttrs.OS,
+			Author:       art.ExtraAttrs.Author,
+			Created:      created,
+			Labels:       art.Labels,
+			PushTime:     t.PushTime,
+			PullTime:     t.PullTime,
+		})
+	}
+	return tags
+}
+
+var v20ArtifactQuery = url.Values{
+	"with_tag":   {"true"},
+	"with_label": {"true"},
+}
+
+func (a *v20Adapter) listTags(c *Client, repoName string) ([]*Tag, error) {
+	base, err := v20RepositoryPath(repoName)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []*Tag
+	p := c.NewPager(base+"/artifacts", v20ArtifactQuery, 1, MaxPageSize)
+	for {
+		var arts []*v20Artifact
+		if !p.Next(&arts) {
+			break
+		}
+		for _, art := range arts {
+			tags = append(tags, art.tags()...)
+		}
+	}
+	return tags, p.Err()
+}
+
+func (a *v20Adapter) getTag(c *Client, repoName, tag string) (*Tag, error) {
+	base, err := v20RepositoryPath(repoName)
+	if err != nil {
+		return nil, err
+	}
+
+	var art v20Artifact
+	if err := c.call("GET", base+"/artifacts/"+url.PathEscape(tag), v20ArtifactQuery, nil, &art); err != nil {
+		return nil, err
+	}
+	for _, t := range art.tags() {
+		if t.Name == tag {
+			return t, nil
+		}
+	}
+	// tag was a digest
+	if tags := art.tags(); len(tags) > 0 {
+		return tags[0], nil
+	}
+	return &Tag{Digest: art.Digest, Size: art.Size, Created: art.PushTime, Labels: art.Labels}, nil
+}
@@ -0,0 +1,181 @@
+package harbor
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestV20Route(t *testing.T) {
+	a := &v20Adapter{harborVersion: "v2.1.0"}
+	for _, tt := range []struct {
+		method, path string
+		want         string // empty if unsupported
+	}{
+		{"POST", "/login", "/c/login"},
+		{"GET", "/log_out", "/c/log_out"},
+		{"GET", "/api/projects", "/api/v2.0/projects"},
+		{"GET", "/api/v2.0/projects/library/repositories", "/api/v2.0/projects/library/repositories"},
+		{"DELETE", "/api/repositories/library/a/b", "/api/v2.0/projects/library/repositories/a%252Fb"},
+		{"GET", "/api/repositories/library/nginx/tags/1.0", "/api/v2.0/projects/library/repositories/nginx/artifacts/1.0"},
+		{"DELETE", "/api/repositories/library/nginx/tags/1.0", "/api/v2.0/projects/library/repositories/nginx/artifacts/1.0/tags/1.0"},
+		{"POST", "/api/repositories/library/nginx/tags/1.0/labels", "/api/v2.0/projects/library/repositories/nginx/artifacts/1.0/labels"},
+		{"DELETE", "/api/repositories/library/nginx/tags/1.0/labels/3", "/api/v2.0/projects/library/repositories/nginx/artifacts/1.0/labels/3"},
+		{"GET", "/api/repositories/library/nginx/tags/1.0/labels", ""},
+		{"POST", "/api/repositories/library/nginx/tags/1.0/scan", "/api/v2.0/projects/library/repositories/nginx/artifacts/1.0/scan"},
+		{"GET", "/api/repositories/library/nginx/tags/1.0/manifest", ""},
+		{"GET", "/api/repositories/library/nginx/tags/1.0/vulnerability/details", ""},
+		{"GET", "/api/repositories/library/nginx/labels", ""},
+		{"GET", "/api/repositories/library/nginx/signatures", ""},
+		{"GET", "/api/repositories", ""},
+		{"GET", "/api/repositories/top", ""},
+		{"GET", "/api/targets", ""},
+		{"GET", "/api/policies/replication/1", ""},
+		{"GET", "/api/logs", ""},
+		{"GET", "/api/projects/1/logs", ""},
+	} {
+		got, _, err := a.route(tt.method, tt.path, nil)
+		if tt.want == "" {
+			if !errors.Is(err, ErrUnsupported) {
+				t.Errorf("%s %s: got %q, %v, want unsupported", tt.method, tt.path, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s %s: got %q, %v, want %q", tt.method, tt.path, got, err, tt.want)
+		}
+	}
+}
+
+func TestV20RepositoryPath(t *testing.T) {
+	for _, tt := range []struct {
+		repoName, want string
+	}{
+		{"library/nginx", "/api/v2.0/projects/library/repositories/nginx"},
+		{"library/a/b", "/api/v2.0/projects/library/repositories/a%252Fb"},
+		{"library/a/b/c", "/api/v2.0/projects/library/repositories/a%252Fb%252Fc"},
+		{"nginx", ""},
+		{"/nginx", ""},
+		{"library/", ""},
+	} {
+		got, err := v20RepositoryPath(tt.repoName)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%q: got %q, want an error", tt.repoName, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%q: got %q, %v, want %q", tt.repoName, got, err, tt.want)
+		}
+	}
+}
+
+func TestV20ArtifactTags(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		artifact string
+		want     []*Tag
+	}{
+		{
+			name: "tags share the artifact",
+			artifact: `{"digest": "sha256:1", "size": 10, "push_time": "2020-01-02T00:00:00Z",
+				"extra_attrs": {"architecture": "amd64", "os": "linux", "author": "dev", "created": "2020-01-01T00:00:00Z"},
+				"tags": [{"name": "1.0", "push_time": "2020-01-02T00:00:00Z", "pull_time": "2020-01-03T00:00:00Z"},
+					{"name": "latest", "push_time": "2020-01-04T00:00:00Z"}]}`,
+			want: []*Tag{
+				{Digest: "sha256:1", Name: "1.0", Size: 10, Architecture: "amd64", OS: "linux", Author: "dev", Created: "2020-01-01T00:00:00Z",
+					PushTime: "2020-01-02T00:00:00Z", PullTime: "2020-01-03T00:00:00Z"},
+				{Digest: "sha256:1", Name: "latest", Size: 10, Architecture: "amd64", OS: "linux", Author: "dev", Created: "2020-01-01T00:00:00Z",
+					PushTime: "2020-01-04T00:00:00Z"},
+			},
+		},
+		{
+			name:     "created defaults to the push time",
+			artifact: `{"digest": "sha256:2", "push_time": "2020-01-02T00:00:00Z", "tags": [{"name": "1.1"}]}`,
+			want:     []*Tag{{Digest: "sha256:2", Name: "1.1", Created: "2020-01-02T00:00:00Z"}},
+		},
+		{
+			name:     "untagged",
+			artifact: `{"digest": "sha256:3", "tags": null}`,
+		},
+	} {
+		var art v20Artifact
+		if err := json.Unmarshal([]byte(tt.artifact), &art); err != nil {
+			t.Fatal(err)
+		}
+		if got := art.tags(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// v20Server emulates the few endpoints of Harbor 2.x used by the tests,
+// recording the requests.
+func v20Server(t *testing.T, requests *[]string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.Method+" "+r.URL.EscapedPath())
+		var v interface{}
+		switch r.Method + " " + r.URL.EscapedPath() {
+		case "GET /api/v2.0/systeminfo":
+			v = map[string]string{"harbor_version": "v2.1.0-0b5e7a1c"}
+		case "GET /api/v2.0/projects/1":
+			v = map[string]interface{}{"project_id": 1, "name": "library"}
+		case "GET /api/v2.0/projects/library/repositories":
+			v = []map[string]interface{}{{"id": 1, "name": "library/a/b", "project_id": 1, "artifact_count": 2}}
+		case "GET /api/v2.0/projects/library/repositories/a%252Fb/artifacts":
+			v = []map[string]interface{}{
+				{"digest": "sha256:1", "tags": []map[string]string{{"name": "1.0"}, {"name": "latest"}}},
+				{"digest": "sha256:2", "tags": []map[string]string{{"name": "0.9"}}},
+			}
+		case "DELETE /api/v2.0/projects/library/repositories/a%252Fb/artifacts/1.0/tags/1.0":
+			return
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestV20Client(t *testing.T) {
+	var requests []string
+	srv := v20Server(t, &requests)
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// /api/systeminfo is not found, /api/v2.0/systeminfo is the fallback
+	if err := c.DetectVersion(); err != nil {
+		t.Fatal(err)
+	}
+	if c.APIVersion != API20 || c.HarborVersion != "v2.1.0-0b5e7a1c" {
+		t.Fatalf("got harbor %s, API %s", c.HarborVersion, c.APIVersion)
+	}
+	if requests[0] != "GET /api/systeminfo" || requests[1] != "GET /api/v2.0/systeminfo" {
+		t.Errorf("got requests %v", requests)
+	}
+
+	repos, err := c.ListRepositories(&RepositoryListOptions{ProjectID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 1 || repos[0].Name != "library/a/b" || repos[0].TagsCount != 3 {
+		t.Errorf("got repositories %+v", repos)
+	}
+
+	requests = nil
+	if err := c.DeleteTag("library/a/b", "1.0"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "DELETE /api/v2.0/projects/library/repositories/a%252Fb/artifacts/1.0/tags/1.0"; len(requests) != 1 || requests[0] != want {
+		t.Errorf("got requests %v, want %s", requests, want)
+	}
+}
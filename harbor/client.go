@@ -0,0 +1,373 @@
+package harbor
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SessionCookie is the name of the cookie Harbor uses to track a login session.
+const SessionCookie = "beegosessionID"
+
+// Logger is used by Client to trace requests and responses.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Client manages communication with a Harbor instance.
+type Client struct {
+	// BaseURL is the root URL of Harbor, e.g. https://localhost.
+	BaseURL *url.URL
+
+	// SessionID is the value of beegosessionID sent along with every request.
+	// It is set by Login and cleared by Logout.
+	SessionID string
+
+	// Logger traces every request and response if not nil.
+	Logger Logger
+
+	// Reauth, if not nil, is called when a request carrying a session is
+	// answered with 401 Unauthorized, which usually means the session has
+	// expired. If it succeeds (e.g. by calling Login), the request is sent
+	// once more with the new session.
+	Reauth func(c *Client) error
+
+	// MaxRetries is the number of times an idempotent request (GET, HEAD,
+	// PUT, DELETE) is sent again after a network error, or a response of 429,
+	// 502, 503 or 504. Zero means never retry.
+	MaxRetries int
+
+	// RetryWait is the wait before the first retry, which is doubled by every
+	// retry up to RetryMaxWait. A random jitter is applied to all waits.
+	RetryWait    time.Duration
+	RetryMaxWait time.Duration
+
+	// APIVersion is the API generation of the server, which decides how
+	// requests are translated. It is detected by the first request (see
+	// DetectVersion) if not set.
+	APIVersion APIVersion
+
+	// HarborVersion is the harbor_version of the server, set by
+	// DetectVersion.
+	HarborVersion string
+
+	client         *http.Client
+	adapter        adapter
+	adapterVersion APIVersion
+}
+
+// ErrorResponse reports a response with a non-2xx status code.
+type ErrorResponse struct {
+	Response *http.Response // HTTP response that caused this error
+	Body     []byte         // raw response body
+}
+
+func (r *ErrorResponse) Error() string {
+	msg := fmt.Sprintf("%v %v: %v", r.Response.Request.Method, r.Response.Request.URL, r.Response.Status)
+	if body := strings.TrimSpace(string(r.Body)); body != "" {
+		msg += " " + body
+	}
+	return msg
+}
+
+// NewClient returns a new Harbor client for baseURL. If httpClient is nil,
+// http.DefaultClient is used.
+func NewClient(baseURL string, httpClient *http.Client) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid harbor URL %q, expect scheme://host[:port]", baseURL)
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		BaseURL:      u,
+		RetryWait:    500 * time.Millisecond,
+		RetryMaxWait: 10 * time.Second,
+		client:       httpClient,
+	}, nil
+}
+
+func (c *Client) logf(format string, v ...interface{}) {
+	if c.Logger != nil {
+		c.Logger.Printf(format, v...)
+	}
+}
+
+// newRequest creates a request for path (relative to BaseURL, as of Harbor
+// 1.5) with the optional query, translated for the API generation of the
+// server. A non-nil body is encoded as JSON.
+func (c *Client) newRequest(method, path string, query url.Values, body interface{}) (*http.Request, error) {
+	a, err := c.getAdapter()
+	if err != nil {
+		return nil, err
+	}
+	path, query, err = a.route(method, path, query)
+	if err != nil {
+		return nil, err
+	}
+	return c.newRawRequest(method, path, query, body)
+}
+
+// newRawRequest is the same as newRequest, without any translation.
+func (c *Client) newRawRequest(method, path string, query url.Values, body interface{}) (*http.Request, error) {
+	target := c.BaseURL.String() + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var buf io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		buf = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, target, buf)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.SessionID != "" {
+		req.AddCookie(&http.Cookie{Name: SessionCookie, Value: c.SessionID})
+	}
+
+	return req, nil
+}
+
+// do sends req and decodes the response body into v.
+//
+// v may be nil if the body is of no interest, a *[]byte to get the raw body,
+// or a pointer to anything encoding/json can decode into.
+func (c *Client) do(req *http.Request, v interface{}) (*http.Response, error) {
+	resp, err := c.doRetry(req, v)
+
+	if e, ok := err.(*ErrorResponse); ok && e.Response.StatusCode == http.StatusUnauthorized &&
+		c.Reauth != nil && c.SessionID != "" && !strings.HasSuffix(req.URL.Path, "/login") {
+		c.logf("<== session expired, log in again")
+
+		reauth := c.Reauth
+		c.Reauth = nil // never recursive
+		rerr := reauth(c)
+		c.Reauth = reauth
+		if rerr != nil {
+			c.logf("<== log in again failed: %v", rerr)
+			return resp, err
+		}
+
+		retry, rerr := c.replay(req)
+		if rerr != nil {
+			return resp, err
+		}
+		return c.doRetry(retry, v)
+	}
+
+	return resp, err
+}
+
+// doRetry sends req up to MaxRetries more times while it fails temporarily.
+func (c *Client) doRetry(req *http.Request, v interface{}) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.doOnce(req, v)
+		if err == nil || attempt >= c.MaxRetries || !idempotent(req.Method) || !temporary(err) {
+			return resp, err
+		}
+
+		wait := c.backoff(attempt, resp)
+		c.logf("<== retry %d/%d in %v: %v", attempt+1, c.MaxRetries, wait.Round(time.Millisecond), err)
+
+		t := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			t.Stop()
+			return resp, err
+		case <-t.C:
+		}
+
+		next, rerr := c.replay(req)
+		if rerr != nil {
+			return resp, err
+		}
+		req = next
+	}
+}
+
+// idempotent reports whether a request of method can be sent more than
+// once safely.
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// temporary reports whether err may go away if the request is sent again.
+func temporary(err error) bool {
+	if e, ok := err.(*ErrorResponse); ok {
+		switch e.Response.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	var ue *url.Error
+	if !errors.As(err, &ue) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	// a bad certificate never gets better
+	var certErr *tls.CertificateVerificationError
+	var unknownAuth x509.UnknownAuthorityError
+	var hostErr x509.HostnameError
+	if errors.As(err, &certErr) || errors.As(err, &unknownAuth) || errors.As(err, &hostErr) {
+		return false
+	}
+	return true
+}
+
+// backoff returns the wait before the retry after attempt (from 0), which
+// is the Retry-After of resp if any, or the exponential backoff with jitter.
+func (c *Client) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+			if wait := time.Duration(secs) * time.Second; c.RetryMaxWait <= 0 || wait <= c.RetryMaxWait {
+				return wait
+			}
+			return c.RetryMaxWait
+		}
+	}
+
+	wait := c.RetryWait << uint(attempt)
+	if c.RetryMaxWait > 0 && (wait > c.RetryMaxWait || wait <= 0) {
+		wait = c.RetryMaxWait
+	}
+	if wait <= 0 {
+		return 0
+	}
+	// equal jitter: [wait/2, wait)
+	half := wait / 2
+	return half + time.Duration(rand.Int63n(int64(wait-half)+1))
+}
+
+// replay copies req with the current session.
+func (c *Client) replay(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+
+	r.Header.Del("Cookie")
+	if c.SessionID != "" {
+		r.AddCookie(&http.Cookie{Name: SessionCookie, Value: c.SessionID})
+	}
+	return r, nil
+}
+
+func (c *Client) doOnce(req *http.Request, v interface{}) (*http.Response, error) {
+	c.logf("==> %s %s", req.Method, req.URL)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp, err
+	}
+	c.logf("<== Rsp Status: %s", resp.Status)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp, &ErrorResponse{Response: resp, Body: data}
+	}
+
+	switch v := v.(type) {
+	case nil:
+	case *[]byte:
+		*v = data
+	default:
+		if len(bytes.TrimSpace(data)) == 0 {
+			return resp, nil
+		}
+		if err := json.Unmarshal(data, v); err != nil {
+			return resp, fmt.Errorf("decode response of %s %s: %v", req.Method, req.URL, err)
+		}
+	}
+
+	return resp, nil
+}
+
+// call is a shortcut of newRequest followed by do.
+func (c *Client) call(method, path string, query url.Values, body, v interface{}) error {
+	req, err := c.newRequest(method, path, query, body)
+	if err != nil {
+		return err
+	}
+	_, err = c.do(req, v)
+	return err
+}
+
+// Do sends a request of method to path (relative to BaseURL, e.g.
+// /api/projects) with the optional query and JSON body, and returns the
+// response with its raw body. Unlike the other methods, the request is sent
+// as it is, without being translated for the API generation of the server,
+// which makes Do a way to reach the endpoints no method is written for.
+func (c *Client) Do(method, path string, query url.Values, body []byte) (*http.Response, []byte, error) {
+	var v interface{}
+	if body != nil {
+		v = json.RawMessage(body)
+	}
+	req, err := c.newRawRequest(method, path, query, v)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var data []byte
+	resp, err := c.do(req, &data)
+	if e, ok := err.(*ErrorResponse); ok {
+		data = e.Body
+	}
+	return resp, data, err
+}
+
+// pageQuery adds the pagination parameters shared by all list endpoints.
+func pageQuery(q url.Values, page, pageSize int) url.Values {
+	if page > 0 {
+		q.Set("page", fmt.Sprint(page))
+	}
+	if pageSize > 0 {
+		q.Set("page_size", fmt.Sprint(pageSize))
+	}
+	return q
+}
@@ -0,0 +1,189 @@
+package harbor
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// flakyServer answers every request with the statuses in order, the last one
+// repeated, and records the method and body of the requests.
+func flakyServer(t *testing.T, statuses ...int) (*httptest.Server, *[]string) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+string(body))
+		status := statuses[len(statuses)-1]
+		if len(requests) <= len(statuses) {
+			status = statuses[len(requests)-1]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func newTestClient(t *testing.T, url string, maxRetries int) *Client {
+	c, err := NewClient(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.MaxRetries = maxRetries
+	c.RetryWait, c.RetryMaxWait = time.Millisecond, 5*time.Millisecond
+	return c
+}
+
+func TestRetryNotIdempotent(t *testing.T) {
+	srv, requests := flakyServer(t, http.StatusServiceUnavailable, http.StatusCreated)
+	c := newTestClient(t, srv.URL, 3)
+
+	_, _, err := c.Do("POST", "/api/projects", nil, []byte(`{"project_name":"prj"}`))
+	if !errors.Is(err, ErrServer) || len(*requests) != 1 {
+		t.Errorf("POST: got %v after %d requests, want 503 without retry", err, len(*requests))
+	}
+}
+
+func TestRetryAttempts(t *testing.T) {
+	srv, requests := flakyServer(t, http.StatusServiceUnavailable)
+	c := newTestClient(t, srv.URL, 2)
+
+	_, _, err := c.Do("GET", "/api/projects", nil, nil)
+	if !errors.Is(err, ErrServer) || len(*requests) != 3 {
+		t.Errorf("GET: got %v after %d requests, want 503 after 1+2", err, len(*requests))
+	}
+
+	*requests = nil
+	c.MaxRetries = 0
+	if _, _, err := c.Do("GET", "/api/projects", nil, nil); err == nil || len(*requests) != 1 {
+		t.Errorf("GET without retries: got %v after %d requests", err, len(*requests))
+	}
+}
+
+func TestRetryRecovers(t *testing.T) {
+	srv, requests := flakyServer(t, http.StatusBadGateway, http.StatusTooManyRequests, http.StatusOK)
+	c := newTestClient(t, srv.URL, 3)
+
+	// the body is sent again with every retry
+	body := `{"description":"tiny"}`
+	if _, _, err := c.Do("PUT", "/api/repositories/library/busybox", nil, []byte(body)); err != nil {
+		t.Fatal(err)
+	}
+	if len(*requests) != 3 {
+		t.Fatalf("PUT: got %d requests, want 3", len(*requests))
+	}
+	for i, r := range *requests {
+		if r != "PUT "+body {
+			t.Errorf("request %d: got %q", i, r)
+		}
+	}
+
+	// 404 never gets better
+	srv, requests = flakyServer(t, http.StatusNotFound, http.StatusOK)
+	c = newTestClient(t, srv.URL, 3)
+	if _, _, err := c.Do("GET", "/api/projects/1", nil, nil); !errors.Is(err, ErrNotFound) || len(*requests) != 1 {
+		t.Errorf("404: got %v after %d requests", err, len(*requests))
+	}
+}
+
+func TestTemporary(t *testing.T) {
+	response := func(code int) error {
+		return &ErrorResponse{Response: &http.Response{StatusCode: code}}
+	}
+	netErr := func(err error) error {
+		return &url.Error{Op: "Get", URL: "https://localhost/api/projects", Err: err}
+	}
+
+	for _, tt := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"429", response(http.StatusTooManyRequests), true},
+		{"502", response(http.StatusBadGateway), true},
+		{"503", response(http.StatusServiceUnavailable), true},
+		{"504", response(http.StatusGatewayTimeout), true},
+		{"500", response(http.StatusInternalServerError), false},
+		{"401", response(http.StatusUnauthorized), false},
+		{"404", response(http.StatusNotFound), false},
+		{"connection refused", netErr(errors.New("connection refused")), true},
+		{"unknown authority", netErr(x509.UnknownAuthorityError{}), false},
+		{"hostname", netErr(x509.HostnameError{Host: "harbor"}), false},
+		{"decode", fmt.Errorf("decode response of GET /api/projects: %v", errors.New("bad json")), false},
+	} {
+		if got := temporary(tt.err); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	c := &Client{RetryWait: 100 * time.Millisecond, RetryMaxWait: time.Second}
+	for attempt, max := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		max *= time.Millisecond
+		if got := c.backoff(attempt, nil); got < max/2 || got > max {
+			t.Errorf("attempt %d: got %v, want in [%v, %v]", attempt, got, max/2, max)
+		}
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": {"0"}}}
+	if got := c.backoff(0, resp); got != 0 {
+		t.Errorf("Retry-After 0: got %v", got)
+	}
+	resp.Header.Set("Retry-After", "120")
+	if got := c.backoff(0, resp); got != time.Second {
+		t.Errorf("Retry-After 120: got %v, want RetryMaxWait", got)
+	}
+}
+
+func TestReauth(t *testing.T) {
+	var requests []string
+	session := "old"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		cookie, _ := r.Cookie(SessionCookie)
+		requests = append(requests, fmt.Sprintf("%s %s %s %s", r.Method, r.URL.Path, cookie.Value, body))
+		if cookie.Value != session {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv.URL, 0)
+	c.SessionID = "expired"
+
+	logins := 0
+	c.Reauth = func(c *Client) error {
+		logins++
+		c.SessionID = session
+		return nil
+	}
+	body := `{"description":"tiny"}`
+	if _, _, err := c.Do("PUT", "/api/repositories/library/busybox", nil, []byte(body)); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"PUT /api/repositories/library/busybox expired " + body,
+		"PUT /api/repositories/library/busybox old " + body,
+	}
+	if logins != 1 || !reflect.DeepEqual(requests, want) {
+		t.Errorf("got %d logins, requests %q, want 1 and %q", logins, requests, want)
+	}
+
+	// the new session is refused too, which is not tried again
+	requests, logins = nil, 0
+	session = "new"
+	c.SessionID = "old"
+	c.Reauth = func(c *Client) error {
+		logins++
+		return nil
+	}
+	if _, _, err := c.Do("DELETE", "/api/repositories/library/busybox", nil, nil); !errors.Is(err, ErrUnauthorized) || logins != 1 || len(requests) != 2 {
+		t.Errorf("got %v after %d logins and %d requests, want 401 after 1 and 2", err, logins, len(requests))
+	}
+}
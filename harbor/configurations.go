@@ -0,0 +1,103 @@
+package harbor
+
+// Configurations holds the system configurations which can be modified by admin user.
+//
+// It carries yaml tags as well, since harbor-go-client loads it from conf/config.yaml.
+type Configurations struct {
+	AuthMode                   string         `yaml:"auth_mode" json:"auth_mode"`
+	EmailFrom                  string         `yaml:"email_from" json:"email_from"`
+	EmailHost                  string         `yaml:"email_host" json:"email_host"`
+	EmailPort                  int            `yaml:"email_port" json:"email_port"`
+	EmailIdentity              string         `yaml:"email_identity" json:"email_identity"`
+	EmailUsername              string         `yaml:"email_username" json:"email_username"`
+	EmailPassword              string         `yaml:"email_password,omitempty" json:"email_password,omitempty"`
+	EmailSsl                   bool           `yaml:"email_ssl" json:"email_ssl"`
+	EmailInsecure              bool           `yaml:"email_insecure" json:"email_insecure"`
+	LdapURL                    string         `yaml:"ldap_url" json:"ldap_url"`
+	LdapBaseDN                 string         `yaml:"ldap_base_dn" json:"ldap_base_dn"`
+	LdapFilter                 string         `yaml:"ldap_filter" json:"ldap_filter"`
+	LdapScope                  int            `yaml:"ldap_scope" json:"ldap_scope"`
+	LdapUID                    string         `yaml:"ldap_uid" json:"ldap_uid"`
+	LdapSearchDN               string         `yaml:"ldap_search_dn" json:"ldap_search_dn"`
+	LdapSearchPassword         string         `yaml:"ldap_search_password,omitempty" json:"ldap_search_password,omitempty"`
+	LdapTimeout                int            `yaml:"ldap_timeout" json:"ldap_timeout"`
+	ProjectCreationRestriction string         `yaml:"project_creation_restriction" json:"project_creation_restriction"`
+	SelfRegistration           bool           `yaml:"self_registration" json:"self_registration"`
+	TokenExpiration            int            `yaml:"token_expiration" json:"token_expiration"`
+	VerifyRemoteCert           bool           `yaml:"verify_remote_cert" json:"verify_remote_cert"`
+	ScanAllPolicy              *ScanAllPolicy `yaml:"scan_all_policy" json:"scan_all_policy,omitempty"`
+}
+
+// ScanAllPolicy is the policy of scanning all images.
+type ScanAllPolicy struct {
+	Type      string `yaml:"type" json:"type"`
+	Parameter struct {
+		DailyTime int `yaml:"daily_time" json:"daily_time"`
+	} `yaml:"parameter" json:"parameter"`
+}
+
+// ConfigItem is a single item returned by GetConfigurations.
+type ConfigItem struct {
+	Value    interface{} `json:"value"`
+	Editable bool        `json:"editable"`
+}
+
+// GetConfigurations is for retrieving system configurations that only provides for admin user.
+//
+// format:
+//  GET /configurations
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/configurations'
+func (c *Client) GetConfigurations() (map[string]*ConfigItem, error) {
+	var cfg map[string]*ConfigItem
+	err := c.call("GET", "/api/configurations", nil, nil, &cfg)
+	return cfg, err
+}
+
+// UpdateConfigurations is for modifying system configurations that only provides for admin user.
+//
+// format:
+//  PUT /configurations
+//
+/* e.g.
+  curl -X PUT --header 'Content-Type: application/json' --header 'Accept: text/plain' -d '{
+  "auth_mode": "db_auth",
+  "email_from": "admin <sample_admin@mydomain.com>",
+  "email_host": "smtp.mydomain.com",
+  "email_port": 1200,
+  "email_identity": "",
+  "email_username": "sample_admin@mydomain.com",
+  "email_ssl": false,
+  "email_insecure": true,
+  "ldap_url": "ldaps://ldap.mydomain.com",
+  "ldap_base_dn": "ou=people,dc=mydomain,dc=com",
+  "ldap_filter": "",
+  "ldap_scope": 3,
+  "ldap_uid": "uid",
+  "ldap_search_dn": "",
+  "ldap_timeout": 5,
+  "project_creation_restriction": "everyone",
+  "self_registration": true,
+  "token_expiration": 30,
+  "verify_remote_cert": true,
+  "scan_all_policy": {
+    "type": "daily",
+    "parameter": {
+      "daily_time": 0
+    }
+  }
+}' 'https://localhost/api/configurations'
+*/
+func (c *Client) UpdateConfigurations(cfg *Configurations) error {
+	return c.call("PUT", "/api/configurations", nil, cfg, nil)
+}
+
+// ResetConfigurations resets system configurations from environment variables. Can only be accessed by admin user.
+//
+// format:
+//  POST /configurations/reset
+//
+// e.g. curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/plain' 'https://localhost/api/configurations/reset'
+func (c *Client) ResetConfigurations() error {
+	return c.call("POST", "/api/configurations/reset", nil, nil, nil)
+}
@@ -0,0 +1,17 @@
+// Package harbor is a Go client library for the Harbor REST API.
+//
+// All the commands of harbor-go-client are thin wrappers around this package,
+// so any Go program can reuse it directly:
+//
+//	c, err := harbor.NewClient("https://localhost", nil)
+//	if err != nil {
+//		return err
+//	}
+//	if err := c.Login("admin", "Harbor12345"); err != nil {
+//		return err
+//	}
+//	prjs, err := c.ListProjects(&harbor.ProjectListOptions{Name: "library"})
+//
+// Every method returns a typed result (if any) and an error. A response with a
+// non-2xx status code is reported as an *ErrorResponse.
+package harbor // import "github.com/moooofly/harbor-go-client/harbor"
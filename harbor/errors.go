@@ -0,0 +1,46 @@
+package harbor
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Errors which an *ErrorResponse matches according to its status code, e.g.
+//
+//	if errors.Is(err, harbor.ErrNotFound) {
+//		...
+//	}
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrServer       = errors.New("server error")
+)
+
+// Kind returns the typed error matching the status code of the response, or
+// nil if there is none.
+func (r *ErrorResponse) Kind() error {
+	code := r.Response.StatusCode
+	switch {
+	case code == http.StatusBadRequest:
+		return ErrBadRequest
+	case code == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case code == http.StatusForbidden:
+		return ErrForbidden
+	case code == http.StatusNotFound:
+		return ErrNotFound
+	case code == http.StatusConflict:
+		return ErrConflict
+	case code >= 500:
+		return ErrServer
+	}
+	return nil
+}
+
+// Is makes errors.Is work with the typed errors above.
+func (r *ErrorResponse) Is(target error) bool {
+	return target != nil && r.Kind() == target
+}
@@ -0,0 +1,165 @@
+package harbor
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// ReplicationJob is a job triggered by a replication policy.
+type ReplicationJob struct {
+	ID           int      `json:"id"`
+	Status       string   `json:"status"`
+	Repository   string   `json:"repository"`
+	PolicyID     int      `json:"policy_id"`
+	Operation    string   `json:"operation"`
+	Tags         []string `json:"tags"`
+	CreationTime string   `json:"creation_time"`
+	UpdateTime   string   `json:"update_time"`
+}
+
+// ReplicationJobListOptions filters the result of ListReplicationJobs.
+type ReplicationJobListOptions struct {
+	// PolicyID is required.
+	PolicyID int
+	Num      int
+	// StartTime and EndTime are UNIX timestamps, 0 means not set.
+	StartTime  int64
+	EndTime    int64
+	Repository string
+	// Status is one of running, error, pending, retrying, stopped, finished and canceled.
+	Status   string
+	Page     int
+	PageSize int
+}
+
+// ListReplicationJobs list filtered jobs according to the policy and repository.
+//
+// params:
+//  policy_id  - (REQUIRED) The ID of the policy that triggered this job.
+//  num        - The return list length number.
+//  end_time   - The end time of jobs done. (Timestamp)
+//  start_time - The start time of jobs. (Timestamp)
+//  repository - The jobs list filtered by repository name.
+//  status     - The jobs list filtered by status.
+//  page       - The page nubmer, default is 1.
+//  page_size  - The size of per page, default is 10, maximum is 100.
+//
+// format:
+//  GET /jobs/replication
+//
+// e.g. curl -X GET --header 'Accept: text/plain' 'https://localhost/api/jobs/replication?page=1&page_size=15&status=finished&start_time=1529884800&end_time=1530057600&policy_id=6'
+func (c *Client) ListReplicationJobs(opt *ReplicationJobListOptions) ([]*ReplicationJob, error) {
+	if opt == nil {
+		opt = &ReplicationJobListOptions{}
+	}
+
+	var jobs []*ReplicationJob
+	err := c.call("GET", "/api/jobs/replication", pageQuery(opt.query(), opt.Page, opt.PageSize), nil, &jobs)
+	return jobs, err
+}
+
+func (opt *ReplicationJobListOptions) query() url.Values {
+	q := url.Values{}
+	q.Set("policy_id", strconv.Itoa(opt.PolicyID))
+	if opt.Num > 0 {
+		q.Set("num", strconv.Itoa(opt.Num))
+	}
+	if opt.StartTime > 0 {
+		q.Set("start_time", strconv.FormatInt(opt.StartTime, 10))
+	}
+	if opt.EndTime > 0 {
+		q.Set("end_time", strconv.FormatInt(opt.EndTime, 10))
+	}
+	setIfNotEmpty(q, "repository", opt.Repository)
+	setIfNotEmpty(q, "status", opt.Status)
+	return q
+}
+
+// ReplicationJobIterator iterates over the jobs returned by
+// ListReplicationJobs, page by page.
+type ReplicationJobIterator struct {
+	iterator
+}
+
+// IterReplicationJobs returns an iterator over all the jobs matching opt, from
+// opt.Page with opt.PageSize (MaxPageSize by default) jobs per request.
+func (c *Client) IterReplicationJobs(opt *ReplicationJobListOptions) *ReplicationJobIterator {
+	if opt == nil {
+		opt = &ReplicationJobListOptions{}
+	}
+	p := c.NewPager("/api/jobs/replication", opt.query(), opt.Page, opt.PageSize)
+	return &ReplicationJobIterator{newIterator(p, []*ReplicationJob(nil))}
+}
+
+// ReplicationJob returns the current job.
+func (it *ReplicationJobIterator) ReplicationJob() *ReplicationJob {
+	j, _ := it.Item().(*ReplicationJob)
+	return j
+}
+
+// UpdateReplicationJobs is used to stop the replication jobs of a policy.
+//
+// params:
+//  policy_id - (REQUIRED) The ID of replication policy.
+//  status    - (REQUIRED) The status of jobs. The only valid value is "stop" for now.
+//
+// format:
+//  PUT /jobs/replication
+//
+// e.g.
+/*
+curl -X PUT --header 'Content-Type: application/json' --header 'Accept: text/plain' -d '{ \
+   "policy_id": 1, \
+   "status": "stop" \
+}' 'https://localhost/api/jobs/replication'
+*/
+func (c *Client) UpdateReplicationJobs(policyID int, status string) error {
+	body := struct {
+		PolicyID int    `json:"policy_id"`
+		Status   string `json:"status"`
+	}{policyID, status}
+	return c.call("PUT", "/api/jobs/replication", nil, &body, nil)
+}
+
+// DeleteReplicationJob is aimed to remove job with specific ID from jobservice.
+//
+// params:
+//  id - (REQUIRED) Replication job ID to delete.
+//
+// format:
+//  DELETE /jobs/replication/{id}
+//
+// e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/jobs/replication/1'
+func (c *Client) DeleteReplicationJob(id int) error {
+	return c.call("DELETE", "/api/jobs/replication/"+strconv.Itoa(id), nil, nil, nil)
+}
+
+// GetReplicationJobLog let user search job logs filtered by specific ID.
+//
+// params:
+//  id - (REQUIRED) Relevant job ID.
+//
+// format:
+//  GET /jobs/replication/{id}/log
+//
+// e.g. curl -X GET --header 'Accept: text/plain' 'https://localhost/api/jobs/replication/1/log'
+func (c *Client) GetReplicationJobLog(id int) (string, error) {
+	var log []byte
+	err := c.call("GET", "/api/jobs/replication/"+strconv.Itoa(id)+"/log", nil, nil, &log)
+	return string(log), err
+}
+
+// GetScanJobLog let user get scan job logs filtered by specific ID.
+//
+// params:
+//  id - (REQUIRED) Relevant job ID.
+//
+// format:
+//  GET /jobs/scan/{id}/log
+//
+// e.g. curl -X GET --header 'Accept: text/plain' 'https://localhost/api/jobs/scan/1/log'
+func (c *Client) GetScanJobLog(id int) (string, error) {
+	var log []byte
+	err := c.call("GET", "/api/jobs/scan/"+strconv.Itoa(id)+"/log", nil, nil, &log)
+	return string(log), err
+}
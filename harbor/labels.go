@@ -0,0 +1,175 @@
+package harbor
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// Label is a global or project label of Harbor.
+type Label struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	Color        string `json:"color"`
+	Scope        string `json:"scope"`
+	ProjectID    int    `json:"project_id"`
+	CreationTime string `json:"creation_time,omitempty"`
+	UpdateTime   string `json:"update_time,omitempty"`
+	Deleted      bool   `json:"deleted"`
+}
+
+// LabelListOptions filters the result of ListLabels.
+type LabelListOptions struct {
+	Name string
+	// Scope is either "g" for global labels or "p" for project labels.
+	Scope string
+	// ProjectID is required when Scope is "p".
+	ProjectID int
+	Page      int
+	PageSize  int
+}
+
+// ListLabels let user list labels by name, scope and project_id.
+//
+// params:
+//  name       - The label name.
+//  scope      - (REQUIRED) The label scope. Valid values are g and p. g for global labels and p for project labels.
+//  project_id - Relevant project ID, required when scope is p.
+//  page       - The page nubmer, default is 1.
+//  page_size  - The size of per page, default is 10, maximum is 100.
+//
+// format:
+//  GET /labels
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/labels?scope=g&page=1&page_size=10'
+func (c *Client) ListLabels(opt *LabelListOptions) ([]*Label, error) {
+	if opt == nil {
+		opt = &LabelListOptions{}
+	}
+
+	var labels []*Label
+	err := c.call("GET", "/api/labels", pageQuery(opt.query(), opt.Page, opt.PageSize), nil, &labels)
+	return labels, err
+}
+
+func (opt *LabelListOptions) query() url.Values {
+	q := url.Values{}
+	setIfNotEmpty(q, "scope", opt.Scope)
+	setIfNotEmpty(q, "name", opt.Name)
+	if opt.ProjectID > 0 {
+		q.Set("project_id", strconv.Itoa(opt.ProjectID))
+	}
+	return q
+}
+
+// LabelIterator iterates over the labels returned by ListLabels, page by
+// page.
+type LabelIterator struct {
+	iterator
+}
+
+// IterLabels returns an iterator over all the labels matching opt, from
+// opt.Page with opt.PageSize (MaxPageSize by default) labels per request.
+func (c *Client) IterLabels(opt *LabelListOptions) *LabelIterator {
+	if opt == nil {
+		opt = &LabelListOptions{}
+	}
+	p := c.NewPager("/api/labels", opt.query(), opt.Page, opt.PageSize)
+	return &LabelIterator{newIterator(p, []*Label(nil))}
+}
+
+// Label returns the current label.
+func (it *LabelIterator) Label() *Label {
+	l, _ := it.Item().(*Label)
+	return l
+}
+
+// CreateLabel let user creates a label.
+//
+// params:
+//   name        - (REQUIRED) The name of label.
+//   description - (REQUIRED) The description of label.
+//   color       - The color code of label. (e.g. Format: #A9B6BE)
+//   scope       - The scope of label. ('p' indicates project scope, 'g' indicates global scope)
+//   project_id  - Which project id this label belongs to when created. ('0' indicates global label, others indicate specific project)
+//
+// format:
+//   POST /labels
+//
+// e.g.
+/*
+curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/plain' -d '{ \
+   "id": 100, \
+   "name": "label-name-100", \
+   "description": "label-description-100", \
+   "color": "#000000", \
+   "scope": "g", \
+   "project_id": 0, \
+   "deleted": true \
+ }' 'https://localhost/api/labels'
+*/
+func (c *Client) CreateLabel(label *Label) error {
+	return c.call("POST", "/api/labels", nil, label, nil)
+}
+
+// GetLabel gets the label specified by ID.
+//
+// params:
+//   id - (REQUIRED) Label ID.
+//
+// format:
+//  GET /labels/{id}
+//
+// e.g. curl -X GET --header 'Accept: text/plain' 'https://localhost/api/labels/100'
+func (c *Client) GetLabel(id int) (*Label, error) {
+	var l Label
+	if err := c.call("GET", "/api/labels/"+strconv.Itoa(id), nil, nil, &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// UpdateLabel let user update label properties.
+//
+// NOTE:
+// Though as swagger shows, both creation_time and update_time can be updated, but actually not.
+//
+// params:
+//   id          - (REQUIRED) The ID of label.
+//   name        - (REQUIRED) The name of label.
+//   description - (REQUIRED) The description of label.
+//   color       - The color code of label. (e.g. Format: #A9B6BE)
+//   scope       - The scope of label. ('p' indicates project scope, 'g' indicates global scope)
+//   project_id  - Which project id this label belongs to when created. ('0' indicates global label, others indicate specific project)
+//
+// format:
+//   PUT /labels/{id}
+//
+// e.g.
+/*
+curl -X PUT --header 'Content-Type: application/json' --header 'Accept: text/plain' -d '{ \
+   "id": 0, \
+   "name": "label-name-100", \
+   "description": "label-description-100", \
+   "color": "#000000", \
+   "scope": "g", \
+   "project_id": 0, \
+   "deleted": true \
+ }' 'https://localhost/api/labels/100'
+*/
+func (c *Client) UpdateLabel(id int, label *Label) error {
+	return c.call("PUT", "/api/labels/"+strconv.Itoa(id), nil, label, nil)
+}
+
+// DeleteLabel deletes the label specified by ID.
+//
+// params:
+//   id - (REQUIRED) Label ID.
+//
+// format:
+//  DELETE /labels/{id}
+//
+// e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/labels/100'
+func (c *Client) DeleteLabel(id int) error {
+	return c.call("DELETE", "/api/labels/"+strconv.Itoa(id), nil, nil, nil)
+}
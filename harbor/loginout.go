@@ -0,0 +1,72 @@
+package harbor
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var errNoSession = errors.New("login succeeded but no " + SessionCookie + " returned")
+
+// Login logs in to Harbor with username and password, and keeps the
+// beegosessionID returned by Harbor in c.SessionID.
+//
+// params:
+//   username - Current login username.
+//   password - Current login password.
+//
+// format:
+//   POST /login
+//
+// e.g. curl -X POST --header 'Content-Type: application/x-www-form-urlencoded;param=value' 'https://localhost/login' -i -k -d "principal=admin&password=Harbor12345"
+func (c *Client) Login(username, password string) error {
+	form := url.Values{}
+	form.Set("principal", username)
+	form.Set("password", password)
+
+	a, err := c.getAdapter()
+	if err != nil {
+		return err
+	}
+	path, _, err := a.route("POST", "/login", nil)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", c.BaseURL.String()+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded;param=value")
+
+	// NOTE:
+	// The session carried by the request (if any) is not sent, so this is
+	// always equivalent to a fresh login.
+	resp, err := c.do(req, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == SessionCookie && cookie.Value != "" {
+			c.SessionID = cookie.Value
+			return nil
+		}
+	}
+	return errNoSession
+}
+
+// Logout logs the current session out from Harbor.
+//
+// format:
+//   GET /log_out
+//
+// e.g. curl -X GET 'https://localhost/log_out' -i -k
+func (c *Client) Logout() error {
+	if err := c.call("GET", "/log_out", nil, nil, nil); err != nil {
+		return err
+	}
+	c.SessionID = ""
+	return nil
+}
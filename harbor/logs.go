@@ -0,0 +1,87 @@
+package harbor
+
+import "net/url"
+
+// AccessLog is an operation log of Harbor.
+type AccessLog struct {
+	LogID     int    `json:"log_id"`
+	Username  string `json:"username"`
+	ProjectID int    `json:"project_id"`
+	RepoName  string `json:"repo_name"`
+	RepoTag   string `json:"repo_tag"`
+	Operation string `json:"operation"`
+	OpTime    string `json:"op_time"`
+}
+
+// LogListOptions filters the result of ListLogs.
+type LogListOptions struct {
+	Username   string
+	Repository string
+	Tag        string
+	// Operation is one of create, delete, push and pull.
+	Operation string
+	// BeginTimestamp and EndTimestamp are formatted as yyyymmdd.
+	BeginTimestamp string
+	EndTimestamp   string
+	Page           int
+	PageSize       int
+}
+
+// ListLogs let user see the recent operation logs of the projects which he is member of.
+//
+// params:
+//  username        - Username of the operator.
+//  repository      - The name of repository.
+//  tag             - The name of tag.
+//  operation       - The operation. ([create|delete|push|pull])
+//  begin_timestamp - The begin timestamp. (format: yyyymmdd)
+//  end_timestamp   - The end timestamp. (format: yyyymmdd)
+//  page            - The page nubmer, default is 1.
+//  page_size       - The size of per page, default is 10, maximum is 100.
+//
+// format:
+//  GET /logs
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/logs?username=admin&repository=prj2%2Fphoton&tag=v3&operation=push&begin_timestamp=20171102&page=1&page_size=10'
+func (c *Client) ListLogs(opt *LogListOptions) ([]*AccessLog, error) {
+	if opt == nil {
+		opt = &LogListOptions{}
+	}
+
+	var logs []*AccessLog
+	err := c.call("GET", "/api/logs", pageQuery(opt.query(), opt.Page, opt.PageSize), nil, &logs)
+	return logs, err
+}
+
+func (opt *LogListOptions) query() url.Values {
+	q := url.Values{}
+	setIfNotEmpty(q, "username", opt.Username)
+	setIfNotEmpty(q, "repository", opt.Repository)
+	setIfNotEmpty(q, "tag", opt.Tag)
+	setIfNotEmpty(q, "operation", opt.Operation)
+	setIfNotEmpty(q, "begin_timestamp", opt.BeginTimestamp)
+	setIfNotEmpty(q, "end_timestamp", opt.EndTimestamp)
+	return q
+}
+
+// AccessLogIterator iterates over the logs returned by ListLogs, page by
+// page.
+type AccessLogIterator struct {
+	iterator
+}
+
+// IterLogs returns an iterator over all the logs matching opt, from opt.Page
+// with opt.PageSize (MaxPageSize by default) logs per request.
+func (c *Client) IterLogs(opt *LogListOptions) *AccessLogIterator {
+	if opt == nil {
+		opt = &LogListOptions{}
+	}
+	p := c.NewPager("/api/logs", opt.query(), opt.Page, opt.PageSize)
+	return &AccessLogIterator{newIterator(p, []*AccessLog(nil))}
+}
+
+// AccessLog returns the current log.
+func (it *AccessLogIterator) AccessLog() *AccessLog {
+	l, _ := it.Item().(*AccessLog)
+	return l
+}
@@ -0,0 +1,49 @@
+package harbor
+
+// EmailSettings is used to test the connection with an email server.
+type EmailSettings struct {
+	EmailHost     string `json:"email_host"`
+	EmailPort     int    `json:"email_port"`
+	EmailUsername string `json:"email_username"`
+	EmailPassword string `json:"email_password"`
+	EmailSsl      bool   `json:"email_ssl"`
+	EmailIdentity string `json:"email_identity"`
+}
+
+// SyncRegistry is for syncing all repositories of registry with database.
+//
+// format:
+//  POST /internal/syncregistry
+//
+// e.g. curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/plain' 'https://localhost/api/internal/syncregistry'
+func (c *Client) SyncRegistry() error {
+	return c.call("POST", "/api/internal/syncregistry", nil, nil, nil)
+}
+
+// PingEmail tests connection and authentication with email server.
+//
+// params:
+//  email_host     - The host of email server.
+//  email_port     - The port of email server.
+//  email_username - The username of email server.
+//  email_password - The password of email server.
+//  email_ssl      - Use ssl/tls or not.
+//  email_identity - The dentity of email server.
+//
+// format:
+//  POST /email/ping
+//
+// e.g.
+/*
+curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/plain' -d '{ \
+   "email_host": "string", \
+   "email_port": 0, \
+   "email_username": "string", \
+   "email_password": "string", \
+   "email_ssl": true, \
+   "email_identity": "string" \
+ }' 'https://localhost/api/email/ping'
+*/
+func (c *Client) PingEmail(settings *EmailSettings) error {
+	return c.call("POST", "/api/email/ping", nil, settings, nil)
+}
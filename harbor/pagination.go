@@ -0,0 +1,215 @@
+package harbor
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+)
+
+// MaxPageSize is the largest page_size accepted by Harbor.
+const MaxPageSize = 100
+
+// Iterator is implemented by the iterators of all list endpoints, e.g.
+// ProjectIterator. Typical usage:
+//
+//	it := c.IterProjects(nil)
+//	for it.Next() {
+//		p := it.Project()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator interface {
+	// Next advances to the next item, fetching the next page when needed. It
+	// returns false at the end, or on error.
+	Next() bool
+	// Item returns the current item, e.g. a *Project of ProjectIterator.
+	Item() interface{}
+	// Err returns the error which stopped Next, if any.
+	Err() error
+}
+
+// Pager fetches the pages of a list endpoint one by one. Whether there is a
+// next page is decided by (in order) the Link header (rel="next") of the
+// response, its X-Total-Count header, or a short page.
+type Pager struct {
+	c        *Client
+	path     string
+	query    url.Values
+	page     int
+	pageSize int
+	total    int
+	done     bool
+	err      error
+	raw      bool // path is not translated, see NewRawPager
+}
+
+// NewPager returns a Pager of the list endpoint at path, starting from page
+// (1 if not positive) with pageSize items per page (MaxPageSize if not
+// positive).
+func (c *Client) NewPager(path string, query url.Values, page, pageSize int) *Pager {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = MaxPageSize
+	}
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	q.Del("page")
+	q.Del("page_size")
+
+	return &Pager{c: c, path: path, query: q, page: page, pageSize: pageSize, total: -1}
+}
+
+// NewRawPager is the same as NewPager, but the requests are sent as they are
+// like Do, without being translated for the API generation of the server.
+func (c *Client) NewRawPager(path string, query url.Values, page, pageSize int) *Pager {
+	p := c.NewPager(path, query, page, pageSize)
+	p.raw = true
+	return p
+}
+
+// Next fetches the next page into v, which must be a pointer to a slice. It
+// returns false if there is no more page, or on error.
+func (p *Pager) Next(v interface{}) bool {
+	if p.done || p.err != nil {
+		return false
+	}
+
+	newRequest := p.c.newRequest
+	if p.raw {
+		newRequest = p.c.newRawRequest
+	}
+	req, err := newRequest("GET", p.path, pageQuery(p.query, p.page, p.pageSize), nil)
+	if err != nil {
+		p.err = err
+		return false
+	}
+	resp, err := p.c.do(req, v)
+	if err != nil {
+		p.err = err
+		return false
+	}
+
+	n := 0
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.Elem().Kind() == reflect.Slice {
+		n = rv.Elem().Len()
+	}
+	if total, err := strconv.Atoi(resp.Header.Get("X-Total-Count")); err == nil {
+		p.total = total
+	}
+
+	switch next, ok := nextLink(resp.Header); {
+	case n == 0:
+		p.done = true
+	case ok:
+		p.follow(next)
+	case resp.Header.Get("Link") != "":
+		// there are links, but no one is next
+		p.done = true
+	case p.total >= 0:
+		p.done = p.page*p.pageSize >= p.total
+		p.page++
+	default:
+		p.done = n < p.pageSize
+		p.page++
+	}
+	return true
+}
+
+// follow takes the query of the next link, so that whatever else harbor put
+// in it is kept.
+func (p *Pager) follow(next *url.URL) {
+	q := next.Query()
+	page, err := strconv.Atoi(q.Get("page"))
+	if err != nil || page <= p.page {
+		// never loop on a broken link
+		p.done = true
+		return
+	}
+	p.page = page
+	if size, err := strconv.Atoi(q.Get("page_size")); err == nil && size > 0 {
+		p.pageSize = size
+	}
+	q.Del("page")
+	q.Del("page_size")
+	p.query = q
+}
+
+// Total returns the total number of items reported by X-Total-Count, or -1 if
+// unknown (yet).
+func (p *Pager) Total() int {
+	return p.total
+}
+
+// Err returns the error which stopped Next, if any.
+func (p *Pager) Err() error {
+	return p.err
+}
+
+var linkRe = regexp.MustCompile(`<([^>]*)>\s*;\s*rel="?([^";]*)"?`)
+
+// nextLink returns the URL of rel="next" in the Link header, e.g.
+//
+//	Link: </api/projects?page=1&page_size=10>; rel="prev", </api/projects?page=3&page_size=10>; rel="next"
+func nextLink(h http.Header) (*url.URL, bool) {
+	for _, link := range h["Link"] {
+		for _, m := range linkRe.FindAllStringSubmatch(link, -1) {
+			if m[2] != "next" {
+				continue
+			}
+			u, err := url.Parse(m[1])
+			if err != nil {
+				return nil, false
+			}
+			return u, true
+		}
+	}
+	return nil, false
+}
+
+// iterator is the common part of the typed iterators, it keeps the current
+// page decoded by a Pager.
+type iterator struct {
+	pager *Pager
+	items reflect.Value // the rest of the current page
+	cur   interface{}
+}
+
+func newIterator(p *Pager, page interface{}) iterator {
+	return iterator{pager: p, items: reflect.ValueOf(page)}
+}
+
+func (it *iterator) Next() bool {
+	for it.items.Len() == 0 {
+		page := reflect.New(it.items.Type())
+		if !it.pager.Next(page.Interface()) {
+			it.cur = nil
+			return false
+		}
+		it.items = page.Elem()
+	}
+	it.cur = it.items.Index(0).Interface()
+	it.items = it.items.Slice(1, it.items.Len())
+	return true
+}
+
+func (it *iterator) Item() interface{} {
+	return it.cur
+}
+
+func (it *iterator) Err() error {
+	return it.pager.Err()
+}
+
+// Total returns the total number of items reported by harbor, or -1 if
+// unknown (yet).
+func (it *iterator) Total() int {
+	return it.pager.Total()
+}
@@ -0,0 +1,115 @@
+package harbor
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// ReplicationPolicy is a replication policy of Harbor.
+type ReplicationPolicy struct {
+	ID                        int                  `json:"id,omitempty"`
+	Name                      string               `json:"name"`
+	Description               string               `json:"description"`
+	Projects                  []*Project           `json:"projects"`
+	Targets                   []*Target            `json:"targets"`
+	Trigger                   *ReplicationTrigger  `json:"trigger"`
+	Filters                   []*ReplicationFilter `json:"filters"`
+	ReplicateExistingImageNow bool                 `json:"replicate_existing_image_now"`
+	ReplicateDeletion         bool                 `json:"replicate_deletion"`
+	CreationTime              string               `json:"creation_time,omitempty"`
+	UpdateTime                string               `json:"update_time,omitempty"`
+	ErrorJobCount             int                  `json:"error_job_count,omitempty"`
+}
+
+// ReplicationTrigger defines when a replication policy is triggered.
+type ReplicationTrigger struct {
+	// Kind is one of "Manual", "Immediate" and "Scheduled".
+	Kind          string               `json:"kind"`
+	ScheduleParam *ReplicationSchedule `json:"schedule_param,omitempty"`
+}
+
+// ReplicationSchedule is the schedule of a "Scheduled" trigger.
+type ReplicationSchedule struct {
+	Type    string `json:"type"`
+	Weekday int    `json:"weekday"`
+	Offtime int    `json:"offtime"`
+}
+
+// ReplicationFilter restricts the resources a replication policy applies to.
+type ReplicationFilter struct {
+	// Kind is one of "repository", "tag" and "label".
+	Kind    string      `json:"kind"`
+	Pattern string      `json:"pattern,omitempty"`
+	Value   interface{} `json:"value,omitempty"`
+}
+
+// ReplicationPolicyListOptions filters the result of ListReplicationPolicies.
+type ReplicationPolicyListOptions struct {
+	Name      string
+	ProjectID int
+	Page      int
+	PageSize  int
+}
+
+// ListReplicationPolicies let user list filters policies by name and project_id, if name and project_id are nil, list returns all policies.
+//
+// params:
+//   name       - The replication's policy name.
+//   project_id - The ID of project.
+//   page       - The page nubmer, default is 1.
+//   page_size  - The size of per page, default is 10, maximum is 100.
+//
+// format:
+//   GET /policies/replication
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/policies/replication?name=repl_policy_name&project_id=86&page=1&page_size=10'
+func (c *Client) ListReplicationPolicies(opt *ReplicationPolicyListOptions) ([]*ReplicationPolicy, error) {
+	if opt == nil {
+		opt = &ReplicationPolicyListOptions{}
+	}
+	q := url.Values{}
+	setIfNotEmpty(q, "name", opt.Name)
+	if opt.ProjectID > 0 {
+		q.Set("project_id", strconv.Itoa(opt.ProjectID))
+	}
+
+	var policies []*ReplicationPolicy
+	err := c.call("GET", "/api/policies/replication", pageQuery(q, opt.Page, opt.PageSize), nil, &policies)
+	return policies, err
+}
+
+// GetReplicationPolicy let user search replication policy by specific ID.
+//
+// params:
+//   id - (REQUIRED) policy ID
+//
+// format:
+//   GET /policies/replication/{id}
+//
+// e.g. curl -X GET --header 'Accept: text/plain' 'https://localhost/api/policies/replication/1'
+func (c *Client) GetReplicationPolicy(id int) (*ReplicationPolicy, error) {
+	var p ReplicationPolicy
+	if err := c.call("GET", "/api/policies/replication/"+strconv.Itoa(id), nil, nil, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// CreateReplicationPolicy let user creates a policy, and if it is enabled, the replication will be triggered right now.
+//
+// format:
+//   POST /policies/replication
+func (c *Client) CreateReplicationPolicy(policy *ReplicationPolicy) error {
+	return c.call("POST", "/api/policies/replication", nil, policy, nil)
+}
+
+// UpdateReplicationPolicy let user update policy name, description, target and enablement.
+//
+// params:
+//   id - (REQUIRED) policy ID
+//
+// format:
+//   PUT /policies/replication/{id}
+func (c *Client) UpdateReplicationPolicy(id int, policy *ReplicationPolicy) error {
+	return c.call("PUT", "/api/policies/replication/"+strconv.Itoa(id), nil, policy, nil)
+}
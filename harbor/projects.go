@@ -0,0 +1,460 @@
+package harbor
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// Project is a project of Harbor.
+type Project struct {
+	ProjectID         int               `json:"project_id"`
+	OwnerID           int               `json:"owner_id"`
+	Name              string            `json:"name"`
+	CreationTime      string            `json:"creation_time"`
+	UpdateTime        string            `json:"update_time"`
+	Deleted           bool              `json:"deleted"`
+	OwnerName         string            `json:"owner_name"`
+	Togglable         bool              `json:"togglable"`
+	CurrentUserRoleID int               `json:"current_user_role_id"`
+	RepoCount         int               `json:"repo_count"`
+	Metadata          map[string]string `json:"metadata"`
+}
+
+// ProjectReq holds the properties used to create or update a project.
+type ProjectReq struct {
+	ProjectName                                string `json:"project_name"`
+	Public                                     int    `json:"public"`
+	EnableContentTrust                         bool   `json:"enable_content_trust"`
+	PreventVulnerableImagesFromRunning         bool   `json:"prevent_vulnerable_images_from_running"`
+	PreventVulnerableImagesFromRunningSeverity string `json:"prevent_vulnerable_images_from_running_severity"`
+	AutomaticallyScanImagesOnPush              bool   `json:"automatically_scan_images_on_push"`
+}
+
+// ProjectListOptions filters the result of ListProjects.
+type ProjectListOptions struct {
+	Name string
+	// NOTE:
+	// Public is a string rather than a bool, because three semantics are needed:
+	// 1. "true" returns public projects only;
+	// 2. "false" returns private projects only;
+	// 3. "" returns both public and private projects.
+	Public   string
+	Owner    string
+	Page     int
+	PageSize int
+}
+
+// ListProjects returns all projects created by Harbor, and can be filtered by project name.
+//
+// params:
+//  name      - The name of project.
+//  public    - The project is public or private. default is "", return both public and private prjs.
+//  owner     - The name of project owner.
+//  page      - The page nubmer, default is 1.
+//  page_size - The size of per page, default is 10, maximum is 100.
+//
+// format:
+//  GET /projects
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/projects?name=prj&public=true&owner=moooofly&page=1&page_size=10'
+func (c *Client) ListProjects(opt *ProjectListOptions) ([]*Project, error) {
+	if opt == nil {
+		opt = &ProjectListOptions{}
+	}
+
+	var prjs []*Project
+	err := c.call("GET", "/api/projects", pageQuery(opt.query(), opt.Page, opt.PageSize), nil, &prjs)
+	return prjs, err
+}
+
+func (opt *ProjectListOptions) query() url.Values {
+	q := url.Values{}
+	setIfNotEmpty(q, "name", opt.Name)
+	setIfNotEmpty(q, "public", opt.Public)
+	setIfNotEmpty(q, "owner", opt.Owner)
+	return q
+}
+
+// ProjectIterator iterates over the projects returned by ListProjects, page
+// by page.
+type ProjectIterator struct {
+	iterator
+}
+
+// IterProjects returns an iterator over all the projects matching opt, from
+// opt.Page with opt.PageSize (MaxPageSize by default) projects per request.
+func (c *Client) IterProjects(opt *ProjectListOptions) *ProjectIterator {
+	if opt == nil {
+		opt = &ProjectListOptions{}
+	}
+	p := c.NewPager("/api/projects", opt.query(), opt.Page, opt.PageSize)
+	return &ProjectIterator{newIterator(p, []*Project(nil))}
+}
+
+// Project returns the current project.
+func (it *ProjectIterator) Project() *Project {
+	p, _ := it.Item().(*Project)
+	return p
+}
+
+// GetProject returns specific project information by project ID.
+//
+// params:
+//  project_id - (REQUIRED) Project ID of project which will be get.
+//
+// format:
+//  GET /projects/{project_id}
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/projects/100'
+func (c *Client) GetProject(projectID int) (*Project, error) {
+	var p Project
+	if err := c.call("GET", "/api/projects/"+strconv.Itoa(projectID), nil, nil, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// CreateProject is for user to create a new project.
+//
+// params:
+//  project_name - The name of the project.
+//  public - The public status of the project, public(1) or private(0).
+//  enable_content_trust - Whether content trust is enabled or not. If it is enabled, user cann't pull unsigned images from this project.
+//  prevent_vulnerable_images_from_running - Whether prevent the vulnerable images from running.
+//  prevent_vulnerable_images_from_running_severity - If the vulnerability is high than severity defined here, the images cann't be pulled.
+//  automatically_scan_images_on_push - Whether scan images automatically when pushing.
+//
+// format:
+//  POST /projects
+//
+// e.g.
+/*
+curl -X POST --header 'Content-Type: text/plain' --header 'Accept: text/plain' -d '{
+  "project_name": "t1",
+  "public": 0,
+  "enable_content_trust": false,
+  "prevent_vulnerable_images_from_running": false,
+  "prevent_vulnerable_images_from_running_severity": "",
+  "automatically_scan_images_on_push": false
+}' 'https://localhost/api/projects'
+*/
+func (c *Client) CreateProject(prj *ProjectReq) error {
+	return c.call("POST", "/api/projects", nil, prj, nil)
+}
+
+// UpdateProject is aimed to update the properties of a project.
+//
+// params:
+//   project_id           - (REQUIRED) Project ID of project which will be get.
+//   project_name         - The name of the project.
+//   public               - The public status of the project, public(1) or private(0).
+//   enable_content_trust - Whether content trust is enabled or not. If it is enabled, user cann't pull unsigned images from this project.
+//   prevent_vulnerable_images_from_running          - Whether prevent the vulnerable images from running.
+//   prevent_vulnerable_images_from_running_severity - If the vulnerability is high than severity defined here, the images cann't be pulled.
+//   automatically_scan_images_on_push               - Whether scan images automatically when pushing.
+//
+// format:
+//    PUT /projects/{project_id}
+//
+// e.g.
+/*
+curl -X PUT --header 'Content-Type: application/json' --header 'Accept: text/plain' -d '{ \
+   "project_name": "t1", \
+     "public": 1, \
+     "enable_content_trust": false, \
+     "prevent_vulnerable_images_from_running": false, \
+     "prevent_vulnerable_images_from_running_severity": "", \
+     "automatically_scan_images_on_push": false \
+ }' 'https://localhost/api/projects/92'
+*/
+func (c *Client) UpdateProject(projectID int, prj *ProjectReq) error {
+	return c.call("PUT", "/api/projects/"+strconv.Itoa(projectID), nil, prj, nil)
+}
+
+// DeleteProject is aimed to delete project by project ID.
+//
+// params:
+//  project_id - (REQUIRED) Project ID of project which will be deleted.
+//
+// format:
+//  DELETE /projects/{project_id}
+//
+// e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/projects/100'
+func (c *Client) DeleteProject(projectID int) error {
+	return c.call("DELETE", "/api/projects/"+strconv.Itoa(projectID), nil, nil, nil)
+}
+
+// ProjectLogListOptions filters the result of ListProjectLogs.
+type ProjectLogListOptions struct {
+	Username       string
+	Repository     string
+	Tag            string
+	Operation      string
+	BeginTimestamp string
+	EndTimestamp   string
+	Page           int
+	PageSize       int
+}
+
+// ListProjectLogs lets user search access logs filtered by operations and date time ranges.
+//
+// params:
+//   project_id      - (REQUIRED) Project ID of project which will be get.
+//   username        - Username of the operator
+//   repository      - The name of repository
+//   tag             - The name of tag
+//   operation       - The operation, ether 'pull' or 'push'.
+//   begin_timestamp - The begin timestamp, time format is unknown.
+//   end_timestamp   - The end timestamp, time format is unknown.
+//   page            - The page nubmer, default is 1.
+//   page_size       - The size of per page, default is 10, maximum is 100.
+//
+// format:
+//   GET /projects/{project_id}/logs
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/projects/86/logs?username=admin&repository=temp_5&tag=v6&operation=pull&page=1&page_size=10'
+func (c *Client) ListProjectLogs(projectID int, opt *ProjectLogListOptions) ([]*AccessLog, error) {
+	if opt == nil {
+		opt = &ProjectLogListOptions{}
+	}
+	q := url.Values{}
+	setIfNotEmpty(q, "username", opt.Username)
+	setIfNotEmpty(q, "repository", opt.Repository)
+	setIfNotEmpty(q, "tag", opt.Tag)
+	setIfNotEmpty(q, "operation", opt.Operation)
+	setIfNotEmpty(q, "begin_timestamp", opt.BeginTimestamp)
+	setIfNotEmpty(q, "end_timestamp", opt.EndTimestamp)
+
+	var logs []*AccessLog
+	err := c.call("GET", "/api/projects/"+strconv.Itoa(projectID)+"/logs",
+		pageQuery(q, opt.Page, opt.PageSize), nil, &logs)
+	return logs, err
+}
+
+// GetProjectMetadata returns metadata of the project specified by project ID.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//
+// format:
+//   GET /projects/{project_id}/metadatas
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/projects/86/metadatas'
+func (c *Client) GetProjectMetadata(projectID int) (map[string]string, error) {
+	var meta map[string]string
+	err := c.call("GET", "/api/projects/"+strconv.Itoa(projectID)+"/metadatas", nil, nil, &meta)
+	return meta, err
+}
+
+// AddProjectMetadata is aimed to add metadata of a project.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   metadata   - The metadata to add, e.g. public, enable_content_trust,
+//                prevent_vul, severity, auto_scan.
+//
+// format:
+//   POST /projects/{project_id}/metadatas
+//
+// e.g.
+/*
+curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/plain' -d '{ \
+   "public": "false" \
+ }' 'https://localhost/api/projects/86/metadatas'
+*/
+func (c *Client) AddProjectMetadata(projectID int, metadata map[string]string) error {
+	return c.call("POST", "/api/projects/"+strconv.Itoa(projectID)+"/metadatas", nil, metadata, nil)
+}
+
+// GetProjectMetadataByName returns specified metadata of a project.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   meta_name  - (REQUIRED) The name of metadata.
+//
+// format:
+//   GET /projects/{project_id}/metadatas/{meta_name}
+//
+// e.g. curl -X GET --header 'Accept: text/plain' 'https://localhost/api/projects/86/metadatas/metaname_new'
+func (c *Client) GetProjectMetadataByName(projectID int, name string) (map[string]string, error) {
+	var meta map[string]string
+	err := c.call("GET", "/api/projects/"+strconv.Itoa(projectID)+"/metadatas/"+name, nil, nil, &meta)
+	return meta, err
+}
+
+// UpdateProjectMetadataByName is aimed to update the metadata of a project.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   meta_name  - (REQUIRED) The name of metadata.
+//   value      - The new value of metadata.
+//
+// format:
+//   PUT /projects/{project_id}/metadatas/{meta_name}
+//
+// e.g.
+/*
+curl -X PUT --header 'Content-Type: application/json' --header 'Accept: text/plain' -d '{ \
+   "auto_scan": "true" \
+ }' 'https://localhost/api/projects/86/metadatas/auto_scan'
+*/
+func (c *Client) UpdateProjectMetadataByName(projectID int, name, value string) error {
+	return c.call("PUT", "/api/projects/"+strconv.Itoa(projectID)+"/metadatas/"+name,
+		nil, map[string]string{name: value}, nil)
+}
+
+// DeleteProjectMetadataByName is aimed to delete metadata of a project.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   meta_name  - (REQUIRED) The name of metadata.
+//
+// format:
+//   DELETE /projects/{project_id}/metadatas/{meta_name}
+//
+// e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/projects/86/metadatas/metaname-new'
+func (c *Client) DeleteProjectMetadataByName(projectID int, name string) error {
+	return c.call("DELETE", "/api/projects/"+strconv.Itoa(projectID)+"/metadatas/"+name, nil, nil, nil)
+}
+
+// ProjectMember is a user or group bound to a project with a role.
+type ProjectMember struct {
+	ID         int    `json:"id"`
+	ProjectID  int    `json:"project_id"`
+	EntityName string `json:"entity_name"`
+	RoleName   string `json:"role_name"`
+	RoleID     int    `json:"role_id"`
+	EntityID   int    `json:"entity_id"`
+	EntityType string `json:"entity_type"`
+}
+
+// ProjectMemberReq defines the member settings of a project.
+/*
+{
+  "role_id": 2,
+  "member_user": {
+    "username": "fei.sun"
+  }
+}
+*/
+type ProjectMemberReq struct {
+	RoleID      int          `json:"role_id,omitempty"`
+	MemberUser  *MemberUser  `json:"member_user,omitempty"`
+	MemberGroup *MemberGroup `json:"member_group,omitempty"`
+}
+
+// MemberUser identifies the user of a project member, by user_id or username.
+type MemberUser struct {
+	UserID   int    `json:"user_id,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+// MemberGroup identifies the group of a project member, by id or ldap_group_dn.
+type MemberGroup struct {
+	ID          int    `json:"id,omitempty"`
+	GroupName   string `json:"group_name,omitempty"`
+	GroupType   int    `json:"group_type,omitempty"`
+	LdapGroupDn string `json:"ldap_group_dn,omitempty"`
+}
+
+// ListProjectMembers gets all members information of the project.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   entityname - The entity name to search (filter).
+//
+// format:
+//   GET /projects/{project_id}/members
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/projects/86/members?entityname=admin'
+func (c *Client) ListProjectMembers(projectID int, entityName string) ([]*ProjectMember, error) {
+	q := url.Values{}
+	setIfNotEmpty(q, "entityname", entityName)
+
+	var members []*ProjectMember
+	err := c.call("GET", "/api/projects/"+strconv.Itoa(projectID)+"/members", q, nil, &members)
+	return members, err
+}
+
+// GetProjectMember gets a member of the project.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   mid        - (REQUIRED) Member ID.
+//
+// format:
+//   GET /projects/{project_id}/members/{mid}
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/projects/86/members/86'
+func (c *Client) GetProjectMember(projectID, mid int) (*ProjectMember, error) {
+	var m ProjectMember
+	if err := c.call("GET", "/api/projects/"+strconv.Itoa(projectID)+"/members/"+strconv.Itoa(mid),
+		nil, nil, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// CreateProjectMember creates project member relationship, the member can be one of the user_member and group_member, The user_member need to specify user_id or username. If the user already exist in harbor DB, specify the user_id, If does not exist in harbor DB, it will SearchAndOnBoard the user. The group_member need to specify id or ldap_group_dn. If the group already exist in harbor DB. specify the user group's id, If does not exist, it will SearchAndOnBoard the group.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   member     - (REQUIRED) The role and the user or group of the member.
+//
+// format:
+//   POST /projects/{project_id}/members
+//
+// e.g.
+/*
+curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/plain' -d '{ \
+   "role_id": 3, \
+   "member_user": { \
+     "username": "fei.sun" \
+   } \
+ }' 'https://localhost/api/projects/86/members'
+*/
+func (c *Client) CreateProjectMember(projectID int, member *ProjectMemberReq) error {
+	return c.call("POST", "/api/projects/"+strconv.Itoa(projectID)+"/members", nil, member, nil)
+}
+
+// UpdateProjectMember updates the role of a member of the project.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   mid        - (REQUIRED) Member ID.
+//   role_id    - (REQUIRED) Role ID. Only 1 (projectAdmin),2 (developer), 3 (guest) are valid.
+//
+// format:
+//   PUT /projects/{project_id}/members/{mid}
+//
+// e.g.
+/*
+curl -X PUT --header 'Content-Type: application/json' --header 'Accept: text/plain' -d '{ \
+   "role_id": 1 \
+ }' 'https://localhost/api/projects/86/members/86'
+*/
+func (c *Client) UpdateProjectMember(projectID, mid, roleID int) error {
+	return c.call("PUT", "/api/projects/"+strconv.Itoa(projectID)+"/members/"+strconv.Itoa(mid),
+		nil, &ProjectMemberReq{RoleID: roleID}, nil)
+}
+
+// DeleteProjectMember deletes a member of the project.
+//
+// params:
+//   project_id - (REQUIRED) The ID of project.
+//   mid        - (REQUIRED) Member ID.
+//
+// format:
+//   DELETE /projects/{project_id}/members/{mid}
+//
+// e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/projects/86/members/86'
+func (c *Client) DeleteProjectMember(projectID, mid int) error {
+	return c.call("DELETE", "/api/projects/"+strconv.Itoa(projectID)+"/members/"+strconv.Itoa(mid),
+		nil, nil, nil)
+}
+
+func setIfNotEmpty(q url.Values, key, value string) {
+	if value != "" {
+		q.Set(key, value)
+	}
+}
@@ -0,0 +1,19 @@
+package harbor
+
+// TriggerReplication is used to trigger a replication according to the specified policy.
+//
+// params:
+//  policy_id - (REQUIRED) The ID of replication policy.
+//
+// format:
+//  POST /replications
+//
+// e.g.
+/*
+  curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/plain' -d '{ \
+     "policy_id": 1 \
+   }' 'https://localhost/api/replications'
+*/
+func (c *Client) TriggerReplication(policyID int) error {
+	return c.call("POST", "/api/replications", nil, map[string]int{"policy_id": policyID}, nil)
+}
@@ -0,0 +1,308 @@
+package harbor
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// Repository is a repository of Harbor.
+type Repository struct {
+	ID           int      `json:"id"`
+	Name         string   `json:"name"`
+	ProjectID    int      `json:"project_id"`
+	Description  string   `json:"description"`
+	PullCount    int      `json:"pull_count"`
+	StarCount    int      `json:"star_count"`
+	TagsCount    int      `json:"tags_count"`
+	Labels       []*Label `json:"labels"`
+	CreationTime string   `json:"creation_time"`
+	UpdateTime   string   `json:"update_time"`
+}
+
+// Manifest is the manifest of an image together with its config.
+type Manifest struct {
+	Manifest interface{} `json:"manifest"`
+	Config   string      `json:"config"`
+}
+
+// Signature is the notary signature of a tag.
+type Signature struct {
+	Tag    string            `json:"tag"`
+	Hashes map[string]string `json:"hashes"`
+}
+
+// RepositoryListOptions filters the result of ListRepositories.
+type RepositoryListOptions struct {
+	ProjectID int
+	Q         string
+	LabelID   int
+	Page      int
+	PageSize  int
+}
+
+// ListRepositories let user search repositories accompanying with relevant project ID and repo name.
+//
+// params:
+//   project_id - (REQUIRED) Relevant project ID.
+//   q          - Repo name for filtering results.
+//   label_id   - The ID of label used to filter the result.
+//   page       - The page nubmer, default is 1.
+//   page_size  - The size of per page, default is 10, maximum is 100.
+//
+// format:
+//   GET /repositories
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/repositories?project_id=1&q=prj&label_id=100&page=1&page_size=10'
+func (c *Client) ListRepositories(opt *RepositoryListOptions) ([]*Repository, error) {
+	if opt == nil {
+		opt = &RepositoryListOptions{}
+	}
+	a, err := c.getAdapter()
+	if err != nil {
+		return nil, err
+	}
+	return a.listRepositories(c, opt)
+}
+
+// ListTopRepositories aims to let users see the most popular public repositories.
+//
+// params:
+//   count - The number of the requested public repositories, default is 10 if not provided.
+//
+// format:
+//   GET /repositories/top
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/repositories/top?count=3'
+func (c *Client) ListTopRepositories(count int) ([]*Repository, error) {
+	q := url.Values{}
+	q.Set("count", strconv.Itoa(count))
+
+	var repos []*Repository
+	err := c.call("GET", "/api/repositories/top", q, nil, &repos)
+	return repos, err
+}
+
+// DeleteRepository let user delete a repository with name.
+//
+// params:
+//   repo_name - (REQUIRED) The name of repository which will be deleted.
+//
+// format:
+//   DELETE /repositories/{repo_name}
+//
+// e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/repositories/prj1%2Fhello-world'
+func (c *Client) DeleteRepository(repoName string) error {
+	return c.call("DELETE", "/api/repositories/"+repoName, nil, nil, nil)
+}
+
+// UpdateRepositoryDescription is used to update description of the repository.
+//
+// params:
+//   repo_name   - (REQUIRED) The name of repository which will be updated.
+//   description - (REQUIRED) The description of the repository.
+//
+// format:
+//   PUT /repositories/{repo_name}
+//
+// e.g.
+/*
+curl -X PUT --header 'Content-Type: application/json' --header 'Accept: text/plain' -d '{ \
+   "description": "change" \
+ }' 'https://localhost/api/repositories/temp_5%2Fhello-world'
+*/
+func (c *Client) UpdateRepositoryDescription(repoName, description string) error {
+	return c.call("PUT", "/api/repositories/"+repoName, nil,
+		map[string]string{"description": description}, nil)
+}
+
+// ListRepositoryLabels get labels of a repository specified by the repo_name.
+//
+// params:
+//   repo_name - (REQUIRED) The name of repository.
+//
+// format:
+//   GET /repositories/{repo_name}/labels
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/repositories/temp_5%2Fhello-world/labels'
+func (c *Client) ListRepositoryLabels(repoName string) ([]*Label, error) {
+	var labels []*Label
+	err := c.call("GET", "/api/repositories/"+repoName+"/labels", nil, nil, &labels)
+	return labels, err
+}
+
+// AddRepositoryLabel adds an already existing label (global or project specific) to the repository.
+//
+// params:
+//   repo_name - (REQUIRED) The name of repository that you want to add a label.
+//   label     - (REQUIRED) The already existing label, only its ID is mandatory.
+//
+// format:
+//   POST /repositories/{repo_name}/labels
+//
+// e.g.
+/*
+curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/plain' -d '{ \
+   "id": 2, \
+   "name": "test-name", \
+   "description": "test-description", \
+   "color": "test-color", \
+   "scope": "test-scope", \
+   "project_id": 10, \
+   "deleted": true \
+ }' 'https://localhost/api/repositories/temp_5%2Fhello-world/labels'
+*/
+func (c *Client) AddRepositoryLabel(repoName string, label *Label) error {
+	return c.call("POST", "/api/repositories/"+repoName+"/labels", nil, label, nil)
+}
+
+// DeleteRepositoryLabel deletes the label from the repository specified by the repo_name.
+//
+// params:
+//   repo_name - (REQUIRED) The name of repository that you want to delete a label from.
+//   label_id  - (REQUIRED) The ID of label.
+//
+// format:
+//   DELETE /repositories/{repo_name}/labels/{label_id}
+//
+// e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/repositories/temp_3%2Fhello-world/labels/2'
+func (c *Client) DeleteRepositoryLabel(repoName string, labelID int) error {
+	return c.call("DELETE", "/api/repositories/"+repoName+"/labels/"+strconv.Itoa(labelID), nil, nil, nil)
+}
+
+// ListImageLabels gets labels of an image specified by the repo_name and tag.
+//
+// params:
+//   repo_name - (REQUIRED) The name of repository.
+//   tag       - (REQUIRED) The tag of the image.
+//
+// format:
+//   GET /repositories/{repo_name}/tags/{tag}/labels
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/repositories/temp_3%2Fhello-world/tags/v1/labels'
+func (c *Client) ListImageLabels(repoName, tag string) ([]*Label, error) {
+	var labels []*Label
+	err := c.call("GET", "/api/repositories/"+repoName+"/tags/"+tag+"/labels", nil, nil, &labels)
+	return labels, err
+}
+
+// AddImageLabel adds a label to the image under specific repository.
+//
+// params:
+//   repo_name - (REQUIRED) The name of repository that you want to add a label.
+//   tag       - (REQUIRED) The tag of the image.
+//   label     - (REQUIRED) The already existing label, only its ID is mandatory.
+//
+// format:
+//   POST /repositories/{repo_name}/tags/{tag}/labels
+//
+// e.g.
+/*
+curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/plain' -d '{ \
+   "id": 3, \
+   "name": "test-name", \
+   "description": "test-description", \
+   "color": "test-color", \
+   "scope": "test-scope", \
+   "project_id": 10, \
+   "deleted": true \
+ }' 'https://localhost/api/repositories/temp_3%2Fhello-world/tags/v1/labels'
+*/
+func (c *Client) AddImageLabel(repoName, tag string, label *Label) error {
+	return c.call("POST", "/api/repositories/"+repoName+"/tags/"+tag+"/labels", nil, label, nil)
+}
+
+// DeleteImageLabel deletes the label from the image specified by the repo_name and tag.
+//
+// params:
+//   repo_name - (REQUIRED) The name of repository.
+//   tag       - (REQUIRED) The tag of the image.
+//   label_id  - (REQUIRED) The ID of label.
+//
+// format:
+//   DELETE /repositories/{repo_name}/tags/{tag}/labels/{label_id}
+//
+// e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/repositories/temp_3%2Fhello-world/tags/v1/labels/2'
+func (c *Client) DeleteImageLabel(repoName, tag string, labelID int) error {
+	return c.call("DELETE", "/api/repositories/"+repoName+"/tags/"+tag+"/labels/"+strconv.Itoa(labelID),
+		nil, nil, nil)
+}
+
+// VulnerabilityItem is a vulnerability found in an image by Clair.
+type VulnerabilityItem struct {
+	ID          string `json:"id"`
+	Severity    int    `json:"severity"` // 1 (none), 2 (unknown), 3 (low), 4 (medium) or 5 (high)
+	Package     string `json:"package"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+	Link        string `json:"link"`
+	Fixed       string `json:"fixedVersion"`
+}
+
+// ScanImage triggers jobservice to call Clair API to scan the image identified by the repo_name and tag.
+// Only project admins have permission to scan images under the project.
+//
+// params:
+//   repo_name - (REQUIRED) The name of repository.
+//   tag       - (REQUIRED) The tag of the image.
+//
+// format:
+//   POST /repositories/{repo_name}/tags/{tag}/scan
+//
+// e.g. curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/plain' 'https://localhost/api/repositories/temp_3%2Fhello-world/tags/v1/scan'
+func (c *Client) ScanImage(repoName, tag string) error {
+	return c.call("POST", "/api/repositories/"+repoName+"/tags/"+tag+"/scan", nil, nil, nil)
+}
+
+// ListImageVulnerabilities calls Clair API to get the vulnerabilities of the image based on the previous successful scan.
+//
+// params:
+//   repo_name - (REQUIRED) The name of repository.
+//   tag       - (REQUIRED) The tag of the image.
+//
+// format:
+//   GET /repositories/{repo_name}/tags/{tag}/vulnerability/details
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/repositories/temp_3%2Fhello-world/tags/v1/vulnerability/details'
+func (c *Client) ListImageVulnerabilities(repoName, tag string) ([]*VulnerabilityItem, error) {
+	var vulns []*VulnerabilityItem
+	err := c.call("GET", "/api/repositories/"+repoName+"/tags/"+tag+"/vulnerability/details", nil, nil, &vulns)
+	return vulns, err
+}
+
+// GetImageManifest aims to retrieve manifests from a relevant repository.
+//
+// params:
+//   repo_name - (REQUIRED) The name of repository.
+//   tag       - (REQUIRED) The tag of the image.
+//   version   - The version of manifest, valid value are "v1" and "v2", default is "v2".
+//
+// format:
+//   GET /repositories/{repo_name}/tags/{tag}/manifest
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/repositories/temp_3%2Fhello-world/tags/v1/manifest?version=v2'
+func (c *Client) GetImageManifest(repoName, tag, version string) (*Manifest, error) {
+	q := url.Values{}
+	setIfNotEmpty(q, "version", version)
+
+	var m Manifest
+	if err := c.call("GET", "/api/repositories/"+repoName+"/tags/"+tag+"/manifest", q, nil, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ListRepositorySignatures aims to retrieve signature information of a repository, the data is from the nested notary instance of Harbor.
+// If the repository does not have any signature information in notary, this API will return an empty list with response code 200, instead of 404.
+//
+// params:
+//   repo_name - (REQUIRED) The name of repository.
+//
+// format:
+//   GET /repositories/{repo_name}/signatures
+//
+// e.g. curl -X GET --header 'Accept: text/plain' 'https://localhost/api/repositories/temp_3%2Fhello-world/signatures'
+func (c *Client) ListRepositorySignatures(repoName string) ([]*Signature, error) {
+	var sigs []*Signature
+	err := c.call("GET", "/api/repositories/"+repoName+"/signatures", nil, nil, &sigs)
+	return sigs, err
+}
@@ -0,0 +1,42 @@
+package harbor
+
+import "net/url"
+
+// SearchRepository is a repository item of SearchResult.
+type SearchRepository struct {
+	ProjectID      int    `json:"project_id"`
+	ProjectName    string `json:"project_name"`
+	ProjectPublic  bool   `json:"project_public"`
+	PullCount      int    `json:"pull_count"`
+	RepositoryName string `json:"repository_name"`
+	TagsCount      int    `json:"tags_count"`
+}
+
+// SearchResult is the projects and repositories matched by Search.
+type SearchResult struct {
+	Project    []*Project          `json:"project"`
+	Repository []*SearchRepository `json:"repository"`
+}
+
+// Search returns information about the projects and repositories offered at public status or related to the current logged in user. The response includes the project and repository list in a proper display order.
+//
+// NOTE:
+// By setting "q=" query parameter, you can obtain ALL items.
+//
+// params:
+//  q - (REQUIRED) Search parameter for project and repository name.
+//
+// format:
+//  GET /search
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/search?q=hello-world'
+func (c *Client) Search(q string) (*SearchResult, error) {
+	query := url.Values{}
+	query.Set("q", q)
+
+	var r SearchResult
+	if err := c.call("GET", "/api/search", query, nil, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
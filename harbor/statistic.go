@@ -0,0 +1,25 @@
+package harbor
+
+// Statistics is the number of projects and repositories relevant to the user.
+type Statistics struct {
+	PrivateProjectCount int `json:"private_project_count"`
+	PrivateRepoCount    int `json:"private_repo_count"`
+	PublicProjectCount  int `json:"public_project_count"`
+	PublicRepoCount     int `json:"public_repo_count"`
+	TotalProjectCount   int `json:"total_project_count"`
+	TotalRepoCount      int `json:"total_repo_count"`
+}
+
+// GetStatistics is aimed to statistic all of the projects number and repositories number relevant to the logined user, also the public projects number and repositories number. If the user is admin, he can also get total projects number and total repositories number.
+//
+// format:
+//  GET /statistics
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/statistics'
+func (c *Client) GetStatistics() (*Statistics, error) {
+	var s Statistics
+	if err := c.call("GET", "/api/statistics", nil, nil, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
@@ -0,0 +1,67 @@
+package harbor
+
+// SystemInfo is the general system info of Harbor.
+type SystemInfo struct {
+	WithNotary                  bool        `json:"with_notary"`
+	WithClair                   bool        `json:"with_clair"`
+	WithAdmiral                 bool        `json:"with_admiral"`
+	AdmiralEndpoint             string      `json:"admiral_endpoint"`
+	AuthMode                    string      `json:"auth_mode"`
+	RegistryURL                 string      `json:"registry_url"`
+	ProjectCreationRestriction  string      `json:"project_creation_restriction"`
+	SelfRegistration            bool        `json:"self_registration"`
+	HasCARoot                   bool        `json:"has_ca_root"`
+	HarborVersion               string      `json:"harbor_version"`
+	NextScanAll                 int64       `json:"next_scan_all"`
+	ClairVulnerabilityStatus    interface{} `json:"clair_vulnerability_status,omitempty"`
+	RegistryStorageProviderName string      `json:"registry_storage_provider_name"`
+	ReadOnly                    bool        `json:"read_only"`
+}
+
+// SystemVolumes is the volume info of Harbor storage.
+type SystemVolumes struct {
+	Storage struct {
+		Total uint64 `json:"total"`
+		Free  uint64 `json:"free"`
+	} `json:"storage"`
+}
+
+// GetSystemInfo is for retrieving general system info, this can be called by anonymous request.
+//
+// format:
+//  GET /systeminfo
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/systeminfo'
+func (c *Client) GetSystemInfo() (*SystemInfo, error) {
+	var info SystemInfo
+	if err := c.call("GET", "/api/systeminfo", nil, nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// GetSystemVolumes is for retrieving system volume info that only provides for admin user.
+//
+// format:
+//  GET /systeminfo/volumes
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/systeminfo/volumes'
+func (c *Client) GetSystemVolumes() (*SystemVolumes, error) {
+	var v SystemVolumes
+	if err := c.call("GET", "/api/systeminfo/volumes", nil, nil, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// GetRootCert is for downloading a default root certificate that only provides for admin user under OVA deployment.
+//
+// format:
+//  GET /systeminfo/getcert
+//
+// e.g. curl -X GET --header 'Accept: text/plain' 'https://localhost/api/systeminfo/getcert'
+func (c *Client) GetRootCert() ([]byte, error) {
+	var cert []byte
+	err := c.call("GET", "/api/systeminfo/getcert", nil, nil, &cert)
+	return cert, err
+}
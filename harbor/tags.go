@@ -0,0 +1,80 @@
+package harbor
+
+// Tag is the detail of an image tag.
+type Tag struct {
+	Digest        string      `json:"digest"`
+	Name          string      `json:"name"`
+	Size          int64       `json:"size"`
+	Architecture  string      `json:"architecture"`
+	OS            string      `json:"os"`
+	DockerVersion string      `json:"docker_version"`
+	Author        string      `json:"author"`
+	Created       string      `json:"created"`
+	Signature     interface{} `json:"signature"`
+	Labels        []*Label    `json:"labels"`
+	// PushTime and PullTime are returned since Harbor 1.9.
+	PushTime string `json:"push_time,omitempty"`
+	PullTime string `json:"pull_time,omitempty"`
+}
+
+// ListTags aims to retrieve tags from a relevant repository. If deployed with Notary, the signature property of response represents whether the image is singed or not. If the property is null, the image is unsigned.
+//
+// params:
+//
+//	repo_name - (REQUIRED) Relevant repository name.
+//
+// format:
+//
+//	GET /repositories/{repo_name}/tags
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/repositories/prj2%2Fphoton/tags'
+//
+// With Harbor 2.x, a Tag is made for every tag of the artifacts of the
+// repository.
+func (c *Client) ListTags(repoName string) ([]*Tag, error) {
+	a, err := c.getAdapter()
+	if err != nil {
+		return nil, err
+	}
+	return a.listTags(c, repoName)
+}
+
+// GetTag aims to retrieve the tag of the repository. If deployed with Notary, the signature property of
+// response represents whether the image is singed or not. If the property is null, the image is unsigned.
+//
+// params:
+//
+//	repo_name - (REQUIRED) Relevant repository name.
+//	tag       - (REQUIRED) Tag of the repository.
+//
+// format:
+//
+//	GET /repositories/{repo_name}/tags/{tag}
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/repositories/prj2%2Fphoton/tags/v2'
+func (c *Client) GetTag(repoName, tag string) (*Tag, error) {
+	a, err := c.getAdapter()
+	if err != nil {
+		return nil, err
+	}
+	return a.getTag(c, repoName, tag)
+}
+
+// DeleteTag let user delete tags with repo name and tag.
+//
+// params:
+//
+//	repo_name - (REQUIRED) The name of repository which will be deleted.
+//	tag       - (REQUIRED) Tag of a repository.
+//
+// format:
+//
+//	DELETE /repositories/{repo_name}/tags/{tag}
+//
+// e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/repositories/prj2%2Fphoton/tags/v2'
+//
+// NOTE: the manifest of the tag is deleted, so are all the other tags of the
+// same digest. With Harbor 2.x, the artifact of the tag is deleted.
+func (c *Client) DeleteTag(repoName, tag string) error {
+	return c.call("DELETE", "/api/repositories/"+repoName+"/tags/"+tag, nil, nil, nil)
+}
@@ -0,0 +1,172 @@
+package harbor
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// Target is a replication target (endpoint) of Harbor.
+type Target struct {
+	ID           int    `json:"id,omitempty"`
+	Endpoint     string `json:"endpoint"`
+	Name         string `json:"name,omitempty"`
+	Username     string `json:"username"`
+	Password     string `json:"password,omitempty"`
+	Type         int    `json:"type,omitempty"`
+	Insecure     bool   `json:"insecure"`
+	CreationTime string `json:"creation_time,omitempty"`
+	UpdateTime   string `json:"update_time,omitempty"`
+}
+
+// ListTargets let user list filters targets by name, if name is nil, list returns all targets.
+//
+// params:
+//  name - The replication's target name (for filter).
+//
+// format:
+//  GET /targets
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/targets?name=remote'
+func (c *Client) ListTargets(name string) ([]*Target, error) {
+	q := url.Values{}
+	setIfNotEmpty(q, "name", name)
+
+	var targets []*Target
+	err := c.call("GET", "/api/targets", q, nil, &targets)
+	return targets, err
+}
+
+// CreateTarget is for user to create a new replication target.
+//
+// params:
+//  endpoint - The target address URL string
+//  name     - The target name.
+//  username - The target server username.
+//  password - The target server password.
+//  insecure - Whether or not the certificate will be verified when Harbor tries to access the server.
+//
+// format:
+//  POST /targets
+//
+// e.g.
+/*
+curl -X POST --header 'Content-Type: text/plain' --header 'Accept: text/plain' -d '{
+  "endpoint": "https://11.11.11.14",
+  "name": "rule4",
+  "username": "admin",
+  "password": "Harbor12345",
+  "insecure": true
+}' 'https://localhost/api/targets'
+*/
+func (c *Client) CreateTarget(target *Target) error {
+	return c.call("POST", "/api/targets", nil, target, nil)
+}
+
+// PingTarget is for ping validates whether the target is reachable and whether the credential is valid.
+//
+// params:
+//  endpoint - The target address URL string
+//  username - The target server username.
+//  password - The target server password.
+//  insecure - Whether or not the certificate will be verified when Harbor tries to access the server.
+//
+// format:
+//  POST /targets/ping
+//
+// e.g.
+/*
+curl -X POST --header 'Content-Type: text/plain' --header 'Accept: text/plain' -d '{
+  "endpoint": "https://11.11.11.11",
+  "username": "admin",
+  "password": "Harbor12345",
+  "insecure": true
+}' 'https://localhost/api/targets/ping'
+*/
+func (c *Client) PingTarget(target *Target) error {
+	return c.call("POST", "/api/targets/ping", nil, target, nil)
+}
+
+// PingTargetByID is for ping target. (deprecated)
+//
+// params:
+//  id - (REQUIRED) The replication's target ID.
+//
+// format:
+//  POST /targets/{id}/ping
+//
+// e.g. curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/plain' 'https://localhost/api/targets/1/ping'
+func (c *Client) PingTargetByID(id int) error {
+	return c.call("POST", "/api/targets/"+strconv.Itoa(id)+"/ping", nil, nil, nil)
+}
+
+// GetTarget is for get specific replication's target.
+//
+// params:
+//  id - (REQUIRED) The replication's target ID.
+//
+// format:
+//  GET /targets/{id}
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/targets/1'
+func (c *Client) GetTarget(id int) (*Target, error) {
+	var t Target
+	if err := c.call("GET", "/api/targets/"+strconv.Itoa(id), nil, nil, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// UpdateTarget is for update specific replication's target.
+//
+// params:
+//  id       - (REQUIRED) The replication's target ID.
+//  endpoint - The target address URL string
+//  name     - The target name.
+//  username - The target server username.
+//  password - The target server password.
+//  insecure - Whether or not the certificate will be verified when Harbor tries to access the server.
+//
+// format:
+//  PUT /targets/{id}
+//
+// e.g.
+/*
+curl -X PUT --header 'Content-Type: text/plain' --header 'Accept: text/plain' -d '{
+  "endpoint": "https://11.11.11.14",
+  "name": "change from rule4 to RULE4",
+  "username": "admin",
+  "password": "12345",
+  "insecure": true
+}' 'https://localhost/api/targets/4'
+*/
+func (c *Client) UpdateTarget(id int, target *Target) error {
+	return c.call("PUT", "/api/targets/"+strconv.Itoa(id), nil, target, nil)
+}
+
+// DeleteTarget is for to delete specific replication's target.
+//
+// params:
+//  id - (REQUIRED) The replication's target ID.
+//
+// format:
+//  DELETE /targets/{id}
+//
+// e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/targets/2'
+func (c *Client) DeleteTarget(id int) error {
+	return c.call("DELETE", "/api/targets/"+strconv.Itoa(id), nil, nil, nil)
+}
+
+// ListTargetPolicies lists policies filter with specific replication's target ID.
+//
+// params:
+//  id - (REQUIRED) The replication's target ID.
+//
+// format:
+//  GET /targets/{id}/policies/
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/targets/1/policies/'
+func (c *Client) ListTargetPolicies(id int) ([]*ReplicationPolicy, error) {
+	var policies []*ReplicationPolicy
+	err := c.call("GET", "/api/targets/"+strconv.Itoa(id)+"/policies/", nil, nil, &policies)
+	return policies, err
+}
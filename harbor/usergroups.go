@@ -0,0 +1,83 @@
+package harbor
+
+import "strconv"
+
+// UserGroup is a user group of Harbor, e.g. a LDAP group.
+type UserGroup struct {
+	ID          int    `json:"id"`
+	GroupName   string `json:"group_name"`
+	GroupType   int    `json:"group_type"`
+	LdapGroupDN string `json:"ldap_group_dn"`
+}
+
+// ListUserGroups gets all user groups information.
+//
+// format:
+//  GET /usergroups
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/usergroups'
+func (c *Client) ListUserGroups() ([]*UserGroup, error) {
+	var groups []*UserGroup
+	err := c.call("GET", "/api/usergroups", nil, nil, &groups)
+	return groups, err
+}
+
+// CreateUserGroup creates user group information.
+//
+// format:
+//  POST /usergroups
+//
+// e.g.
+/*
+curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/plain' -d '{ \
+   "id": 100, \
+   "group_name": "tmp-group", \
+   "group_type": 1, \
+   "ldap_group_dn": "" \
+ }' 'https://localhost/api/usergroups'
+*/
+func (c *Client) CreateUserGroup(group *UserGroup) error {
+	return c.call("POST", "/api/usergroups", nil, group, nil)
+}
+
+// GetUserGroup gets user group information.
+//
+// format:
+//  GET /usergroups/{group_id}
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/usergroups/1'
+func (c *Client) GetUserGroup(groupID int) (*UserGroup, error) {
+	var g UserGroup
+	if err := c.call("GET", "/api/usergroups/"+strconv.Itoa(groupID), nil, nil, &g); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// UpdateUserGroup updates group information.
+//
+// format:
+//  PUT /usergroups/{group_id}
+//
+// e.g.
+/*
+curl -X PUT --header 'Content-Type: application/json' --header 'Accept: text/plain' -d '{ \
+   "id": 1, \
+   "group_name": "tmp-group", \
+   "group_type": 1, \
+   "ldap_group_dn": "" \
+ }' 'https://localhost/api/usergroups/1'
+*/
+func (c *Client) UpdateUserGroup(groupID int, group *UserGroup) error {
+	return c.call("PUT", "/api/usergroups/"+strconv.Itoa(groupID), nil, group, nil)
+}
+
+// DeleteUserGroup deletes user group.
+//
+// format:
+//  DELETE /usergroups/{group_id}
+//
+// e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/usergroups/1'
+func (c *Client) DeleteUserGroup(groupID int) error {
+	return c.call("DELETE", "/api/usergroups/"+strconv.Itoa(groupID), nil, nil, nil)
+}
@@ -0,0 +1,219 @@
+package harbor
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// User is a registered user of Harbor.
+type User struct {
+	UserID       int    `json:"user_id"`
+	Username     string `json:"username"`
+	Email        string `json:"email"`
+	Password     string `json:"password,omitempty"`
+	Realname     string `json:"realname"`
+	Comment      string `json:"comment"`
+	Deleted      bool   `json:"deleted"`
+	RoleName     string `json:"role_name"`
+	RoleID       int    `json:"role_id"`
+	HasAdminRole bool   `json:"has_admin_role"`
+	ResetUUID    string `json:"reset_uuid"`
+	Salt         string `json:"Salt"`
+	CreationTime string `json:"creation_time"`
+	UpdateTime   string `json:"update_time"`
+}
+
+// UserProfile holds the properties of a user that can be changed by himself.
+type UserProfile struct {
+	Email    string `json:"email"`
+	Realname string `json:"realname"`
+	Comment  string `json:"comment"`
+}
+
+// UserSearchOptions filters the result of SearchUsers.
+type UserSearchOptions struct {
+	Username string
+	Email    string
+	Page     int
+	PageSize int
+}
+
+// SearchUsers gets registered users of Harbor. Notice, by now this operation is only for administrator.
+//
+// params:
+//  username  - Username for filtering results.
+//  email     - Email for filtering results.
+//  page      - The page nubmer, default is 1.
+//  page_size - The size of per page, default is 10.
+//
+// format:
+//  GET /users
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/users?username=san.zhang&email=san.zhang@163.com&page=1&page_size=10'
+func (c *Client) SearchUsers(opt *UserSearchOptions) ([]*User, error) {
+	if opt == nil {
+		opt = &UserSearchOptions{}
+	}
+
+	var users []*User
+	err := c.call("GET", "/api/users", pageQuery(opt.query(), opt.Page, opt.PageSize), nil, &users)
+	return users, err
+}
+
+func (opt *UserSearchOptions) query() url.Values {
+	q := url.Values{}
+	setIfNotEmpty(q, "username", opt.Username)
+	setIfNotEmpty(q, "email", opt.Email)
+	return q
+}
+
+// UserIterator iterates over the users returned by SearchUsers, page by page.
+type UserIterator struct {
+	iterator
+}
+
+// IterUsers returns an iterator over all the users matching opt, from
+// opt.Page with opt.PageSize (MaxPageSize by default) users per request.
+func (c *Client) IterUsers(opt *UserSearchOptions) *UserIterator {
+	if opt == nil {
+		opt = &UserSearchOptions{}
+	}
+	p := c.NewPager("/api/users", opt.query(), opt.Page, opt.PageSize)
+	return &UserIterator{newIterator(p, []*User(nil))}
+}
+
+// User returns the current user.
+func (it *UserIterator) User() *User {
+	u, _ := it.Item().(*User)
+	return u
+}
+
+// GetCurrentUser gets the current user information.
+//
+// format:
+//  GET /users/current
+//
+// e.g. curl -X GET --header 'Accept: application/json' 'https://localhost/api/users/current'
+func (c *Client) GetCurrentUser() (*User, error) {
+	var u User
+	if err := c.call("GET", "/api/users/current", nil, nil, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// GetUser gets user's profile with user id.
+//
+// params:
+//  user_id - (REQUIRED) Registered user ID.
+//
+// format:
+//  GET /users/{user_id}
+//
+// e.g. curl -X GET --header 'Accept: text/plain' 'https://localhost/api/users/1'
+func (c *Client) GetUser(userID int) (*User, error) {
+	var u User
+	if err := c.call("GET", "/api/users/"+strconv.Itoa(userID), nil, nil, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// CreateUser creates a new user account if the user does not already exist.
+//
+// params:
+//  username       - (REQUIRED) User name.
+//  password       - (REQUIRED) User password.
+//  email          - (REQUIRED) User's email.
+//  has_admin_role - Mark a user whether is admin or not.
+//  realname       - User's realname.
+//  comment        - Custom comment.
+//
+// format:
+//  POST /users
+//
+// e.g.
+// curl -X POST --header 'Content-Type: application/json' --header 'Accept: text/plain' -d '{ \
+//    "user_id": 100, \
+//    "username": "san.zhang", \
+//    "email": "san.zhang%40163.com", \
+//    "password": "Harbor12345", \
+//    "realname": "san.zhang", \
+//    "comment": "I%27m Zhang San", \
+//    "has_admin_role": false \
+//  }' 'https://localhost/api/users'
+func (c *Client) CreateUser(user *User) error {
+	return c.call("POST", "/api/users", nil, user, nil)
+}
+
+// UpdateUserProfile let a registered user change his profile.
+//
+// params:
+//  user_id  - (REQUIRED) Registered user ID.
+//  email    - (REQUIRED) User email.
+//  realname - (REQUIRED) User's realname.
+//  comment  - (REQUIRED) Custom comment.
+//
+// format:
+//  PUT /users/{user_id}
+//
+// e.g.
+// curl -X PUT --header 'Content-Type: application/json' --header 'Accept: text/plain' -d '{ \
+//    "email": "si.li%40163.com", \
+//    "realname": "si.li", \
+//    "comment": "I'm Li Si" \
+//  }' 'https://localhost/api/users/1'
+func (c *Client) UpdateUserProfile(userID int, profile *UserProfile) error {
+	return c.call("PUT", "/api/users/"+strconv.Itoa(userID), nil, profile, nil)
+}
+
+// UpdateUserPassword is for user to update password. Users with the admin role can change any user's password. Guest users can change only their own password.
+//
+// params:
+//  user_id      - (REQUIRED) Registered user ID.
+//  old_password - (REQUIRED) Old password.
+//  new_password - (REQUIRED) New password.
+//
+// format:
+//  PUT /users/{user_id}/password
+//
+// e.g.
+// curl -X PUT --header 'Content-Type: application/json' --header 'Accept: text/plain' -d '{ \
+//    "old_password": "old password", \
+//    "new_password": "new password" \
+//  }' 'https://localhost/api/users/1/password'
+func (c *Client) UpdateUserPassword(userID int, oldPassword, newPassword string) error {
+	return c.call("PUT", "/api/users/"+strconv.Itoa(userID)+"/password", nil,
+		map[string]string{"old_password": oldPassword, "new_password": newPassword}, nil)
+}
+
+// UpdateUserRole let a registered user change to be an administrator of Harbor.
+//
+// params:
+//  user_id        - (REQUIRED) Registered user ID.
+//  has_admin_role - (REQUIRED) Toggle a user to admin or not.
+//
+// format:
+//  PUT /users/{user_id}/sysadmin
+//
+// e.g.
+// curl -X PUT --header 'Content-Type: application/json' --header 'Accept: text/plain' -d '{ \
+//    "has_admin_role": true \
+//  }' 'https://localhost/api/users/1/sysadmin'
+func (c *Client) UpdateUserRole(userID int, hasAdminRole bool) error {
+	return c.call("PUT", "/api/users/"+strconv.Itoa(userID)+"/sysadmin", nil,
+		map[string]bool{"has_admin_role": hasAdminRole}, nil)
+}
+
+// DeleteUser let administrator of Harbor mark a registered user as be removed. It actually won't be deleted from DB.
+//
+// params:
+//  user_id - (REQUIRED) User ID for marking as to be removed.
+//
+// format:
+//  DELETE /users/{user_id}
+//
+// e.g. curl -X DELETE --header 'Accept: text/plain' 'https://localhost/api/users/1'
+func (c *Client) DeleteUser(userID int) error {
+	return c.call("DELETE", "/api/users/"+strconv.Itoa(userID), nil, nil, nil)
+}
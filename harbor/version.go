@@ -0,0 +1,149 @@
+package harbor
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// APIVersion is a generation of the Harbor API. All the methods of Client are
+// written against Harbor 1.5, and translated by an adapter of the generation
+// of the server.
+type APIVersion string
+
+// API generations known by Client.
+const (
+	API15 APIVersion = "1.5" // Harbor 1.5 and earlier (swagger 1.4)
+	API16 APIVersion = "1.6" // Harbor 1.6 to 1.10
+	API20 APIVersion = "2.0" // Harbor 2.x (/api/v2.0)
+)
+
+// ErrUnsupported is matched by the errors of requests the server does not
+// support any more, e.g.
+//
+//	if errors.Is(err, harbor.ErrUnsupported) {
+//		...
+//	}
+var ErrUnsupported = errors.New("not supported by this version of harbor")
+
+// UnsupportedError reports a feature which does not exist in the API
+// generation of the server.
+type UnsupportedError struct {
+	Feature       string // e.g. "replication targets"
+	HarborVersion string // harbor_version of the server, if known
+	APIVersion    APIVersion
+	Hint          string // what replaces it, if any
+}
+
+func (e *UnsupportedError) Error() string {
+	version := e.HarborVersion
+	if version == "" {
+		version = "API " + string(e.APIVersion)
+	}
+	msg := fmt.Sprintf("%s: not supported by harbor %s", e.Feature, version)
+	if e.Hint != "" {
+		msg += " (" + e.Hint + ")"
+	}
+	return msg
+}
+
+// Is makes errors.Is(err, ErrUnsupported) work.
+func (e *UnsupportedError) Is(target error) bool {
+	return target == ErrUnsupported
+}
+
+var versionRe = regexp.MustCompile(`^v?(\d+)\.(\d+)`)
+
+// parseVersion returns the major and minor version of harborVersion, e.g.
+// "v1.10.2-6e4ad1b2" is 1 and 10.
+func parseVersion(harborVersion string) (major, minor int, err error) {
+	m := versionRe.FindStringSubmatch(harborVersion)
+	if m == nil {
+		return 0, 0, fmt.Errorf("unknown harbor version %q", harborVersion)
+	}
+	major, _ = strconv.Atoi(m[1])
+	minor, _ = strconv.Atoi(m[2])
+	return major, minor, nil
+}
+
+// ParseAPIVersion returns the API generation of harborVersion, which is the
+// harbor_version of /api/systeminfo, e.g. "v1.5.0-d59c257e".
+func ParseAPIVersion(harborVersion string) (APIVersion, error) {
+	major, minor, err := parseVersion(harborVersion)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case major >= 2:
+		return API20, nil
+	case major == 1 && minor >= 6:
+		return API16, nil
+	default:
+		return API15, nil
+	}
+}
+
+// DetectVersion reads harbor_version from /api/systeminfo, or from
+// /api/v2.0/systeminfo which is the only one served by Harbor 2.x, and sets
+// HarborVersion and APIVersion accordingly. It is called by the first request
+// if APIVersion is not set.
+func (c *Client) DetectVersion() error {
+	api := API15
+	info, err := c.rawSystemInfo("/api/systeminfo")
+	if errors.Is(err, ErrNotFound) {
+		api = API20
+		info, err = c.rawSystemInfo("/api/v2.0/systeminfo")
+	}
+	if err != nil {
+		return fmt.Errorf("detect version of harbor: %w", err)
+	}
+
+	if v, err := ParseAPIVersion(info.HarborVersion); err == nil {
+		api = v
+	} else {
+		c.logf("<== %v, take it as API %s", err, api)
+	}
+
+	c.HarborVersion = info.HarborVersion
+	c.APIVersion = api
+	c.adapter = nil
+	c.logf("<== harbor %s, API %s", c.HarborVersion, c.APIVersion)
+	return nil
+}
+
+// rawSystemInfo gets the system info at path without routing.
+func (c *Client) rawSystemInfo(path string) (*SystemInfo, error) {
+	req, err := c.newRawRequest("GET", path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var info SystemInfo
+	if _, err := c.do(req, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// getAdapter returns the adapter of the server, detecting its version first
+// if needed.
+func (c *Client) getAdapter() (adapter, error) {
+	if c.adapter != nil && c.adapterVersion == c.APIVersion {
+		return c.adapter, nil
+	}
+
+	if c.APIVersion == "" {
+		if err := c.DetectVersion(); err != nil {
+			return nil, err
+		}
+	}
+
+	a, err := newAdapter(c.APIVersion, c.HarborVersion)
+	if err != nil {
+		return nil, err
+	}
+	c.adapter, c.adapterVersion = a, c.APIVersion
+	return a, nil
+}
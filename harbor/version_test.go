@@ -0,0 +1,29 @@
+package harbor
+
+import "testing"
+
+func TestParseAPIVersion(t *testing.T) {
+	for _, tt := range []struct {
+		harborVersion string
+		want          APIVersion
+	}{
+		{"v1.5.0-d59c257e", API15},
+		{"v1.6.0", API16},
+		{"v1.10.2-6e4ad1b2", API16},
+		{"1.8.1", API16},
+		{"v2.0.0", API20},
+		{"v2.10.1-6aa95d31", API20},
+		{"dev", ""},
+	} {
+		got, err := ParseAPIVersion(tt.harborVersion)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%q: got %s, want an error", tt.harborVersion, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%q: got %s, %v, want %s", tt.harborVersion, got, err, tt.want)
+		}
+	}
+}
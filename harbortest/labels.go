@@ -0,0 +1,176 @@
+package harbortest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+func (s *Server) labelByID(id int) *harbor.Label {
+	for _, l := range s.labels {
+		if l.ID == id {
+			return l
+		}
+	}
+	return nil
+}
+
+// labelWritable requires the right to change labels of the scope of l,
+// global labels are for system admins only.
+func (s *Server) labelWritable(rc *request, l *harbor.Label) bool {
+	if l.Scope != "p" {
+		return rc.admin()
+	}
+	p := s.projectByID(l.ProjectID)
+	if p == nil {
+		rc.error(http.StatusBadRequest, "project %d not found", l.ProjectID)
+		return false
+	}
+	return rc.writable(p, RoleProjectAdmin)
+}
+
+// serveLabels serves /api/labels[/{id}].
+func (s *Server) serveLabels(rc *request, segs []string) {
+	if !rc.login() {
+		return
+	}
+
+	if len(segs) == 0 || segs[0] == "" {
+		switch rc.r.Method {
+		case "GET":
+			s.listLabels(rc)
+		case "POST":
+			var req harbor.Label
+			if !rc.decode(&req) {
+				return
+			}
+			if req.Name == "" || (req.Scope != "g" && req.Scope != "p") {
+				rc.error(http.StatusBadRequest, "invalid name or scope of label")
+				return
+			}
+			if req.Scope == "g" {
+				req.ProjectID = 0
+			}
+			if !s.labelWritable(rc, &req) {
+				return
+			}
+			if s.labelExists(&req) {
+				rc.error(http.StatusConflict, "label %s already exists", req.Name)
+				return
+			}
+			s.addLabel(&req)
+			rc.created("/api/labels/" + strconv.Itoa(req.ID))
+		default:
+			rc.methodNotAllowed()
+		}
+		return
+	}
+
+	id, ok := rc.id(segs[0])
+	if !ok {
+		return
+	}
+	l := s.labelByID(id)
+	if l == nil {
+		rc.error(http.StatusNotFound, "label %d not found", id)
+		return
+	}
+
+	switch rc.r.Method {
+	case "GET":
+		if l.Scope == "p" && !rc.readable(s.projectByID(l.ProjectID)) {
+			return
+		}
+		rc.json(http.StatusOK, l)
+	case "PUT":
+		if !s.labelWritable(rc, l) {
+			return
+		}
+		var req harbor.Label
+		if !rc.decode(&req) {
+			return
+		}
+		if req.Name != "" && req.Name != l.Name {
+			req.Scope, req.ProjectID = l.Scope, l.ProjectID
+			if s.labelExists(&req) {
+				rc.error(http.StatusConflict, "label %s already exists", req.Name)
+				return
+			}
+			l.Name = req.Name
+		}
+		l.Description, l.Color = req.Description, req.Color
+		l.UpdateTime = s.now()
+		rc.ok()
+	case "DELETE":
+		if !s.labelWritable(rc, l) {
+			return
+		}
+		s.deleteLabel(l)
+		rc.ok()
+	default:
+		rc.methodNotAllowed()
+	}
+}
+
+func (s *Server) listLabels(rc *request) {
+	scope, name, projectID := rc.query("scope"), rc.query("name"), rc.queryInt("project_id")
+	switch scope {
+	case "g":
+	case "p":
+		p := s.projectByID(projectID)
+		if p == nil {
+			rc.error(http.StatusBadRequest, "invalid project_id %s", rc.query("project_id"))
+			return
+		}
+		if !rc.readable(p) {
+			return
+		}
+	default:
+		rc.error(http.StatusBadRequest, "invalid scope %q", scope)
+		return
+	}
+
+	labels := []*harbor.Label{}
+	for _, l := range s.labels {
+		if l.Scope == scope && (scope == "g" || l.ProjectID == projectID) && contains(l.Name, name) {
+			labels = append(labels, l)
+		}
+	}
+	rc.page(labels)
+}
+
+func (s *Server) labelExists(l *harbor.Label) bool {
+	for _, o := range s.labels {
+		if o.Name == l.Name && o.Scope == l.Scope && o.ProjectID == l.ProjectID {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteLabel deletes l and detaches it from all the repositories and tags.
+func (s *Server) deleteLabel(l *harbor.Label) {
+	detach := func(labels []*harbor.Label) []*harbor.Label {
+		var kept []*harbor.Label
+		for _, o := range labels {
+			if o != l {
+				kept = append(kept, o)
+			}
+		}
+		return kept
+	}
+
+	for i := range s.labels {
+		if s.labels[i] == l {
+			s.labels = append(s.labels[:i], s.labels[i+1:]...)
+			break
+		}
+	}
+	for _, r := range s.repos {
+		r.Labels = detach(r.Labels)
+		for _, t := range r.tags {
+			t.Labels = detach(t.Labels)
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package harbortest
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+func (s *Server) projectByName(name string) *project {
+	for _, p := range s.projects {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *Server) projectByID(id int) *project {
+	for _, p := range s.projects {
+		if p.ProjectID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// projectView returns a copy of p as seen by rc (which may be nil).
+func (s *Server) projectView(p *project, rc *request) *harbor.Project {
+	cp := p.Project
+	cp.Metadata = make(map[string]string, len(p.Metadata))
+	for k, v := range p.Metadata {
+		cp.Metadata[k] = v
+	}
+	if rc != nil {
+		cp.CurrentUserRoleID = rc.role(p)
+		cp.Togglable = cp.CurrentUserRoleID == RoleProjectAdmin
+	}
+	return &cp
+}
+
+// serveProjects serves /api/projects/<segs...>.
+func (s *Server) serveProjects(rc *request, segs []string) {
+	if len(segs) == 0 || segs[0] == "" {
+		switch rc.r.Method {
+		case "GET":
+			s.listProjects(rc)
+		case "HEAD":
+			if s.projectByName(rc.query("project_name")) == nil {
+				rc.w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			rc.ok()
+		case "POST":
+			s.createProject(rc)
+		default:
+			rc.methodNotAllowed()
+		}
+		return
+	}
+
+	id, ok := rc.id(segs[0])
+	if !ok {
+		return
+	}
+	p := s.projectByID(id)
+	if p == nil {
+		rc.error(http.StatusNotFound, "project %d not found", id)
+		return
+	}
+	if !rc.readable(p) {
+		return
+	}
+
+	if len(segs) == 1 {
+		switch rc.r.Method {
+		case "GET":
+			rc.json(http.StatusOK, s.projectView(p, rc))
+		case "PUT":
+			s.updateProject(rc, p)
+		case "DELETE":
+			s.deleteProject(rc, p)
+		default:
+			rc.methodNotAllowed()
+		}
+		return
+	}
+
+	switch segs[1] {
+	case "logs":
+		s.listProjectLogs(rc, p)
+	case "metadatas":
+		s.serveMetadata(rc, p, segs[2:])
+	case "members":
+		s.serveMembers(rc, p, segs[2:])
+	default:
+		rc.error(http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) listProjects(rc *request) {
+	name, public, owner := rc.query("name"), rc.query("public"), rc.query("owner")
+
+	prjs := []*harbor.Project{}
+	for _, p := range s.projects {
+		switch {
+		case p.Metadata["public"] != "true" && rc.role(p) == 0:
+		case !contains(p.Name, name):
+		case public != "" && p.Metadata["public"] != public:
+		case owner != "" && p.OwnerName != owner:
+		default:
+			prjs = append(prjs, s.projectView(p, rc))
+		}
+	}
+	rc.page(prjs)
+}
+
+func (s *Server) createProject(rc *request) {
+	if !rc.login() {
+		return
+	}
+	var req harbor.ProjectReq
+	if !rc.decode(&req) {
+		return
+	}
+	if req.ProjectName == "" {
+		rc.error(http.StatusBadRequest, "project name is required")
+		return
+	}
+	if s.projectByName(req.ProjectName) != nil {
+		rc.error(http.StatusConflict, "project %s already exists", req.ProjectName)
+		return
+	}
+
+	p := s.addProject(req.ProjectName, req.Public == 1, rc.user)
+	setProjectReq(p, &req)
+	rc.created("/api/projects/" + strconv.Itoa(p.ProjectID))
+}
+
+func (s *Server) updateProject(rc *request, p *project) {
+	if !rc.writable(p, RoleProjectAdmin) {
+		return
+	}
+	var req harbor.ProjectReq
+	if !rc.decode(&req) {
+		return
+	}
+	p.Metadata["public"] = strconv.FormatBool(req.Public == 1)
+	setProjectReq(p, &req)
+	p.UpdateTime = s.now()
+	rc.ok()
+}
+
+func setProjectReq(p *project, req *harbor.ProjectReq) {
+	p.Metadata["enable_content_trust"] = strconv.FormatBool(req.EnableContentTrust)
+	p.Metadata["prevent_vul"] = strconv.FormatBool(req.PreventVulnerableImagesFromRunning)
+	p.Metadata["auto_scan"] = strconv.FormatBool(req.AutomaticallyScanImagesOnPush)
+	if req.PreventVulnerableImagesFromRunningSeverity != "" {
+		p.Metadata["severity"] = req.PreventVulnerableImagesFromRunningSeverity
+	}
+}
+
+func (s *Server) deleteProject(rc *request, p *project) {
+	if !rc.writable(p, RoleProjectAdmin) {
+		return
+	}
+	for _, r := range s.repos {
+		if r.ProjectID == p.ProjectID {
+			rc.error(http.StatusPreconditionFailed, "project %s contains repositories, can not be deleted", p.Name)
+			return
+		}
+	}
+	for i := range s.projects {
+		if s.projects[i] == p {
+			s.projects = append(s.projects[:i], s.projects[i+1:]...)
+			break
+		}
+	}
+	rc.ok()
+}
+
+func (s *Server) listProjectLogs(rc *request, p *project) {
+	if rc.r.Method != "GET" {
+		rc.methodNotAllowed()
+		return
+	}
+	logs := []*harbor.AccessLog{}
+	for _, l := range s.filterLogs(rc) {
+		if l.ProjectID == p.ProjectID {
+			logs = append(logs, l)
+		}
+	}
+	rc.page(logs)
+}
+
+// serveMetadata serves /api/projects/{id}/metadatas[/{name}].
+func (s *Server) serveMetadata(rc *request, p *project, segs []string) {
+	if len(segs) == 0 || segs[0] == "" {
+		switch rc.r.Method {
+		case "GET":
+			rc.json(http.StatusOK, p.Metadata)
+		case "POST":
+			if !rc.writable(p, RoleProjectAdmin) {
+				return
+			}
+			var meta map[string]string
+			if !rc.decode(&meta) {
+				return
+			}
+			for k, v := range meta {
+				p.Metadata[k] = v
+			}
+			rc.ok()
+		default:
+			rc.methodNotAllowed()
+		}
+		return
+	}
+
+	name := segs[0]
+	value, found := p.Metadata[name]
+	if !found && rc.r.Method != "PUT" {
+		rc.error(http.StatusNotFound, "metadata %s of project %s not found", name, p.Name)
+		return
+	}
+	switch rc.r.Method {
+	case "GET":
+		rc.json(http.StatusOK, map[string]string{name: value})
+	case "PUT":
+		if !rc.writable(p, RoleProjectAdmin) {
+			return
+		}
+		var meta map[string]string
+		if !rc.decode(&meta) {
+			return
+		}
+		v, ok := meta[name]
+		if !ok {
+			rc.error(http.StatusBadRequest, "no value of metadata %s", name)
+			return
+		}
+		p.Metadata[name] = v
+		rc.ok()
+	case "DELETE":
+		if !rc.writable(p, RoleProjectAdmin) {
+			return
+		}
+		delete(p.Metadata, name)
+		rc.ok()
+	default:
+		rc.methodNotAllowed()
+	}
+}
+
+// serveMembers serves /api/projects/{id}/members[/{mid}].
+func (s *Server) serveMembers(rc *request, p *project, segs []string) {
+	if len(segs) == 0 || segs[0] == "" {
+		switch rc.r.Method {
+		case "GET":
+			entityName := rc.query("entityname")
+			members := []*harbor.ProjectMember{}
+			for _, m := range p.members {
+				if contains(m.EntityName, entityName) {
+					members = append(members, m)
+				}
+			}
+			sort.Slice(members, func(i, j int) bool { return members[i].ID < members[j].ID })
+			rc.json(http.StatusOK, members)
+		case "POST":
+			s.createMember(rc, p)
+		default:
+			rc.methodNotAllowed()
+		}
+		return
+	}
+
+	mid, ok := rc.id(segs[0])
+	if !ok {
+		return
+	}
+	var m *harbor.ProjectMember
+	i := 0
+	for ; i < len(p.members); i++ {
+		if p.members[i].ID == mid {
+			m = p.members[i]
+			break
+		}
+	}
+	if m == nil {
+		rc.error(http.StatusNotFound, "member %d of project %s not found", mid, p.Name)
+		return
+	}
+
+	switch rc.r.Method {
+	case "GET":
+		rc.json(http.StatusOK, m)
+	case "PUT":
+		if !rc.writable(p, RoleProjectAdmin) {
+			return
+		}
+		var req harbor.ProjectMemberReq
+		if !rc.decode(&req) {
+			return
+		}
+		if roleNames[req.RoleID] == "" {
+			rc.error(http.StatusBadRequest, "invalid role %d", req.RoleID)
+			return
+		}
+		m.RoleID, m.RoleName = req.RoleID, roleNames[req.RoleID]
+		rc.ok()
+	case "DELETE":
+		if !rc.writable(p, RoleProjectAdmin) {
+			return
+		}
+		p.members = append(p.members[:i], p.members[i+1:]...)
+		rc.ok()
+	default:
+		rc.methodNotAllowed()
+	}
+}
+
+func (s *Server) createMember(rc *request, p *project) {
+	if !rc.writable(p, RoleProjectAdmin) {
+		return
+	}
+	var req harbor.ProjectMemberReq
+	if !rc.decode(&req) {
+		return
+	}
+	if req.MemberUser == nil {
+		rc.error(http.StatusBadRequest, "member_user is required")
+		return
+	}
+	if roleNames[req.RoleID] == "" {
+		rc.error(http.StatusBadRequest, "invalid role %d", req.RoleID)
+		return
+	}
+
+	u := s.userByID(req.MemberUser.UserID)
+	if u == nil {
+		u = s.userByName(req.MemberUser.Username)
+	}
+	if u == nil {
+		rc.error(http.StatusNotFound, "user %s not found", req.MemberUser.Username)
+		return
+	}
+	for _, m := range p.members {
+		if m.EntityID == u.UserID {
+			rc.error(http.StatusConflict, "%s is a member of project %s already", u.Username, p.Name)
+			return
+		}
+	}
+
+	m := s.addMember(p, u, req.RoleID)
+	rc.created("/api/projects/" + strconv.Itoa(p.ProjectID) + "/members/" + strconv.Itoa(m.ID))
+}
@@ -0,0 +1,390 @@
+package harbortest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+func (s *Server) targetByID(id int) *harbor.Target {
+	for _, t := range s.targets {
+		if t.ID == id {
+			return t
+		}
+	}
+	return nil
+}
+
+func (s *Server) policyByID(id int) *harbor.ReplicationPolicy {
+	for _, p := range s.policies {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *Server) jobByID(id int) *job {
+	for _, j := range s.jobs {
+		if j.ID == id {
+			return j
+		}
+	}
+	return nil
+}
+
+// serveTargets serves /api/targets/<segs...>, all for system admins.
+func (s *Server) serveTargets(rc *request, segs []string) {
+	if !rc.admin() {
+		return
+	}
+
+	if len(segs) == 0 || segs[0] == "" {
+		switch rc.r.Method {
+		case "GET":
+			name := rc.query("name")
+			targets := []*harbor.Target{}
+			for _, t := range s.targets {
+				if contains(t.Name, name) {
+					targets = append(targets, t)
+				}
+			}
+			rc.json(http.StatusOK, targets)
+		case "POST":
+			var req harbor.Target
+			if !rc.decode(&req) {
+				return
+			}
+			if req.Name == "" || req.Endpoint == "" {
+				rc.error(http.StatusBadRequest, "name and endpoint are required")
+				return
+			}
+			for _, t := range s.targets {
+				if t.Name == req.Name {
+					rc.error(http.StatusConflict, "target %s already exists", req.Name)
+					return
+				}
+			}
+			s.addTarget(&req)
+			rc.created("/api/targets/" + strconv.Itoa(req.ID))
+		default:
+			rc.methodNotAllowed()
+		}
+		return
+	}
+
+	if segs[0] == "ping" {
+		var req harbor.Target
+		if rc.r.Method != "POST" {
+			rc.methodNotAllowed()
+		} else if rc.decode(&req) {
+			pingTarget(rc, &req)
+		}
+		return
+	}
+
+	id, ok := rc.id(segs[0])
+	if !ok {
+		return
+	}
+	t := s.targetByID(id)
+	if t == nil {
+		rc.error(http.StatusNotFound, "target %d not found", id)
+		return
+	}
+
+	if len(segs) > 1 {
+		switch segs[1] {
+		case "ping":
+			pingTarget(rc, t)
+		case "policies":
+			policies := []*harbor.ReplicationPolicy{}
+			for _, p := range s.policies {
+				for _, pt := range p.Targets {
+					if pt.ID == t.ID {
+						policies = append(policies, p)
+					}
+				}
+			}
+			rc.json(http.StatusOK, policies)
+		default:
+			rc.error(http.StatusNotFound, "not found")
+		}
+		return
+	}
+
+	switch rc.r.Method {
+	case "GET":
+		rc.json(http.StatusOK, t)
+	case "PUT":
+		var req harbor.Target
+		if !rc.decode(&req) {
+			return
+		}
+		if req.Name != "" {
+			t.Name = req.Name
+		}
+		if req.Endpoint != "" {
+			t.Endpoint = req.Endpoint
+		}
+		t.Username, t.Insecure = req.Username, req.Insecure
+		t.UpdateTime = s.now()
+		rc.ok()
+	case "DELETE":
+		for _, p := range s.policies {
+			for _, pt := range p.Targets {
+				if pt.ID == t.ID {
+					rc.error(http.StatusBadRequest, "target %s is used by policy %s", t.Name, p.Name)
+					return
+				}
+			}
+		}
+		for i := range s.targets {
+			if s.targets[i] == t {
+				s.targets = append(s.targets[:i], s.targets[i+1:]...)
+				break
+			}
+		}
+		rc.ok()
+	default:
+		rc.methodNotAllowed()
+	}
+}
+
+// pingTarget succeeds for any endpoint, nothing is dialed.
+func pingTarget(rc *request, t *harbor.Target) {
+	if t.Endpoint == "" {
+		rc.error(http.StatusBadRequest, "endpoint is required")
+		return
+	}
+	rc.ok()
+}
+
+// servePolicies serves /api/policies/replication[/{id}], all for system
+// admins.
+func (s *Server) servePolicies(rc *request, segs []string) {
+	if !rc.admin() {
+		return
+	}
+
+	if len(segs) == 0 || segs[0] == "" {
+		switch rc.r.Method {
+		case "GET":
+			name, projectID := rc.query("name"), rc.queryInt("project_id")
+			policies := []*harbor.ReplicationPolicy{}
+			for _, p := range s.policies {
+				if contains(p.Name, name) && (projectID == 0 || policyOf(p, projectID)) {
+					policies = append(policies, p)
+				}
+			}
+			rc.page(policies)
+		case "POST":
+			var req harbor.ReplicationPolicy
+			if !rc.decode(&req) || !s.validPolicy(rc, &req) {
+				return
+			}
+			s.addPolicy(&req)
+			rc.created("/api/policies/replication/" + strconv.Itoa(req.ID))
+		default:
+			rc.methodNotAllowed()
+		}
+		return
+	}
+
+	id, ok := rc.id(segs[0])
+	if !ok {
+		return
+	}
+	p := s.policyByID(id)
+	if p == nil {
+		rc.error(http.StatusNotFound, "policy %d not found", id)
+		return
+	}
+
+	switch rc.r.Method {
+	case "GET":
+		rc.json(http.StatusOK, p)
+	case "PUT":
+		var req harbor.ReplicationPolicy
+		if !rc.decode(&req) || !s.validPolicy(rc, &req) {
+			return
+		}
+		req.ID, req.CreationTime, req.UpdateTime = p.ID, p.CreationTime, s.now()
+		*p = req
+		rc.ok()
+	case "DELETE":
+		for i := range s.policies {
+			if s.policies[i] == p {
+				s.policies = append(s.policies[:i], s.policies[i+1:]...)
+				break
+			}
+		}
+		rc.ok()
+	default:
+		rc.methodNotAllowed()
+	}
+}
+
+func (s *Server) validPolicy(rc *request, p *harbor.ReplicationPolicy) bool {
+	if p.Name == "" || len(p.Projects) == 0 || len(p.Targets) == 0 {
+		rc.error(http.StatusBadRequest, "name, projects and targets are required")
+		return false
+	}
+	for _, prj := range p.Projects {
+		if s.projectByID(prj.ProjectID) == nil {
+			rc.error(http.StatusBadRequest, "project %d not found", prj.ProjectID)
+			return false
+		}
+	}
+	for _, t := range p.Targets {
+		if s.targetByID(t.ID) == nil {
+			rc.error(http.StatusBadRequest, "target %d not found", t.ID)
+			return false
+		}
+	}
+	return true
+}
+
+func policyOf(p *harbor.ReplicationPolicy, projectID int) bool {
+	for _, prj := range p.Projects {
+		if prj.ProjectID == projectID {
+			return true
+		}
+	}
+	return false
+}
+
+// serveReplications serves POST /api/replications, which starts a job for
+// every repository of the projects of the policy.
+func (s *Server) serveReplications(rc *request) {
+	if rc.r.Method != "POST" {
+		rc.methodNotAllowed()
+		return
+	}
+	if !rc.admin() {
+		return
+	}
+	var body struct {
+		PolicyID int `json:"policy_id"`
+	}
+	if !rc.decode(&body) {
+		return
+	}
+	p := s.policyByID(body.PolicyID)
+	if p == nil {
+		rc.error(http.StatusNotFound, "policy %d not found", body.PolicyID)
+		return
+	}
+
+	for _, r := range s.repos {
+		if policyOf(p, r.ProjectID) {
+			s.addJob(p.ID, r.Name, "pending")
+		}
+	}
+	rc.ok()
+}
+
+// serveJobs serves /api/jobs/replication/... and /api/jobs/scan/{id}/log.
+func (s *Server) serveJobs(rc *request, segs []string) {
+	if !rc.admin() {
+		return
+	}
+	if len(segs) == 0 {
+		rc.error(http.StatusNotFound, "not found")
+		return
+	}
+
+	if segs[0] == "scan" {
+		// no scanner, so no scan jobs
+		rc.error(http.StatusNotFound, "scan job not found")
+		return
+	}
+	if segs[0] != "replication" {
+		rc.error(http.StatusNotFound, "not found")
+		return
+	}
+
+	if len(segs) == 1 {
+		switch rc.r.Method {
+		case "GET":
+			s.listJobs(rc)
+		case "PUT":
+			var body struct {
+				PolicyID int    `json:"policy_id"`
+				Status   string `json:"status"`
+			}
+			if !rc.decode(&body) {
+				return
+			}
+			if body.Status != "stop" {
+				rc.error(http.StatusBadRequest, "invalid status %q, only stop is supported", body.Status)
+				return
+			}
+			if s.policyByID(body.PolicyID) == nil {
+				rc.error(http.StatusNotFound, "policy %d not found", body.PolicyID)
+				return
+			}
+			for _, j := range s.jobs {
+				if j.PolicyID == body.PolicyID && (j.Status == "pending" || j.Status == "running") {
+					j.Status, j.UpdateTime = "stopped", s.now()
+				}
+			}
+			rc.ok()
+		default:
+			rc.methodNotAllowed()
+		}
+		return
+	}
+
+	id, ok := rc.id(segs[1])
+	if !ok {
+		return
+	}
+	j := s.jobByID(id)
+	if j == nil {
+		rc.error(http.StatusNotFound, "job %d not found", id)
+		return
+	}
+
+	switch {
+	case len(segs) == 2 && rc.r.Method == "DELETE":
+		if j.Status == "pending" || j.Status == "running" {
+			rc.error(http.StatusBadRequest, "job %d is %s, can not be deleted", j.ID, j.Status)
+			return
+		}
+		for i := range s.jobs {
+			if s.jobs[i] == j {
+				s.jobs = append(s.jobs[:i], s.jobs[i+1:]...)
+				break
+			}
+		}
+		rc.ok()
+	case len(segs) == 3 && segs[2] == "log" && rc.r.Method == "GET":
+		rc.w.Header().Set("Content-Type", "text/plain")
+		rc.w.Write([]byte(j.log))
+	default:
+		rc.methodNotAllowed()
+	}
+}
+
+func (s *Server) listJobs(rc *request) {
+	policyID := rc.queryInt("policy_id")
+	if policyID <= 0 {
+		rc.error(http.StatusBadRequest, "invalid policy_id %q", rc.query("policy_id"))
+		return
+	}
+	repo, status := rc.query("repository"), rc.query("status")
+
+	jobs := []*harbor.ReplicationJob{}
+	for _, j := range s.jobs {
+		if j.PolicyID == policyID && contains(j.Repository, repo) && (status == "" || j.Status == status) {
+			cp := j.ReplicationJob
+			jobs = append(jobs, &cp)
+		}
+	}
+	if n := rc.queryInt("num"); n > 0 && len(jobs) > n {
+		jobs = jobs[:n]
+	}
+	rc.page(jobs)
+}
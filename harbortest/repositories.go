@@ -0,0 +1,315 @@
+package harbortest
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+func (s *Server) repoByName(name string) *repository {
+	for _, r := range s.repos {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+func (s *Server) tag(repoName, tag string) *harbor.Tag {
+	if r := s.repoByName(repoName); r != nil {
+		for _, t := range r.tags {
+			if t.Name == tag {
+				return t
+			}
+		}
+	}
+	return nil
+}
+
+// serveRepositories serves /api/repositories/<rest>, where rest starts with
+// the repository name, which may have slashes.
+func (s *Server) serveRepositories(rc *request, rest string) {
+	switch {
+	case rest == "":
+		s.listRepositories(rc)
+		return
+	case rest == "top":
+		s.listTopRepositories(rc)
+		return
+	}
+
+	repoName, sub := rest, ""
+	if i := strings.Index(rest, "/tags/"); i >= 0 {
+		repoName, sub = rest[:i], rest[i+1:]
+	} else {
+		for _, suffix := range []string{"/tags", "/labels", "/signatures"} {
+			if i := strings.LastIndex(rest, suffix); i >= 0 && (strings.HasSuffix(rest, suffix) || strings.HasPrefix(rest[i+len(suffix):], "/")) {
+				repoName, sub = rest[:i], rest[i+1:]
+				break
+			}
+		}
+	}
+
+	r := s.repoByName(unescape(repoName))
+	if r == nil {
+		rc.error(http.StatusNotFound, "repository %s not found", repoName)
+		return
+	}
+	p := s.projectByID(r.ProjectID)
+	if !rc.readable(p) {
+		return
+	}
+
+	segs := strings.Split(sub, "/")
+	switch {
+	case sub == "":
+		s.serveRepository(rc, p, r)
+	case segs[0] == "labels":
+		s.serveLabelsOf(rc, p, &r.Labels, segs[1:])
+	case segs[0] == "signatures":
+		rc.json(http.StatusOK, []*harbor.Signature{})
+	case segs[0] == "tags" && len(segs) == 1:
+		if rc.r.Method != "GET" {
+			rc.methodNotAllowed()
+			return
+		}
+		tags := []*harbor.Tag{}
+		tags = append(tags, r.tags...)
+		rc.json(http.StatusOK, tags)
+	default:
+		s.serveTag(rc, p, r, segs[1], segs[2:])
+	}
+}
+
+func (s *Server) listRepositories(rc *request) {
+	if rc.r.Method != "GET" {
+		rc.methodNotAllowed()
+		return
+	}
+	p := s.projectByID(rc.queryInt("project_id"))
+	if p == nil {
+		rc.error(http.StatusNotFound, "project %s not found", rc.query("project_id"))
+		return
+	}
+	if !rc.readable(p) {
+		return
+	}
+
+	q, labelID := rc.query("q"), rc.queryInt("label_id")
+	repos := []*harbor.Repository{}
+	for _, r := range s.repos {
+		if r.ProjectID == p.ProjectID && contains(r.Name, q) && (labelID == 0 || hasLabel(r.Labels, labelID)) {
+			cp := r.Repository
+			repos = append(repos, &cp)
+		}
+	}
+	rc.page(repos)
+}
+
+func (s *Server) listTopRepositories(rc *request) {
+	count := rc.queryInt("count")
+	if count <= 0 {
+		count = 10
+	}
+
+	repos := []*harbor.Repository{}
+	for _, r := range s.repos {
+		if p := s.projectByID(r.ProjectID); p.Metadata["public"] == "true" || rc.role(p) != 0 {
+			cp := r.Repository
+			repos = append(repos, &cp)
+		}
+	}
+	sort.SliceStable(repos, func(i, j int) bool { return repos[i].PullCount > repos[j].PullCount })
+	if len(repos) > count {
+		repos = repos[:count]
+	}
+	rc.json(http.StatusOK, repos)
+}
+
+func (s *Server) serveRepository(rc *request, p *project, r *repository) {
+	switch rc.r.Method {
+	case "PUT":
+		if !rc.writable(p, RoleDeveloper) {
+			return
+		}
+		var body struct {
+			Description string `json:"description"`
+		}
+		if !rc.decode(&body) {
+			return
+		}
+		r.Description = body.Description
+		r.UpdateTime = s.now()
+		rc.ok()
+	case "DELETE":
+		if !rc.writable(p, RoleProjectAdmin) {
+			return
+		}
+		for _, t := range r.tags {
+			s.addLog(p, rc.user.Username, r.Name, t.Name, "delete")
+		}
+		s.deleteRepository(r)
+		rc.ok()
+	default:
+		rc.methodNotAllowed()
+	}
+}
+
+func (s *Server) deleteRepository(r *repository) {
+	for i := range s.repos {
+		if s.repos[i] == r {
+			s.repos = append(s.repos[:i], s.repos[i+1:]...)
+			break
+		}
+	}
+	if p := s.projectByID(r.ProjectID); p != nil {
+		p.RepoCount--
+	}
+}
+
+// serveTag serves /api/repositories/{repo}/tags/{tag}[/<segs...>].
+func (s *Server) serveTag(rc *request, p *project, r *repository, tag string, segs []string) {
+	t := s.tag(r.Name, tag)
+	if t == nil {
+		rc.error(http.StatusNotFound, "tag %s of %s not found", tag, r.Name)
+		return
+	}
+
+	switch {
+	case len(segs) == 0:
+		switch rc.r.Method {
+		case "GET":
+			rc.json(http.StatusOK, t)
+		case "DELETE":
+			if !rc.writable(p, RoleProjectAdmin) {
+				return
+			}
+			s.deleteManifest(rc, p, r, t.Digest)
+			rc.ok()
+		default:
+			rc.methodNotAllowed()
+		}
+	case segs[0] == "labels":
+		s.serveLabelsOf(rc, p, &t.Labels, segs[1:])
+	case segs[0] == "scan":
+		if rc.r.Method != "POST" {
+			rc.methodNotAllowed()
+			return
+		}
+		if !rc.writable(p, RoleProjectAdmin) {
+			return
+		}
+		s.scanned[t.Digest] = true
+		rc.ok()
+	case segs[0] == "vulnerability" && len(segs) == 2 && segs[1] == "details":
+		if rc.r.Method != "GET" {
+			rc.methodNotAllowed()
+			return
+		}
+		vulns := []*harbor.VulnerabilityItem{}
+		if s.scanned[t.Digest] {
+			vulns = append(vulns, s.vulns[t.Digest]...)
+		}
+		rc.json(http.StatusOK, vulns)
+	case segs[0] == "manifest":
+		rc.json(http.StatusOK, &harbor.Manifest{
+			Manifest: map[string]interface{}{
+				"schemaVersion": 2,
+				"mediaType":     "application/vnd.docker.distribution.manifest.v2+json",
+				"config":        map[string]interface{}{"digest": t.Digest},
+			},
+			Config: `{"architecture":"` + t.Architecture + `","os":"` + t.OS + `"}`,
+		})
+	default:
+		rc.error(http.StatusNotFound, "not found")
+	}
+}
+
+// deleteManifest deletes all the tags of digest, as the registry does. The
+// repository goes with its last tag.
+func (s *Server) deleteManifest(rc *request, p *project, r *repository, digest string) {
+	var kept []*harbor.Tag
+	for _, t := range r.tags {
+		if t.Digest == digest {
+			s.addLog(p, rc.user.Username, r.Name, t.Name, "delete")
+			continue
+		}
+		kept = append(kept, t)
+	}
+	r.tags = kept
+	r.TagsCount = len(kept)
+	r.UpdateTime = s.now()
+	if len(kept) == 0 {
+		s.deleteRepository(r)
+	}
+}
+
+// serveLabelsOf serves the labels (of a repository or a tag) at
+// .../labels[/{id}].
+func (s *Server) serveLabelsOf(rc *request, p *project, labels *[]*harbor.Label, segs []string) {
+	if len(segs) == 0 || segs[0] == "" {
+		switch rc.r.Method {
+		case "GET":
+			ls := []*harbor.Label{}
+			rc.json(http.StatusOK, append(ls, *labels...))
+		case "POST":
+			if !rc.writable(p, RoleDeveloper) {
+				return
+			}
+			var req harbor.Label
+			if !rc.decode(&req) {
+				return
+			}
+			l := s.labelByID(req.ID)
+			if l == nil {
+				rc.error(http.StatusNotFound, "label %d not found", req.ID)
+				return
+			}
+			if l.Scope == "p" && l.ProjectID != p.ProjectID {
+				rc.error(http.StatusBadRequest, "label %d is not a label of project %s", l.ID, p.Name)
+				return
+			}
+			if hasLabel(*labels, l.ID) {
+				rc.error(http.StatusConflict, "label %d is added already", l.ID)
+				return
+			}
+			*labels = append(*labels, l)
+			rc.created(rc.r.URL.Path + "/" + strconv.Itoa(l.ID))
+		default:
+			rc.methodNotAllowed()
+		}
+		return
+	}
+
+	id, ok := rc.id(segs[0])
+	if !ok {
+		return
+	}
+	if rc.r.Method != "DELETE" {
+		rc.methodNotAllowed()
+		return
+	}
+	if !rc.writable(p, RoleDeveloper) {
+		return
+	}
+	for i, l := range *labels {
+		if l.ID == id {
+			*labels = append((*labels)[:i], (*labels)[i+1:]...)
+			rc.ok()
+			return
+		}
+	}
+	rc.error(http.StatusNotFound, "label %d not found", id)
+}
+
+func hasLabel(labels []*harbor.Label, id int) bool {
+	for _, l := range labels {
+		if l.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,803 @@
+// Package harbortest provides an in-memory Harbor (API v1.5) served by
+// net/http/httptest, so that the harbor package and all the commands can be
+// tested without a real Harbor or a docker daemon:
+//
+//	srv := harbortest.NewServer()
+//	defer srv.Close()
+//
+//	srv.AddProject("prj", false, "admin")
+//	srv.PushImage("prj/busybox", "v1", "sha256:1111")
+//
+//	c, _ := harbor.NewClient(srv.URL, nil)
+//	c.Login(harbortest.AdminUsername, harbortest.AdminPassword)
+//	tags, _ := c.ListTags("prj/busybox")
+//
+// Logins are tracked by beegosessionID cookies, and requests are checked
+// against the system admin flag of the user and the role in the project, like
+// Harbor does (roughly). Nothing is persisted.
+package harbortest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+// The system admin created by NewServer, as the one of a fresh Harbor.
+const (
+	AdminUsername = "admin"
+	AdminPassword = "Harbor12345"
+)
+
+// Version is the harbor_version reported by /api/systeminfo.
+const Version = "v1.5.0-d59c257e"
+
+// Project roles of Harbor.
+const (
+	RoleProjectAdmin = 1
+	RoleDeveloper    = 2
+	RoleGuest        = 3
+)
+
+var roleNames = map[int]string{
+	RoleProjectAdmin: "projectAdmin",
+	RoleDeveloper:    "developer",
+	RoleGuest:        "guest",
+}
+
+// Server is an in-memory Harbor. All the methods are safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	// Now returns the time of creations and updates, time.Now by default.
+	Now func() time.Time
+	// HarborVersion is the harbor_version reported by /api/systeminfo,
+	// Version by default. The client refuses the API not supported by the
+	// version, but the server still serves the API of Harbor 1.5.
+	HarborVersion string
+
+	mu       sync.Mutex
+	ids      map[string]int
+	sessions map[string]int // beegosessionID to user ID
+	users    []*user
+	projects []*project
+	repos    []*repository
+	labels   []*harbor.Label
+	targets  []*harbor.Target
+	policies []*harbor.ReplicationPolicy
+	jobs     []*job
+	logs     []*harbor.AccessLog
+	groups   []*harbor.UserGroup
+	config   map[string]*harbor.ConfigItem
+	vulns    map[string][]*harbor.VulnerabilityItem // found by the scan of a digest
+	scanned  map[string]bool                        // the digests scanned
+}
+
+type user struct {
+	harbor.User
+	password string
+}
+
+type project struct {
+	harbor.Project
+	members []*harbor.ProjectMember
+}
+
+type repository struct {
+	harbor.Repository
+	tags []*harbor.Tag
+}
+
+type job struct {
+	harbor.ReplicationJob
+	log string
+}
+
+// NewServer starts a Harbor with the system admin and the public project
+// "library" only. The caller should call Close when finished.
+func NewServer() *Server {
+	s := newServer()
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// NewTLSServer is the same as NewServer, but serves HTTPS with the
+// self-signed certificate of httptest.
+func NewTLSServer() *Server {
+	s := newServer()
+	s.Server = httptest.NewTLSServer(s)
+	return s
+}
+
+func newServer() *Server {
+	s := &Server{
+		Now:           time.Now,
+		HarborVersion: Version,
+		ids:           make(map[string]int),
+		vulns:         make(map[string][]*harbor.VulnerabilityItem),
+		scanned:       make(map[string]bool),
+		sessions:      make(map[string]int),
+		config:        defaultConfig(),
+	}
+	s.AddUser(AdminUsername, AdminPassword, true)
+	s.AddProject("library", true, AdminUsername)
+	return s
+}
+
+func (s *Server) nextID(kind string) int {
+	s.ids[kind]++
+	return s.ids[kind]
+}
+
+func (s *Server) now() string {
+	return s.Now().UTC().Format(time.RFC3339)
+}
+
+// AddUser adds a user, who is a system admin if admin is true.
+func (s *Server) AddUser(username, password string, admin bool) *harbor.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.addUser(&harbor.User{Username: username, Email: username + "@mydomain.com", HasAdminRole: admin}, password)
+	cp := u.User
+	return &cp
+}
+
+func (s *Server) addUser(hu *harbor.User, password string) *user {
+	u := &user{User: *hu, password: password}
+	u.UserID = s.nextID("user")
+	u.Password = ""
+	u.CreationTime = s.now()
+	u.UpdateTime = u.CreationTime
+	s.users = append(s.users, u)
+	return u
+}
+
+// AddProject adds a project owned by owner, who becomes its project admin.
+func (s *Server) AddProject(name string, public bool, owner string) *harbor.Project {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.addProject(name, public, s.userByName(owner))
+	return s.projectView(p, nil)
+}
+
+func (s *Server) addProject(name string, public bool, owner *user) *project {
+	p := &project{}
+	p.ProjectID = s.nextID("project")
+	p.Name = name
+	p.CreationTime = s.now()
+	p.UpdateTime = p.CreationTime
+	p.Metadata = map[string]string{"public": strconv.FormatBool(public)}
+	if owner != nil {
+		p.OwnerID = owner.UserID
+		p.OwnerName = owner.Username
+		s.addMember(p, owner, RoleProjectAdmin)
+	}
+	s.projects = append(s.projects, p)
+	return p
+}
+
+// AddMember makes username a member of projectName with roleID.
+func (s *Server) AddMember(projectName, username string, roleID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p, u := s.projectByName(projectName), s.userByName(username); p != nil && u != nil {
+		s.addMember(p, u, roleID)
+	}
+}
+
+func (s *Server) addMember(p *project, u *user, roleID int) *harbor.ProjectMember {
+	m := &harbor.ProjectMember{
+		ID:         s.nextID("member"),
+		ProjectID:  p.ProjectID,
+		EntityName: u.Username,
+		EntityID:   u.UserID,
+		EntityType: "u",
+		RoleID:     roleID,
+		RoleName:   roleNames[roleID],
+	}
+	p.members = append(p.members, m)
+	return m
+}
+
+// PushImage pushes repoName:tag with digest, creating the repository if
+// needed, as "docker push" does. The tag is moved if it exists already.
+func (s *Server) PushImage(repoName, tag, digest string) *harbor.Tag {
+	return s.PushImageAt(repoName, tag, digest, s.Now())
+}
+
+// PushImageAt is the same as PushImage, with the creation time of the image.
+func (s *Server) PushImageAt(repoName, tag, digest string, created time.Time) *harbor.Tag {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.projectByName(strings.SplitN(repoName, "/", 2)[0])
+	if p == nil {
+		return nil
+	}
+
+	r := s.repoByName(repoName)
+	if r == nil {
+		r = &repository{}
+		r.ID = s.nextID("repository")
+		r.Name = repoName
+		r.ProjectID = p.ProjectID
+		r.CreationTime = s.now()
+		s.repos = append(s.repos, r)
+		p.RepoCount++
+	}
+	r.UpdateTime = s.now()
+
+	for i, t := range r.tags {
+		if t.Name == tag {
+			r.tags = append(r.tags[:i], r.tags[i+1:]...)
+			break
+		}
+	}
+	t := &harbor.Tag{
+		Digest:        digest,
+		Name:          tag,
+		Size:          1024,
+		Architecture:  "amd64",
+		OS:            "linux",
+		DockerVersion: "17.06.0-ce",
+		Created:       created.UTC().Format(time.RFC3339Nano),
+		PushTime:      s.now(),
+	}
+	r.tags = append(r.tags, t)
+	r.TagsCount = len(r.tags)
+	s.addLog(p, AdminUsername, repoName, tag, "push")
+
+	cp := *t
+	return &cp
+}
+
+// PullImage pulls the image repoName:tag, as docker pull does, which counts
+// the pulls of the repository and updates the pull time of the tag. It returns
+// false if there is no such tag.
+func (s *Server) PullImage(repoName, tag string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := s.repoByName(repoName)
+	if r == nil {
+		return false
+	}
+	for _, t := range r.tags {
+		if t.Name == tag {
+			r.PullCount++
+			t.PullTime = s.now()
+			s.addLog(s.projectByID(r.ProjectID), AdminUsername, repoName, tag, "pull")
+			return true
+		}
+	}
+	return false
+}
+
+// AddLabel adds a global label (projectName is empty) or a project label.
+func (s *Server) AddLabel(name, projectName string) *harbor.Label {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := &harbor.Label{Name: name, Scope: "g", Color: "#FFFFFF"}
+	if p := s.projectByName(projectName); p != nil {
+		l.Scope, l.ProjectID = "p", p.ProjectID
+	}
+	s.addLabel(l)
+	cp := *l
+	return &cp
+}
+
+func (s *Server) addLabel(l *harbor.Label) {
+	l.ID = s.nextID("label")
+	l.CreationTime = s.now()
+	l.UpdateTime = l.CreationTime
+	s.labels = append(s.labels, l)
+}
+
+// LabelImage attaches the label to repoName:tag.
+func (s *Server) LabelImage(repoName, tag string, labelID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t, l := s.tag(repoName, tag), s.labelByID(labelID); t != nil && l != nil {
+		t.Labels = append(t.Labels, l)
+	}
+}
+
+// AddVulnerability makes the scan of the images of digest find v.
+func (s *Server) AddVulnerability(digest string, v *harbor.VulnerabilityItem) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.vulns[digest] = append(s.vulns[digest], v)
+}
+
+// AddTarget adds a replication target.
+func (s *Server) AddTarget(name, endpoint string) *harbor.Target {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := &harbor.Target{Name: name, Endpoint: endpoint, Username: AdminUsername}
+	s.addTarget(t)
+	cp := *t
+	return &cp
+}
+
+func (s *Server) addTarget(t *harbor.Target) {
+	t.ID = s.nextID("target")
+	t.Password = ""
+	t.CreationTime = s.now()
+	t.UpdateTime = t.CreationTime
+	s.targets = append(s.targets, t)
+}
+
+// AddPolicy adds a replication policy of projectName to targetName.
+func (s *Server) AddPolicy(name, projectName, targetName string) *harbor.ReplicationPolicy {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	policy := &harbor.ReplicationPolicy{Name: name, Trigger: &harbor.ReplicationTrigger{Kind: "Manual"}}
+	if p := s.projectByName(projectName); p != nil {
+		policy.Projects = []*harbor.Project{{ProjectID: p.ProjectID, Name: p.Name}}
+	}
+	for _, t := range s.targets {
+		if t.Name == targetName {
+			policy.Targets = []*harbor.Target{{ID: t.ID, Name: t.Name, Endpoint: t.Endpoint}}
+		}
+	}
+	s.addPolicy(policy)
+	cp := *policy
+	return &cp
+}
+
+func (s *Server) addPolicy(p *harbor.ReplicationPolicy) {
+	p.ID = s.nextID("policy")
+	p.CreationTime = s.now()
+	p.UpdateTime = p.CreationTime
+	s.policies = append(s.policies, p)
+}
+
+// AddJob adds a replication job of policyID.
+func (s *Server) AddJob(policyID int, repoName, status string) *harbor.ReplicationJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j := s.addJob(policyID, repoName, status)
+	cp := j.ReplicationJob
+	return &cp
+}
+
+func (s *Server) addJob(policyID int, repoName, status string) *job {
+	j := &job{}
+	j.ID = s.nextID("job")
+	j.PolicyID = policyID
+	j.Repository = repoName
+	j.Status = status
+	j.Operation = "transfer"
+	j.CreationTime = s.now()
+	j.UpdateTime = j.CreationTime
+	j.log = fmt.Sprintf("replicating %s: %s\n", repoName, status)
+	s.jobs = append(s.jobs, j)
+	return j
+}
+
+func (s *Server) addLog(p *project, username, repoName, tag, operation string) {
+	s.logs = append(s.logs, &harbor.AccessLog{
+		LogID:     s.nextID("log"),
+		Username:  username,
+		ProjectID: p.ProjectID,
+		RepoName:  repoName,
+		RepoTag:   tag,
+		Operation: operation,
+		OpTime:    s.now(),
+	})
+}
+
+// ExpireSessions forgets all the logins, as if they had expired.
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions = make(map[string]int)
+}
+
+// Project returns a copy of the project named name, or nil.
+func (s *Server) Project(name string) *harbor.Project {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p := s.projectByName(name); p != nil {
+		return s.projectView(p, nil)
+	}
+	return nil
+}
+
+// Members returns the members of the project named name.
+func (s *Server) Members(name string) []*harbor.ProjectMember {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var members []*harbor.ProjectMember
+	if p := s.projectByName(name); p != nil {
+		for _, m := range p.members {
+			cp := *m
+			members = append(members, &cp)
+		}
+	}
+	return members
+}
+
+// Repository returns a copy of the repository named name, or nil.
+func (s *Server) Repository(name string) *harbor.Repository {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r := s.repoByName(name); r != nil {
+		cp := r.Repository
+		return &cp
+	}
+	return nil
+}
+
+// Tags returns the names of the tags of repoName.
+func (s *Server) Tags(repoName string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var names []string
+	if r := s.repoByName(repoName); r != nil {
+		for _, t := range r.tags {
+			names = append(names, t.Name)
+		}
+	}
+	return names
+}
+
+// User returns a copy of the user named username, or nil.
+func (s *Server) User(username string) *harbor.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if u := s.userByName(username); u != nil {
+		cp := u.User
+		return &cp
+	}
+	return nil
+}
+
+// Label returns a copy of the label named name, or nil.
+func (s *Server) Label(name string) *harbor.Label {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, l := range s.labels {
+		if l.Name == name {
+			cp := *l
+			return &cp
+		}
+	}
+	return nil
+}
+
+// Target returns a copy of the target named name, or nil.
+func (s *Server) Target(name string) *harbor.Target {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.targets {
+		if t.Name == name {
+			cp := *t
+			return &cp
+		}
+	}
+	return nil
+}
+
+// Job returns a copy of the replication job with id, or nil.
+func (s *Server) Job(id int) *harbor.ReplicationJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if j := s.jobByID(id); j != nil {
+		cp := j.ReplicationJob
+		return &cp
+	}
+	return nil
+}
+
+// ServeHTTP serves the Harbor API.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rc := &request{s: s, w: w, r: r, user: s.currentUser(r)}
+	path := r.URL.Path
+	switch {
+	case (path == "/login" || path == "/c/login") && r.Method == "POST":
+		s.login(rc)
+	case (path == "/log_out" || path == "/c/log_out") && r.Method == "GET":
+		s.logout(rc)
+	case path == "/api/repositories" || strings.HasPrefix(path, "/api/repositories/"):
+		s.serveRepositories(rc, strings.TrimPrefix(strings.TrimPrefix(path, "/api/repositories"), "/"))
+	case strings.HasPrefix(path, "/api/"):
+		s.serveAPI(rc, strings.Split(strings.Trim(strings.TrimPrefix(path, "/api/"), "/"), "/"))
+	default:
+		rc.error(http.StatusNotFound, "not found")
+	}
+}
+
+// request is what a handler works on.
+type request struct {
+	s    *Server
+	w    http.ResponseWriter
+	r    *http.Request
+	user *user // nil if not logged in
+}
+
+func (rc *request) error(code int, format string, a ...interface{}) {
+	http.Error(rc.w, fmt.Sprintf(format, a...), code)
+}
+
+func (rc *request) json(code int, v interface{}) {
+	rc.w.Header().Set("Content-Type", "application/json")
+	rc.w.WriteHeader(code)
+	json.NewEncoder(rc.w).Encode(v)
+}
+
+func (rc *request) ok() {
+	rc.w.WriteHeader(http.StatusOK)
+}
+
+func (rc *request) created(location string) {
+	rc.w.Header().Set("Location", location)
+	rc.w.WriteHeader(http.StatusCreated)
+}
+
+// decode reads the JSON body into v, a 400 is sent on failure.
+func (rc *request) decode(v interface{}) bool {
+	if err := json.NewDecoder(rc.r.Body).Decode(v); err != nil {
+		rc.error(http.StatusBadRequest, "invalid JSON: %v", err)
+		return false
+	}
+	return true
+}
+
+// login requires a login, a 401 is sent if not.
+func (rc *request) login() bool {
+	if rc.user == nil {
+		rc.error(http.StatusUnauthorized, "UnAuthorize")
+		return false
+	}
+	return true
+}
+
+// admin requires a system admin.
+func (rc *request) admin() bool {
+	if !rc.login() {
+		return false
+	}
+	if !rc.user.HasAdminRole {
+		rc.error(http.StatusForbidden, "%s is not a system admin", rc.user.Username)
+		return false
+	}
+	return true
+}
+
+// role returns the role of the user in p, system admins are project admins
+// of all projects. It is 0 if the user is not a member.
+func (rc *request) role(p *project) int {
+	if rc.user == nil {
+		return 0
+	}
+	if rc.user.HasAdminRole {
+		return RoleProjectAdmin
+	}
+	for _, m := range p.members {
+		if m.EntityID == rc.user.UserID {
+			return m.RoleID
+		}
+	}
+	return 0
+}
+
+// readable requires the right to read p.
+func (rc *request) readable(p *project) bool {
+	if p.Metadata["public"] == "true" || rc.role(p) != 0 {
+		return true
+	}
+	if !rc.login() {
+		return false
+	}
+	rc.error(http.StatusForbidden, "%s has no permission on project %s", rc.user.Username, p.Name)
+	return false
+}
+
+// writable requires a role in p not lower than role.
+func (rc *request) writable(p *project, role int) bool {
+	if !rc.login() {
+		return false
+	}
+	if r := rc.role(p); r == 0 || r > role {
+		rc.error(http.StatusForbidden, "%s has no permission on project %s", rc.user.Username, p.Name)
+		return false
+	}
+	return true
+}
+
+func (rc *request) query(key string) string {
+	return rc.r.URL.Query().Get(key)
+}
+
+func (rc *request) queryInt(key string) int {
+	i, _ := strconv.Atoi(rc.query(key))
+	return i
+}
+
+// page sends one page of items (a slice) as Harbor does, with the
+// X-Total-Count and Link headers.
+func (rc *request) page(items interface{}) {
+	v := reflect.ValueOf(items)
+	total := v.Len()
+
+	page, size := rc.queryInt("page"), rc.queryInt("page_size")
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 {
+		size = 10
+	}
+	if size > harbor.MaxPageSize {
+		size = harbor.MaxPageSize
+	}
+
+	start, end := (page-1)*size, page*size
+	if start > total {
+		start = total
+	}
+	if end > total {
+		end = total
+	}
+
+	link := func(page int, rel string) string {
+		q := rc.r.URL.Query()
+		q.Set("page", strconv.Itoa(page))
+		q.Set("page_size", strconv.Itoa(size))
+		return fmt.Sprintf(`<%s?%s>; rel="%s"`, rc.r.URL.Path, q.Encode(), rel)
+	}
+	var links []string
+	if page > 1 {
+		links = append(links, link(page-1, "prev"))
+	}
+	if end < total {
+		links = append(links, link(page+1, "next"))
+	}
+	if len(links) > 0 {
+		rc.w.Header().Set("Link", strings.Join(links, ", "))
+	}
+	rc.w.Header().Set("X-Total-Count", strconv.Itoa(total))
+
+	window := reflect.MakeSlice(v.Type(), 0, end-start)
+	window = reflect.AppendSlice(window, v.Slice(start, end))
+	rc.json(http.StatusOK, window.Interface())
+}
+
+func (s *Server) currentUser(r *http.Request) *user {
+	cookie, err := r.Cookie(harbor.SessionCookie)
+	if err != nil {
+		return nil
+	}
+	id, ok := s.sessions[cookie.Value]
+	if !ok {
+		return nil
+	}
+	return s.userByID(id)
+}
+
+func (s *Server) login(rc *request) {
+	if err := rc.r.ParseForm(); err != nil {
+		rc.error(http.StatusBadRequest, "%v", err)
+		return
+	}
+	u := s.userByName(rc.r.PostForm.Get("principal"))
+	if u == nil || u.password != rc.r.PostForm.Get("password") {
+		rc.error(http.StatusUnauthorized, "")
+		return
+	}
+
+	b := make([]byte, 16)
+	rand.Read(b)
+	sid := hex.EncodeToString(b)
+	s.sessions[sid] = u.UserID
+
+	http.SetCookie(rc.w, &http.Cookie{Name: harbor.SessionCookie, Value: sid, Path: "/", HttpOnly: true})
+	rc.ok()
+}
+
+func (s *Server) logout(rc *request) {
+	if cookie, err := rc.r.Cookie(harbor.SessionCookie); err == nil {
+		delete(s.sessions, cookie.Value)
+	}
+	rc.ok()
+}
+
+// serveAPI serves /api/<segs...> except repositories.
+func (s *Server) serveAPI(rc *request, segs []string) {
+	switch segs[0] {
+	case "projects":
+		s.serveProjects(rc, segs[1:])
+	case "users":
+		s.serveUsers(rc, segs[1:])
+	case "labels":
+		s.serveLabels(rc, segs[1:])
+	case "targets":
+		s.serveTargets(rc, segs[1:])
+	case "policies":
+		if len(segs) > 1 && segs[1] == "replication" {
+			s.servePolicies(rc, segs[2:])
+			return
+		}
+		rc.error(http.StatusNotFound, "not found")
+	case "replications":
+		s.serveReplications(rc)
+	case "jobs":
+		s.serveJobs(rc, segs[1:])
+	case "logs":
+		s.serveLogs(rc)
+	case "systeminfo":
+		s.serveSystemInfo(rc, segs[1:])
+	case "statistics":
+		s.serveStatistics(rc)
+	case "search":
+		s.serveSearch(rc)
+	case "configurations":
+		s.serveConfigurations(rc, segs[1:])
+	case "usergroups":
+		s.serveUserGroups(rc, segs[1:])
+	case "email", "internal":
+		// email/ping and internal/syncregistry
+		if rc.r.Method == "POST" && rc.admin() {
+			rc.ok()
+		}
+	default:
+		rc.error(http.StatusNotFound, "not found")
+	}
+}
+
+// id parses the ID in a path segment, a 400 is sent on failure.
+func (rc *request) id(seg string) (int, bool) {
+	id, err := strconv.Atoi(seg)
+	if err != nil || id <= 0 {
+		rc.error(http.StatusBadRequest, "invalid ID: %s", seg)
+		return 0, false
+	}
+	return id, true
+}
+
+func (rc *request) methodNotAllowed() {
+	rc.error(http.StatusMethodNotAllowed, "method %s not allowed", rc.r.Method)
+}
+
+func contains(s, substr string) bool {
+	return substr == "" || strings.Contains(s, substr)
+}
+
+func unescape(s string) string {
+	if u, err := url.PathUnescape(s); err == nil {
+		return u
+	}
+	return s
+}
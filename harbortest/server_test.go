@@ -0,0 +1,137 @@
+package harbortest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+func newClient(t *testing.T, srv *Server, username, password string) *harbor.Client {
+	t.Helper()
+	c, err := harbor.NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if username != "" {
+		if err := c.Login(username, password); err != nil {
+			t.Fatalf("login as %s: %v", username, err)
+		}
+	}
+	return c
+}
+
+func TestLogin(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	c := newClient(t, srv, "", "")
+	if err := c.Login(AdminUsername, "wrong"); !errors.Is(err, harbor.ErrUnauthorized) {
+		t.Fatalf("login with a wrong password: got %v", err)
+	}
+	if err := c.Login(AdminUsername, AdminPassword); err != nil {
+		t.Fatal(err)
+	}
+	u, err := c.GetCurrentUser()
+	if err != nil || u.Username != AdminUsername || !u.HasAdminRole {
+		t.Fatalf("current user: got %+v, %v", u, err)
+	}
+
+	if err := c.Logout(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetCurrentUser(); !errors.Is(err, harbor.ErrUnauthorized) {
+		t.Fatalf("current user after logout: got %v", err)
+	}
+}
+
+func TestProjectVisibility(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.AddUser("dev", "Dev12345", false)
+	srv.AddProject("private", false, AdminUsername)
+	srv.AddProject("mine", false, "dev")
+
+	for _, tc := range []struct {
+		username, password string
+		want               int
+	}{
+		{"", "", 1}, // library
+		{"dev", "Dev12345", 2},
+		{AdminUsername, AdminPassword, 3},
+	} {
+		prjs, err := newClient(t, srv, tc.username, tc.password).ListProjects(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(prjs) != tc.want {
+			t.Errorf("%q sees %d projects, want %d", tc.username, len(prjs), tc.want)
+		}
+	}
+
+	c := newClient(t, srv, "dev", "Dev12345")
+	if _, err := c.GetProject(srv.Project("private").ProjectID); !errors.Is(err, harbor.ErrForbidden) {
+		t.Errorf("get a private project of others: got %v", err)
+	}
+	if err := c.DeleteProject(srv.Project("library").ProjectID); !errors.Is(err, harbor.ErrForbidden) {
+		t.Errorf("delete a public project of others: got %v", err)
+	}
+}
+
+func TestDeleteTagDeletesDigest(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.PushImage("library/busybox", "v1", "sha256:1111")
+	srv.PushImage("library/busybox", "latest", "sha256:1111")
+	srv.PushImage("library/busybox", "v2", "sha256:2222")
+
+	c := newClient(t, srv, AdminUsername, AdminPassword)
+	if err := c.DeleteTag("library/busybox", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	if tags := srv.Tags("library/busybox"); len(tags) != 1 || tags[0] != "v2" {
+		t.Fatalf("tags after delete: got %v, want [v2]", tags)
+	}
+
+	if err := c.DeleteTag("library/busybox", "v2"); err != nil {
+		t.Fatal(err)
+	}
+	if r := srv.Repository("library/busybox"); r != nil {
+		t.Fatalf("repository is not deleted with its last tag: %+v", r)
+	}
+}
+
+func TestPagination(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	for i := 0; i < 25; i++ {
+		srv.AddUser("user"+string(rune('a'+i)), "Passw0rd", false)
+	}
+
+	c := newClient(t, srv, AdminUsername, AdminPassword)
+	it := c.IterUsers(&harbor.UserSearchOptions{PageSize: 10})
+	n := 0
+	for it.Next() {
+		n++
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if n != 26 {
+		t.Fatalf("iterated %d users, want 26", n)
+	}
+}
+
+func TestExpireSessions(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	c := newClient(t, srv, AdminUsername, AdminPassword)
+	srv.ExpireSessions()
+	if _, err := c.GetCurrentUser(); !errors.Is(err, harbor.ErrUnauthorized) {
+		t.Fatalf("current user after the session expired: got %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+package harbortest
+
+import (
+	"encoding/pem"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+func defaultConfig() map[string]*harbor.ConfigItem {
+	values := map[string]interface{}{
+		"auth_mode":                    "db_auth",
+		"email_from":                   "admin <sample_admin@mydomain.com>",
+		"email_host":                   "smtp.mydomain.com",
+		"email_port":                   25,
+		"email_identity":               "",
+		"email_username":               "sample_admin@mydomain.com",
+		"email_ssl":                    false,
+		"email_insecure":               false,
+		"ldap_url":                     "",
+		"ldap_base_dn":                 "",
+		"ldap_filter":                  "",
+		"ldap_scope":                   2,
+		"ldap_uid":                     "cn",
+		"ldap_search_dn":               "",
+		"ldap_timeout":                 5,
+		"project_creation_restriction": "everyone",
+		"self_registration":            true,
+		"token_expiration":             30,
+		"verify_remote_cert":           true,
+	}
+	cfg := make(map[string]*harbor.ConfigItem, len(values))
+	for k, v := range values {
+		cfg[k] = &harbor.ConfigItem{Value: v, Editable: true}
+	}
+	return cfg
+}
+
+// filterLogs returns the access logs readable by rc which match the query,
+// newest first.
+func (s *Server) filterLogs(rc *request) []*harbor.AccessLog {
+	username, repo, tag, op := rc.query("username"), rc.query("repository"), rc.query("tag"), rc.query("operation")
+	begin, end := int64(rc.queryInt("begin_timestamp")), int64(rc.queryInt("end_timestamp"))
+
+	logs := []*harbor.AccessLog{}
+	for i := len(s.logs) - 1; i >= 0; i-- {
+		l := s.logs[i]
+		t, _ := time.Parse(time.RFC3339, l.OpTime)
+		switch {
+		case !contains(l.Username, username), !contains(l.RepoName, repo), !contains(l.RepoTag, tag):
+		case op != "" && l.Operation != op:
+		case begin > 0 && t.Unix() < begin, end > 0 && t.Unix() > end:
+		default:
+			if p := s.projectByID(l.ProjectID); p != nil && rc.role(p) != 0 || rc.user != nil && rc.user.HasAdminRole {
+				logs = append(logs, l)
+			}
+		}
+	}
+	return logs
+}
+
+func (s *Server) serveLogs(rc *request) {
+	if rc.r.Method != "GET" {
+		rc.methodNotAllowed()
+		return
+	}
+	if rc.login() {
+		rc.page(s.filterLogs(rc))
+	}
+}
+
+// serveSystemInfo serves /api/systeminfo[/volumes|/getcert].
+func (s *Server) serveSystemInfo(rc *request, segs []string) {
+	if rc.r.Method != "GET" {
+		rc.methodNotAllowed()
+		return
+	}
+
+	switch {
+	case len(segs) == 0:
+		selfReg, _ := s.config["self_registration"].Value.(bool)
+		restriction, _ := s.config["project_creation_restriction"].Value.(string)
+		authMode, _ := s.config["auth_mode"].Value.(string)
+		rc.json(http.StatusOK, &harbor.SystemInfo{
+			AuthMode:                    authMode,
+			RegistryURL:                 strings.TrimPrefix(strings.TrimPrefix(s.URL, "https://"), "http://"),
+			ProjectCreationRestriction:  restriction,
+			SelfRegistration:            selfReg,
+			HasCARoot:                   s.TLS != nil,
+			HarborVersion:               s.HarborVersion,
+			RegistryStorageProviderName: "filesystem",
+		})
+	case segs[0] == "volumes":
+		if !rc.admin() {
+			return
+		}
+		var v harbor.SystemVolumes
+		v.Storage.Total = 100 << 30
+		v.Storage.Free = 60 << 30
+		rc.json(http.StatusOK, &v)
+	case segs[0] == "getcert":
+		// the certificate of NewTLSServer is the CA of itself
+		if s.TLS == nil || len(s.TLS.Certificates) == 0 {
+			rc.error(http.StatusNotFound, "no CA certificate")
+			return
+		}
+		rc.w.Header().Set("Content-Type", "application/octet-stream")
+		pem.Encode(rc.w, &pem.Block{Type: "CERTIFICATE", Bytes: s.TLS.Certificates[0].Certificate[0]})
+	default:
+		rc.error(http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) serveStatistics(rc *request) {
+	if !rc.login() {
+		return
+	}
+
+	var st harbor.Statistics
+	for _, p := range s.projects {
+		public := p.Metadata["public"] == "true"
+		if public {
+			st.PublicProjectCount++
+			st.PublicRepoCount += p.RepoCount
+		} else if rc.role(p) != 0 {
+			st.PrivateProjectCount++
+			st.PrivateRepoCount += p.RepoCount
+		}
+	}
+	if rc.user.HasAdminRole {
+		st.TotalProjectCount = len(s.projects)
+		st.TotalRepoCount = len(s.repos)
+	}
+	rc.json(http.StatusOK, &st)
+}
+
+func (s *Server) serveSearch(rc *request) {
+	q := rc.query("q")
+	r := harbor.SearchResult{Project: []*harbor.Project{}, Repository: []*harbor.SearchRepository{}}
+	for _, p := range s.projects {
+		if (p.Metadata["public"] == "true" || rc.role(p) != 0) && contains(p.Name, q) {
+			r.Project = append(r.Project, s.projectView(p, rc))
+		}
+	}
+	for _, repo := range s.repos {
+		p := s.projectByID(repo.ProjectID)
+		if (p.Metadata["public"] == "true" || rc.role(p) != 0) && contains(repo.Name, q) {
+			r.Repository = append(r.Repository, &harbor.SearchRepository{
+				ProjectID:      p.ProjectID,
+				ProjectName:    p.Name,
+				ProjectPublic:  p.Metadata["public"] == "true",
+				PullCount:      repo.PullCount,
+				RepositoryName: repo.Name,
+				TagsCount:      repo.TagsCount,
+			})
+		}
+	}
+	rc.json(http.StatusOK, &r)
+}
+
+// serveConfigurations serves /api/configurations[/reset].
+func (s *Server) serveConfigurations(rc *request, segs []string) {
+	if !rc.admin() {
+		return
+	}
+
+	switch {
+	case len(segs) == 1 && segs[0] == "reset" && rc.r.Method == "POST":
+		s.config = defaultConfig()
+		rc.ok()
+	case len(segs) > 0:
+		rc.error(http.StatusNotFound, "not found")
+	case rc.r.Method == "GET":
+		rc.json(http.StatusOK, s.config)
+	case rc.r.Method == "PUT":
+		var values map[string]interface{}
+		if !rc.decode(&values) {
+			return
+		}
+		for k, v := range values {
+			s.config[k] = &harbor.ConfigItem{Value: v, Editable: true}
+		}
+		rc.ok()
+	default:
+		rc.methodNotAllowed()
+	}
+}
+
+// serveUserGroups serves /api/usergroups[/{id}].
+func (s *Server) serveUserGroups(rc *request, segs []string) {
+	if !rc.admin() {
+		return
+	}
+
+	if len(segs) == 0 || segs[0] == "" {
+		switch rc.r.Method {
+		case "GET":
+			groups := []*harbor.UserGroup{}
+			rc.json(http.StatusOK, append(groups, s.groups...))
+		case "POST":
+			var req harbor.UserGroup
+			if !rc.decode(&req) {
+				return
+			}
+			if req.GroupName == "" {
+				rc.error(http.StatusBadRequest, "group_name is required")
+				return
+			}
+			for _, g := range s.groups {
+				if g.GroupName == req.GroupName {
+					rc.error(http.StatusConflict, "group %s already exists", req.GroupName)
+					return
+				}
+			}
+			req.ID = s.nextID("usergroup")
+			s.groups = append(s.groups, &req)
+			rc.created("/api/usergroups/" + strconv.Itoa(req.ID))
+		default:
+			rc.methodNotAllowed()
+		}
+		return
+	}
+
+	id, ok := rc.id(segs[0])
+	if !ok {
+		return
+	}
+	i := sort.Search(len(s.groups), func(i int) bool { return s.groups[i].ID >= id })
+	if i == len(s.groups) || s.groups[i].ID != id {
+		rc.error(http.StatusNotFound, "user group %d not found", id)
+		return
+	}
+	g := s.groups[i]
+
+	switch rc.r.Method {
+	case "GET":
+		rc.json(http.StatusOK, g)
+	case "PUT":
+		var req harbor.UserGroup
+		if !rc.decode(&req) {
+			return
+		}
+		if req.GroupName != "" {
+			g.GroupName = req.GroupName
+		}
+		g.GroupType, g.LdapGroupDN = req.GroupType, req.LdapGroupDN
+		rc.ok()
+	case "DELETE":
+		s.groups = append(s.groups[:i], s.groups[i+1:]...)
+		rc.ok()
+	default:
+		rc.methodNotAllowed()
+	}
+}
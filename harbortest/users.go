@@ -0,0 +1,186 @@
+package harbortest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+func (s *Server) userByName(username string) *user {
+	for _, u := range s.users {
+		if u.Username == username {
+			return u
+		}
+	}
+	return nil
+}
+
+func (s *Server) userByID(id int) *user {
+	for _, u := range s.users {
+		if u.UserID == id {
+			return u
+		}
+	}
+	return nil
+}
+
+// serveUsers serves /api/users/<segs...>.
+func (s *Server) serveUsers(rc *request, segs []string) {
+	if len(segs) == 0 || segs[0] == "" {
+		switch rc.r.Method {
+		case "GET":
+			if !rc.admin() {
+				return
+			}
+			username, email := rc.query("username"), rc.query("email")
+			users := []*harbor.User{}
+			for _, u := range s.users {
+				if contains(u.Username, username) && contains(u.Email, email) {
+					cp := u.User
+					users = append(users, &cp)
+				}
+			}
+			rc.page(users)
+		case "POST":
+			s.createUser(rc)
+		default:
+			rc.methodNotAllowed()
+		}
+		return
+	}
+
+	if segs[0] == "current" {
+		if rc.login() {
+			rc.json(http.StatusOK, &rc.user.User)
+		}
+		return
+	}
+
+	id, ok := rc.id(segs[0])
+	if !ok || !rc.login() {
+		return
+	}
+	u := s.userByID(id)
+	if u == nil {
+		rc.error(http.StatusNotFound, "user %d not found", id)
+		return
+	}
+	self := rc.user.UserID == u.UserID
+	if !self && !rc.admin() {
+		return
+	}
+
+	if len(segs) == 1 {
+		switch rc.r.Method {
+		case "GET":
+			rc.json(http.StatusOK, &u.User)
+		case "PUT":
+			var profile harbor.UserProfile
+			if !rc.decode(&profile) {
+				return
+			}
+			u.Email, u.Realname, u.Comment = profile.Email, profile.Realname, profile.Comment
+			u.UpdateTime = s.now()
+			rc.ok()
+		case "DELETE":
+			if !rc.admin() {
+				return
+			}
+			if self {
+				rc.error(http.StatusForbidden, "can not delete yourself")
+				return
+			}
+			s.deleteUser(u)
+			rc.ok()
+		default:
+			rc.methodNotAllowed()
+		}
+		return
+	}
+
+	if rc.r.Method != "PUT" {
+		rc.methodNotAllowed()
+		return
+	}
+	switch segs[1] {
+	case "password":
+		var body struct {
+			OldPassword string `json:"old_password"`
+			NewPassword string `json:"new_password"`
+		}
+		if !rc.decode(&body) {
+			return
+		}
+		if body.NewPassword == "" {
+			rc.error(http.StatusBadRequest, "new_password is required")
+			return
+		}
+		if self && body.OldPassword != u.password {
+			rc.error(http.StatusForbidden, "incorrect old_password")
+			return
+		}
+		u.password = body.NewPassword
+		rc.ok()
+	case "sysadmin":
+		if !rc.admin() {
+			return
+		}
+		var body struct {
+			HasAdminRole bool `json:"has_admin_role"`
+		}
+		if !rc.decode(&body) {
+			return
+		}
+		u.HasAdminRole = body.HasAdminRole
+		rc.ok()
+	default:
+		rc.error(http.StatusNotFound, "not found")
+	}
+}
+
+// createUser signs up a user, which only a system admin can do unless self
+// registration is on.
+func (s *Server) createUser(rc *request) {
+	if selfReg, _ := s.config["self_registration"].Value.(bool); !selfReg && !rc.admin() {
+		return
+	}
+	var req harbor.User
+	if !rc.decode(&req) {
+		return
+	}
+	if req.Username == "" || req.Password == "" {
+		rc.error(http.StatusBadRequest, "username and password are required")
+		return
+	}
+	if s.userByName(req.Username) != nil {
+		rc.error(http.StatusConflict, "username %s has already been used", req.Username)
+		return
+	}
+
+	req.HasAdminRole = req.HasAdminRole && rc.user != nil && rc.user.HasAdminRole
+	u := s.addUser(&req, req.Password)
+	rc.created("/api/users/" + strconv.Itoa(u.UserID))
+}
+
+func (s *Server) deleteUser(u *user) {
+	for i := range s.users {
+		if s.users[i] == u {
+			s.users = append(s.users[:i], s.users[i+1:]...)
+			break
+		}
+	}
+	for sid, id := range s.sessions {
+		if id == u.UserID {
+			delete(s.sessions, sid)
+		}
+	}
+	for _, p := range s.projects {
+		for i, m := range p.members {
+			if m.EntityID == u.UserID {
+				p.members = append(p.members[:i], p.members[i+1:]...)
+				break
+			}
+		}
+	}
+}
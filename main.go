@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/moooofly/harbor-go-client/utils"
@@ -11,11 +12,16 @@ import (
 )
 
 func main() {
-	if _, err := utils.Parser.Parse(); err != nil {
-		if flagsErr, ok := err.(*flags.Error); ok && flagsErr.Type == flags.ErrHelp {
-			os.Exit(0)
+	if _, err := utils.Parse(); err != nil {
+		if flagsErr, ok := err.(*flags.Error); ok {
+			if flagsErr.Type == flags.ErrHelp {
+				fmt.Println(err)
+			} else {
+				fmt.Fprintln(os.Stderr, err)
+			}
 		} else {
-			os.Exit(1)
+			fmt.Fprintln(os.Stderr, "Error:", err)
 		}
+		os.Exit(utils.ExitCode(err))
 	}
 }
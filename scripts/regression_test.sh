@@ -32,7 +32,7 @@ prepare() {
     separator
 
     echo "----- harbor-go-client login -----"
-    ./harbor-go-client login -u admin -p Harbor12345 && echo -e "${SUCCESS} username: admin\n${SUCCESS} Save .cookie.yaml" || echo "${ERROR}"
+    ./harbor-go-client login -u admin -p Harbor12345 && echo -e "${SUCCESS} username: admin\n${SUCCESS} Save session" || echo "${ERROR}"
     separator
 
     echo "----- whoami -----"
@@ -54,7 +54,7 @@ prepare() {
 cleanup() {
 
     echo "----- harbor-go-client logout -----"
-    ./harbor-go-client logout && echo -e "${SUCCESS} Delete .cookie.yaml" || echo "${ERROR}"
+    ./harbor-go-client logout && echo -e "${SUCCESS} Delete session" || echo "${ERROR}"
     separator
 
     echo "----- delete docker image and tags created for test -----"
@@ -113,17 +113,17 @@ full_api_tests() {
     echo "----- tag_del -----"
     for i in $(seq 1 5)
     do
-        ./harbor-go-client tag_del --repo_name=$PRJ_NAME/hello-world --tag=v"$i" && echo -e "${SUCCESS} repo_name: $PRJ_NAME/hello-world\n${SUCCESS} tag: v$i" || echo "${ERROR}"
+        ./harbor-go-client tag_del --yes --repo_name=$PRJ_NAME/hello-world --tag=v"$i" && echo -e "${SUCCESS} repo_name: $PRJ_NAME/hello-world\n${SUCCESS} tag: v$i" || echo "${ERROR}"
     done
     separator
 
     echo "----- repo_del -----"
     echo "----- (NOTE: if all tags with a repo are deleted, the repo will be deleted altogether. You will get 404 NOT FOUND here) -----"
-    ./harbor-go-client repo_del --repo_name=$PRJ_NAME/hello-world && echo -e "${SUCCESS} repo_name: $PRJ_NAME/hello-world" || echo "${ERROR}"
+    ./harbor-go-client repo_del --yes --repo_name=$PRJ_NAME/hello-world && echo -e "${SUCCESS} repo_name: $PRJ_NAME/hello-world" || echo "${ERROR}"
     separator
 
     echo "----- prj_del -----"
-    ./harbor-go-client prj_del --project_id="$PRJ_ID" && echo -e "${SUCCESS} project_id: $PRJ_ID" || echo "${ERROR}"
+    ./harbor-go-client prj_del --yes --project_id="$PRJ_ID" && echo -e "${SUCCESS} project_id: $PRJ_ID" || echo "${ERROR}"
     separator
 
     # special one
@@ -220,11 +220,11 @@ repositories_test() {
     prepare
 
     echo "----- repo_del -----"
-    ./harbor-go-client repo_del --repo_name=$PRJ_NAME/hello-world && echo -e "${SUCCESS} repo_name: $PRJ_NAME/hello-world" || echo "${ERROR}"
+    ./harbor-go-client repo_del --yes --repo_name=$PRJ_NAME/hello-world && echo -e "${SUCCESS} repo_name: $PRJ_NAME/hello-world" || echo "${ERROR}"
     separator
 
     echo "----- repo_del (one more time, you will get 404 NOT FOUND) -----"
-    ./harbor-go-client repo_del --repo_name=$PRJ_NAME/hello-world && echo -e "${SUCCESS} repo_name: $PRJ_NAME/hello-world" || echo "${ERROR}"
+    ./harbor-go-client repo_del --yes --repo_name=$PRJ_NAME/hello-world && echo -e "${SUCCESS} repo_name: $PRJ_NAME/hello-world" || echo "${ERROR}"
     separator
 
     echo "----- repos_top -----"
@@ -260,7 +260,7 @@ tags_test() {
     echo "----- tag_del -----"
     for i in $(seq 1 5)
     do
-        ./harbor-go-client tag_del --repo_name=$PRJ_NAME/hello-world --tag=v"$i" && echo -e "${SUCCESS} repo_name: $PRJ_NAME/hello-world\n${SUCCESS} tag: v$i" || echo "${ERROR}"
+        ./harbor-go-client tag_del --yes --repo_name=$PRJ_NAME/hello-world --tag=v"$i" && echo -e "${SUCCESS} repo_name: $PRJ_NAME/hello-world\n${SUCCESS} tag: v$i" || echo "${ERROR}"
     done
     separator
 
@@ -317,11 +317,11 @@ projects_test() {
     separator
 
     echo "----- prj_del (--project_id=$PUB_ID, public) -----"
-    ./harbor-go-client prj_del --project_id="$PUB_ID" && echo -e "${SUCCESS} project_id: $PUB_ID\n${SUCCESS} public" || echo "${ERROR}"
+    ./harbor-go-client prj_del --yes --project_id="$PUB_ID" && echo -e "${SUCCESS} project_id: $PUB_ID\n${SUCCESS} public" || echo "${ERROR}"
     separator
 
     echo "----- prj_del (--project_id=$PRI_ID, private) -----"
-    ./harbor-go-client prj_del --project_id="$PRI_ID" && echo -e "${SUCCESS} project_id: $PRI_ID\n${SUCCESS} private" || echo "${ERROR}"
+    ./harbor-go-client prj_del --yes --project_id="$PRI_ID" && echo -e "${SUCCESS} project_id: $PRI_ID\n${SUCCESS} private" || echo "${ERROR}"
     separator
 
     echo "----- prj_get (--project_id=$PUB_ID, after delete) -----"
@@ -335,17 +335,17 @@ projects_test() {
     PRJ_ID=$(./harbor-go-client prjs_list --name=$PRJ_NAME | grep "project_id" | awk '{print $2}' | sed -r 's/,//g')
 
     echo "----- prj_del (project contains repositories, cann't be deleled, will get 412 Precondition Failed) -----"
-    ./harbor-go-client prj_del --project_id="$PRJ_ID" && echo -e "${SUCCESS} project_id: $PRJ_ID" || echo "${ERROR}"
+    ./harbor-go-client prj_del --yes --project_id="$PRJ_ID" && echo -e "${SUCCESS} project_id: $PRJ_ID" || echo "${ERROR}"
     separator
 
     #echo "----- prj_del (project contains targets, cann't be deleled, will get 412 Precondition Failed) -----"
 
     echo "----- repo_del (delete the repo under project $PRJ_NAME)-----"
-    ./harbor-go-client repo_del --repo_name=$PRJ_NAME/hello-world && echo -e "${SUCCESS} repo_name: $PRJ_NAME/hello-world" || echo "${ERROR}"
+    ./harbor-go-client repo_del --yes --repo_name=$PRJ_NAME/hello-world && echo -e "${SUCCESS} repo_name: $PRJ_NAME/hello-world" || echo "${ERROR}"
     separator
 
     echo "----- prj_del (project contains no repositories, can be deleled this time) -----"
-    ./harbor-go-client prj_del --project_id="$PRJ_ID" && echo -e "${SUCCESS} project_id: $PRJ_ID" || echo "${ERROR}"
+    ./harbor-go-client prj_del --yes --project_id="$PRJ_ID" && echo -e "${SUCCESS} project_id: $PRJ_ID" || echo "${ERROR}"
     separator
 
     cleanup
@@ -359,7 +359,7 @@ users_test() {
     separator
 
     echo "----- harbor-go-client login -----"
-    ./harbor-go-client login -u admin -p Harbor12345 && echo -e "${SUCCESS} username: admin\n${SUCCESS} Save .cookie.yaml" || echo "${ERROR}"
+    ./harbor-go-client login -u admin -p Harbor12345 && echo -e "${SUCCESS} username: admin\n${SUCCESS} Save session" || echo "${ERROR}"
     separator
 
     echo "----- whoami -----"
@@ -367,7 +367,7 @@ users_test() {
     separator
 
     echo "----- logout -----"
-    ./harbor-go-client logout && echo -e "${SUCCESS} Delete .cookie.yaml" || echo "${ERROR}"
+    ./harbor-go-client logout && echo -e "${SUCCESS} Delete session" || echo "${ERROR}"
     separator
 
     echo "----- remove harbor-go-client and conf/ -----"
@@ -97,7 +97,7 @@ retention_policy_test() {
     IDS=$(./harbor-go-client prjs_list --name=${PROJECT_NAME} | grep "project_id" | awk '{print $2}' | sed -r 's/,//g')
 	for id in $IDS
 	do
-    	./harbor-go-client prj_del --project_id="$id"
+    	./harbor-go-client prj_del --yes --project_id="$id"
         echo
 	done
 
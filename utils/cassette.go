@@ -0,0 +1,313 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// redacted replaces the session and the passwords in a cassette.
+const redacted = "REDACTED"
+
+// cassetteVersion is the version of the cassette format.
+const cassetteVersion = 1
+
+// Cassette is a recording of the requests sent to the harbor service and
+// their responses, which is written by --record and served back by --replay:
+//
+//	version: 1
+//	url: https://harbor.mydomain.com
+//	interactions:
+//	- request:
+//	    method: GET
+//	    url: https://harbor.mydomain.com/api/projects?page=1&page_size=10
+//	    header:
+//	      Cookie:
+//	      - beegosessionID=REDACTED
+//	  response:
+//	    status_code: 200
+//	    body: '[{"project_id":1,"name":"library"}]'
+//
+// The beegosessionID cookie, the Authorization header and the values of all
+// the fields named like password or secret (in JSON or form bodies) are
+// redacted, so a cassette is safe to be attached to an issue.
+//
+// A Cassette is also an http.RoundTripper replaying the responses, which
+// turns a recording into a test with no network at all:
+//
+//	cassette, _ := utils.LoadCassette("testdata/issue42.yaml")
+//	c, _ := harbor.NewClient(cassette.URL, &http.Client{Transport: cassette})
+type Cassette struct {
+	Version      int            `yaml:"version"`
+	URL          string         `yaml:"url"`
+	Interactions []*Interaction `yaml:"interactions"`
+
+	mu   sync.Mutex
+	used []bool
+}
+
+// Interaction is a request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `yaml:"request"`
+	Response RecordedResponse `yaml:"response"`
+}
+
+// RecordedRequest is a request kept in a cassette.
+type RecordedRequest struct {
+	Method string      `yaml:"method"`
+	URL    string      `yaml:"url"`
+	Header http.Header `yaml:"header,omitempty"`
+	Body   string      `yaml:"body,omitempty"`
+}
+
+// RecordedResponse is a response kept in a cassette.
+type RecordedResponse struct {
+	StatusCode int         `yaml:"status_code"`
+	Header     http.Header `yaml:"header,omitempty"`
+	Body       string      `yaml:"body,omitempty"`
+}
+
+// LoadCassette reads a cassette written by --record.
+func LoadCassette(file string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Cassette
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid cassette %s: %v", file, err)
+	}
+	if c.Version != cassetteVersion {
+		return nil, fmt.Errorf("invalid cassette %s: unsupported version %d", file, c.Version)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette into file.
+func (c *Cassette) Save(file string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, data, 0600)
+}
+
+// RoundTrip replays the response of the first interaction not replayed yet
+// with the same method, path and query as req. The scheme and host are not
+// compared, neither are the headers and body, since the session and passwords
+// are redacted anyway.
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.used) != len(c.Interactions) {
+		c.used = make([]bool, len(c.Interactions))
+	}
+	for i, in := range c.Interactions {
+		if c.used[i] || in.Request.Method != req.Method {
+			continue
+		}
+		u, err := url.Parse(in.Request.URL)
+		if err != nil || u.Path != req.URL.Path || u.RawQuery != req.URL.RawQuery {
+			continue
+		}
+
+		c.used[i] = true
+		header := in.Response.Header
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header.Clone(),
+			Body:          ioutil.NopCloser(strings.NewReader(in.Response.Body)),
+			ContentLength: int64(len(in.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no response recorded for %s %s", req.Method, req.URL.RequestURI())
+}
+
+// recorder sends requests by next, and saves every request and its response
+// into file (redacted) as soon as the response arrives, so nothing is lost
+// whenever the command fails.
+type recorder struct {
+	next http.RoundTripper
+	file string
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// newRecorder creates an empty cassette file for the harbor service at
+// baseURL, so that an unwritable file fails before any request is sent.
+func newRecorder(file, baseURL string, next http.RoundTripper) (*recorder, error) {
+	r := &recorder{
+		next:     next,
+		file:     file,
+		cassette: Cassette{Version: cassetteVersion, URL: baseURL},
+	}
+	if err := r.cassette.Save(file); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		data, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		reqBody = data
+		req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	in := &Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: redactHeader(req.Header),
+			Body:   redactBody(req.Header.Get("Content-Type"), reqBody),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     redactHeader(resp.Header),
+			Body:       redactBody(resp.Header.Get("Content-Type"), respBody),
+		},
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, in)
+	if err := r.cassette.Save(r.file); err != nil {
+		return nil, fmt.Errorf("record %s: %v", r.file, err)
+	}
+	return resp, nil
+}
+
+var sessionCookieRe = regexp.MustCompile(harbor.SessionCookie + `=[^;]*`)
+
+// redactHeader returns a copy of header, with the session and the
+// Authorization header redacted.
+func redactHeader(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+
+	h := header.Clone()
+	for _, key := range []string{"Cookie", "Set-Cookie"} {
+		for i, v := range h[key] {
+			h[key][i] = sessionCookieRe.ReplaceAllString(v, harbor.SessionCookie+"="+redacted)
+		}
+	}
+	if h.Get("Authorization") != "" {
+		h.Set("Authorization", redacted)
+	}
+	return h
+}
+
+// redactBody returns body with the values of the secret fields redacted,
+// the body is kept as it is if there is nothing to redact.
+func redactBody(contentType string, body []byte) string {
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return string(body)
+		}
+		changed := false
+		for key, values := range form {
+			if secretField(key) {
+				for i := range values {
+					values[i] = redacted
+				}
+				changed = true
+			}
+		}
+		if changed {
+			return form.Encode()
+		}
+		return string(body)
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return string(body)
+	}
+	d := json.NewDecoder(bytes.NewReader(trimmed))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil || !redactJSON(v) {
+		return string(body)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return string(body)
+	}
+	return string(data)
+}
+
+// redactJSON redacts the non-empty secret fields of v in place, and reports
+// whether anything is redacted.
+func redactJSON(v interface{}) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if s, ok := value.(string); ok && s != "" && secretField(key) {
+				v[key] = redacted
+				changed = true
+				continue
+			}
+			if redactJSON(value) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			if redactJSON(value) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// secretField reports whether the field named key keeps a secret, e.g.
+// password, old_password, email_password or access_secret.
+func secretField(key string) bool {
+	key = strings.ToLower(key)
+	return strings.Contains(key, "password") || strings.Contains(key, "secret")
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/harbortest"
+)
+
+func TestRecordReplay(t *testing.T) {
+	srv := harbortest.NewServer()
+	defer srv.Close()
+	srv.AddProject("prj", false, harbortest.AdminUsername)
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "cassette.yaml")
+	t.Setenv(EnvConfig, filepath.Join(dir, "config.yaml"))
+	t.Setenv(EnvSessionStore, SessionStoreFile)
+	t.Setenv(EnvURL, srv.URL)
+	t.Setenv(EnvUsername, harbortest.AdminUsername)
+	t.Setenv(EnvPassword, harbortest.AdminPassword)
+	defer func() { Opts = Options{} }()
+
+	// record
+	Opts = Options{Record: file}
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := c.SessionID
+	if err := c.CreateUser(&harbor.User{Username: "dev", Password: "Dev12345", Email: "dev@mydomain.com"}); err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := c.ListProjects(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetProject(100); !errors.Is(err, harbor.ErrNotFound) {
+		t.Fatalf("GetProject(100): %v", err)
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{session, harbortest.AdminPassword, "Dev12345"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains %q:\n%s", secret, data)
+		}
+	}
+
+	// replay with the server gone
+	srv.Close()
+	Opts = Options{Replay: file}
+	c, err = NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.CreateUser(&harbor.User{Username: "dev", Password: "Dev12345", Email: "dev@mydomain.com"}); err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := c.ListProjects(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replayed) != len(recorded) || replayed[1].Name != "prj" {
+		t.Errorf("ListProjects: replayed %+v, recorded %+v", replayed, recorded)
+	}
+	if _, err := c.GetProject(100); !errors.Is(err, harbor.ErrNotFound) {
+		t.Errorf("GetProject(100): %v", err)
+	}
+	if _, err := c.ListProjects(nil); err == nil || !strings.Contains(err.Error(), "no response recorded") {
+		t.Errorf("ListProjects once more: %v", err)
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		contentType, body, want string
+	}{
+		{"application/x-www-form-urlencoded;param=value", "principal=admin&password=Harbor12345", "password=REDACTED&principal=admin"},
+		{"application/json", `{"username":"dev","password":"x","profile":{"email_password":"y"}}`, `{"password":"REDACTED","profile":{"email_password":"REDACTED"},"username":"dev"}`},
+		{"application/json", `[{"name":"t","password":"","access_secret":"z","count":12345678901234567890}]`, `[{"access_secret":"REDACTED","count":12345678901234567890,"name":"t","password":""}]`},
+		{"application/json", `{"name": "prj"}`, `{"name": "prj"}`},
+		{"text/plain", "password=x", "password=x"},
+	}
+	for _, tt := range tests {
+		if got := redactBody(tt.contentType, []byte(tt.body)); got != tt.want {
+			t.Errorf("redactBody(%q, %s) = %s, want %s", tt.contentType, tt.body, got, tt.want)
+		}
+	}
+}
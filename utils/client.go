@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+// NewClient creates a harbor.Client for the current context, and restores the
+// session saved by the last login to it if there is one. Without a saved
+// session, it logs in with $HARBOR_USERNAME and $HARBOR_PASSWORD when both of
+// them are set, and the new session is never saved.
+//
+// When the saved session has expired, the client logs in again (only once)
+// with the credentials found by loginCredentials, saves the new session and
+// replays the request.
+func NewClient() (*harbor.Client, error) {
+	ctx, c, session, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	username, password := os.Getenv(EnvUsername), os.Getenv(EnvPassword)
+	if c.SessionID == "" && username != "" && password != "" {
+		if err := c.Login(username, password); err != nil {
+			return nil, err
+		}
+	}
+
+	if session != nil {
+		tried := false
+		c.Reauth = func(c *harbor.Client) error {
+			if tried {
+				return errors.New("already logged in again")
+			}
+			tried = true
+
+			username, password, err := loginCredentials(ctx)
+			if err != nil {
+				return err
+			}
+			if err := c.Login(username, password); err != nil {
+				return err
+			}
+			return SessionSave(&Session{ID: c.SessionID, Username: username, Since: time.Now()})
+		}
+	}
+
+	return c, nil
+}
+
+// newClient creates a harbor.Client for the current context with the saved
+// session (if any), and never logs in.
+func newClient() (*Context, *harbor.Client, *Session, error) {
+	if Opts.Record != "" && Opts.Replay != "" {
+		return nil, nil, nil, Usagef("--record and --replay are mutually exclusive")
+	}
+	if Opts.Replay != "" {
+		return replayClient()
+	}
+
+	ctx, err := CurrentContext()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	tlsCfg, err := tlsConfig(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if Opts.Timeout < 0 || Opts.Retries < 0 {
+		return nil, nil, nil, Usagef("--timeout and --retries must not be negative")
+	}
+	var transport http.RoundTripper = newTransport(tlsCfg)
+	if Opts.Record != "" {
+		if transport, err = newRecorder(Opts.Record, ctx.URL(), transport); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	hc := &http.Client{
+		Transport: newDryRun(transport),
+		Timeout:   Opts.Timeout,
+	}
+
+	c, err := harbor.NewClient(ctx.URL(), hc)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	c.MaxRetries = Opts.Retries
+	if Opts.Verbose {
+		c.Logger = log.New(os.Stderr, "", 0)
+	}
+
+	session, err := loadSession(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if session != nil {
+		c.SessionID = session.ID
+	}
+
+	return ctx, c, session, nil
+}
+
+// replayClient creates a harbor.Client served by the cassette of --replay,
+// which never touches the network. The recorded session was redacted, so the
+// client always carries a placeholder one, and logging in again just replays
+// the recorded login.
+func replayClient() (*Context, *harbor.Client, *Session, error) {
+	cassette, err := LoadCassette(Opts.Replay)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	c, err := harbor.NewClient(cassette.URL, &http.Client{Transport: newDryRun(cassette)})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	c.MaxRetries = Opts.Retries
+	c.RetryWait, c.RetryMaxWait = 0, 0
+	if Opts.Verbose {
+		c.Logger = log.New(os.Stderr, "", 0)
+	}
+	c.SessionID = redacted
+	c.Reauth = func(c *harbor.Client) error {
+		return c.Login(redacted, redacted)
+	}
+
+	return &Context{}, c, nil, nil
+}
+
+// newTransport returns the transport to the harbor service, which goes
+// through the proxy set by $HTTPS_PROXY or $HTTP_PROXY (both in upper or lower
+// case) unless the host matches $NO_PROXY.
+func newTransport(tlsCfg *tls.Config) *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:       tlsCfg,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: Opts.Timeout,
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConnsPerHost:   10,
+	}
+}
+
+// Run is the common body of a command: it calls fn with a ready-to-use
+// client and prints whatever fn returns, the error (if any) is returned to
+// Parser so that it decides the exit code.
+func Run(fn func(c *harbor.Client) (interface{}, error)) error {
+	if _, _, err := outputFormat(); err != nil {
+		return err
+	}
+
+	c, err := NewClient()
+	if err != nil {
+		return err
+	}
+
+	return printResult(fn(c))
+}
+
+// RunLoginout is the same as Run, but never logs in implicitly, it is used by
+// login and logout.
+func RunLoginout(fn func(c *harbor.Client) (interface{}, error)) error {
+	if _, _, err := outputFormat(); err != nil {
+		return err
+	}
+
+	_, c, _, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	return printResult(fn(c))
+}
+
+// Stdout is where the results of commands are printed, tests replace it to
+// capture them.
+var Stdout io.Writer = os.Stdout
+
+// Stderr is where the requests of --dry-run and --print-curl are printed,
+// apart from the results, tests replace it to capture them.
+var Stderr io.Writer = os.Stderr
+
+// printResult prints the result of a request in the format given by -o, or
+// returns the error.
+func printResult(v interface{}, err error) error {
+	if err != nil {
+		return err
+	}
+	return Render(Stdout, v)
+}
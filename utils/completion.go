@@ -0,0 +1,424 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+func init() {
+	Parser.AddCommand("completion",
+		"Generate shell completion scripts.",
+		"Print the completion script of bash, zsh or fish, e.g. 'source <(harbor-go-client completion bash)'. Commands and options are completed, so are the values of options like --project_id, --repo_name and --tag, which are fetched from the current context and cached for a short while.",
+		&shellCompletion)
+	Parser.CompletionHandler = completeArgs
+}
+
+// completionCacheTTL is how long the names fetched for completion are reused.
+var completionCacheTTL = 30 * time.Second
+
+// completionTimeout bounds the requests sent for completion, the shell is
+// blocked meanwhile.
+const completionTimeout = 5 * time.Second
+
+var completionCacheFile = "completion-cache.json"
+
+type completionScript struct {
+	Args struct {
+		Shell string `positional-arg-name:"shell" description:"One of bash, zsh and fish."`
+	} `positional-args:"yes" required:"yes"`
+}
+
+var shellCompletion completionScript
+
+func (x *completionScript) Execute(args []string) error {
+	script, ok := completionScripts[x.Args.Shell]
+	if !ok {
+		return Usagef("unknown shell %q, should be one of bash, zsh and fish", x.Args.Shell)
+	}
+	_, err := fmt.Fprint(Stdout, script)
+	return err
+}
+
+// completionScripts call harbor-go-client with $GO_FLAGS_COMPLETION set, then
+// Parser prints the completions of the last argument instead of running the
+// command, one per line (with a tab and its description if the variable is
+// "verbose").
+var completionScripts = map[string]string{
+	"bash": `# bash completion for harbor-go-client
+_harbor_go_client() {
+	local args=("${COMP_WORDS[@]:1:$COMP_CWORD}")
+	local IFS=$'\n'
+	COMPREPLY=($(GO_FLAGS_COMPLETION=1 "${COMP_WORDS[0]}" "${args[@]}" 2>/dev/null))
+	return 0
+}
+complete -o default -F _harbor_go_client harbor-go-client
+`,
+	"zsh": `#compdef harbor-go-client
+# zsh completion for harbor-go-client
+_harbor_go_client() {
+	local -a lines items
+	local line
+	lines=("${(@f)$(GO_FLAGS_COMPLETION=verbose "${words[1]}" "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	for line in "${lines[@]}"; do
+		[[ -z "$line" ]] && continue
+		items+=("${${line%%$'\t'*}//:/\\:}:${line#*$'\t'}")
+	done
+	_describe -t values harbor-go-client items
+}
+compdef _harbor_go_client harbor-go-client
+`,
+	"fish": `# fish completion for harbor-go-client
+function __harbor_go_client_complete
+	# quoted, so that an empty token is still passed as one
+	set -l token (commandline -ct)
+	set -l args (commandline -opc) "$token"
+	env GO_FLAGS_COMPLETION=verbose $args[1] $args[2..-1] 2>/dev/null
+end
+complete -c harbor-go-client -f -a '(__harbor_go_client_complete)'
+`,
+}
+
+// completeArgs prints the completions of the last one of os.Args, which are
+// items (commands and options found by Parser) unless it is the value of an
+// option tagged by complete, e.g.
+//
+//	RepoName string `long:"repo_name" complete:"repository"`
+//
+// whose candidates are fetched from harbor. Errors are never shown, there is
+// just nothing to complete.
+func completeArgs(items []flags.Completion) {
+	if values, ok := completeValue(expandArgs(os.Args[1:])); ok {
+		items = values
+	}
+
+	verbose := os.Getenv("GO_FLAGS_COMPLETION") == "verbose"
+	for _, item := range items {
+		if verbose {
+			fmt.Fprintf(Stdout, "%s\t%s\n", item.Item, item.Description)
+		} else {
+			fmt.Fprintln(Stdout, item.Item)
+		}
+	}
+}
+
+// completeValue returns the completions of the last argument, if it is the
+// value of an option tagged by complete.
+func completeValue(args []string) ([]flags.Completion, bool) {
+	if len(args) == 0 {
+		return nil, false
+	}
+	cur, prev := args[len(args)-1], args[:len(args)-1]
+	cmd := findCommand(prev)
+
+	var opt *flags.Option
+	prefix, match := "", cur
+	switch {
+	case strings.HasPrefix(cur, "--") && strings.Contains(cur, "="):
+		i := strings.Index(cur, "=")
+		opt = findOption(cmd, cur[2:i], 0)
+		prefix, match = cur[:i+1], cur[i+1:]
+	case strings.HasPrefix(cur, "-") && !strings.HasPrefix(cur, "--") && len(cur) > 2:
+		opt = findOption(cmd, "", rune(cur[1]))
+		prefix, match = cur[:2], cur[2:]
+	case len(prev) > 0:
+		opt = optionOf(cmd, prev[len(prev)-1])
+	}
+	if opt == nil || opt.Field().Tag.Get("complete") == "" {
+		return nil, false
+	}
+
+	candidates, err := completionCandidates(opt.Field().Tag.Get("complete"), prev, cmd)
+	if err != nil {
+		return nil, true
+	}
+	var ret []flags.Completion
+	for _, c := range candidates {
+		if strings.HasPrefix(c.Item, match) {
+			ret = append(ret, flags.Completion{Item: prefix + c.Item, Description: c.Description})
+		}
+	}
+	return ret, true
+}
+
+// findCommand returns the (sub)command given by args, or nil if there is
+// none yet.
+func findCommand(args []string) *flags.Command {
+	var cmd *flags.Command
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		parent := Parser.Command
+		if cmd != nil {
+			parent = cmd
+		}
+		if c := parent.Find(arg); c != nil {
+			cmd = c
+		}
+	}
+	return cmd
+}
+
+// findOption looks up an option of cmd by its long or short name, then a
+// global one.
+func findOption(cmd *flags.Command, long string, short rune) *flags.Option {
+	for _, c := range []*flags.Command{cmd, Parser.Command} {
+		if c == nil {
+			continue
+		}
+		var opt *flags.Option
+		if long != "" {
+			opt = c.FindOptionByLongName(long)
+		} else {
+			opt = c.FindOptionByShortName(short)
+		}
+		if opt != nil {
+			return opt
+		}
+	}
+	return nil
+}
+
+// optionOf returns the option given by arg like --repo_name or -n, whose
+// value is the next argument.
+func optionOf(cmd *flags.Command, arg string) *flags.Option {
+	var opt *flags.Option
+	switch {
+	case strings.HasPrefix(arg, "--") && !strings.Contains(arg, "="):
+		opt = findOption(cmd, arg[2:], 0)
+	case strings.HasPrefix(arg, "-") && len(arg) == 2:
+		opt = findOption(cmd, "", rune(arg[1]))
+	}
+	if opt == nil || opt.Field().Type.Kind() == reflect.Bool {
+		return nil
+	}
+	return opt
+}
+
+// completedValues returns the values given in args to the options tagged by
+// complete, e.g. the repository of the tag to complete.
+func completedValues(cmd *flags.Command, args []string) map[string]string {
+	values := map[string]string{}
+	for i, arg := range args {
+		var opt *flags.Option
+		var value string
+		switch {
+		case strings.HasPrefix(arg, "--") && strings.Contains(arg, "="):
+			j := strings.Index(arg, "=")
+			opt, value = findOption(cmd, arg[2:j], 0), arg[j+1:]
+		case strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && len(arg) > 2:
+			opt, value = findOption(cmd, "", rune(arg[1])), arg[2:]
+		case i+1 < len(args):
+			opt, value = optionOf(cmd, arg), args[i+1]
+		}
+		if opt != nil {
+			if kind := opt.Field().Tag.Get("complete"); kind != "" {
+				values[kind] = value
+			}
+		}
+	}
+	return values
+}
+
+// completionCandidates returns all the candidates of kind, from the cache if
+// they are fetched recently.
+func completionCandidates(kind string, args []string, cmd *flags.Command) ([]flags.Completion, error) {
+	// the global options, e.g. --context, decide which harbor to ask, they
+	// are parsed with $GO_FLAGS_COMPLETION unset, or nothing is parsed
+	env := os.Getenv("GO_FLAGS_COMPLETION")
+	os.Unsetenv("GO_FLAGS_COMPLETION")
+	flags.NewParser(&Opts, flags.IgnoreUnknown).ParseArgs(args)
+	os.Setenv("GO_FLAGS_COMPLETION", env)
+	if Opts.Timeout <= 0 || Opts.Timeout > completionTimeout {
+		Opts.Timeout = completionTimeout
+	}
+	Opts.Retries = 0
+
+	if kind == "context" {
+		cc, err := contextConfigLoad()
+		if err != nil {
+			return nil, err
+		}
+		var ret []flags.Completion
+		for _, c := range cc.Contexts {
+			ret = append(ret, flags.Completion{Item: c.Name, Description: c.URL()})
+		}
+		return ret, nil
+	}
+
+	ctx, err := CurrentContext()
+	if err != nil {
+		return nil, err
+	}
+	values := completedValues(cmd, args)
+	key := ctx.URL() + " " + kind
+	switch kind {
+	case "tag":
+		if values["repository"] == "" {
+			return nil, nil
+		}
+		key += " " + values["repository"]
+	case "label", "label_id":
+		key += " " + values["project_id"]
+	}
+
+	cache := loadCompletionCache()
+	if e, ok := cache[key]; ok && time.Since(e.Time) < completionCacheTTL {
+		return e.Items, nil
+	}
+
+	c, err := NewClient()
+	if err != nil {
+		return nil, err
+	}
+	items, err := fetchCandidates(c, kind, values)
+	if err != nil {
+		return nil, err
+	}
+
+	cache[key] = &completionCacheEntry{Time: time.Now(), Items: items}
+	saveCompletionCache(cache)
+	return items, nil
+}
+
+// fetchCandidates fetches all the candidates of kind from harbor.
+func fetchCandidates(c *harbor.Client, kind string, values map[string]string) ([]flags.Completion, error) {
+	var ret []flags.Completion
+	switch kind {
+	case "project", "project_id":
+		it := c.IterProjects(nil)
+		for it.Next() {
+			p := it.Project()
+			if kind == "project" {
+				ret = append(ret, flags.Completion{Item: p.Name, Description: "project " + strconv.Itoa(p.ProjectID)})
+			} else {
+				ret = append(ret, flags.Completion{Item: strconv.Itoa(p.ProjectID), Description: p.Name})
+			}
+		}
+		return ret, it.Err()
+	case "repository":
+		r, err := c.Search("")
+		if err != nil {
+			return nil, err
+		}
+		for _, repo := range r.Repository {
+			ret = append(ret, flags.Completion{Item: repo.RepositoryName, Description: plural(repo.TagsCount, "tag", "tags")})
+		}
+	case "tag":
+		tags, err := c.ListTags(values["repository"])
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tags {
+			ret = append(ret, flags.Completion{Item: t.Name, Description: t.Created})
+		}
+	case "label", "label_id":
+		scopes := []*harbor.LabelListOptions{{Scope: "g"}}
+		if id, err := strconv.Atoi(values["project_id"]); err == nil && id > 0 {
+			scopes = append(scopes, &harbor.LabelListOptions{Scope: "p", ProjectID: id})
+		}
+		for _, opt := range scopes {
+			it := c.IterLabels(opt)
+			for it.Next() {
+				l := it.Label()
+				if kind == "label" {
+					ret = append(ret, flags.Completion{Item: l.Name, Description: "label " + strconv.Itoa(l.ID)})
+				} else {
+					ret = append(ret, flags.Completion{Item: strconv.Itoa(l.ID), Description: l.Name})
+				}
+			}
+			if err := it.Err(); err != nil {
+				return nil, err
+			}
+		}
+	case "target", "target_id":
+		targets, err := c.ListTargets("")
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range targets {
+			if kind == "target" {
+				ret = append(ret, flags.Completion{Item: t.Name, Description: t.Endpoint})
+			} else {
+				ret = append(ret, flags.Completion{Item: strconv.Itoa(t.ID), Description: t.Name + " " + t.Endpoint})
+			}
+		}
+	case "user", "user_id":
+		it := c.IterUsers(nil)
+		for it.Next() {
+			u := it.User()
+			if kind == "user" {
+				ret = append(ret, flags.Completion{Item: u.Username, Description: "user " + strconv.Itoa(u.UserID)})
+			} else {
+				ret = append(ret, flags.Completion{Item: strconv.Itoa(u.UserID), Description: u.Username})
+			}
+		}
+		return ret, it.Err()
+	default:
+		return nil, fmt.Errorf("unknown completion %q", kind)
+	}
+	return ret, nil
+}
+
+type completionCacheEntry struct {
+	Time  time.Time          `json:"time"`
+	Items []flags.Completion `json:"items"`
+}
+
+// completionCachePath returns where the candidates are cached, which is next
+// to $HARBOR_CONFIG if it is set.
+func completionCachePath() (string, error) {
+	if p := os.Getenv(EnvConfig); p != "" {
+		return filepath.Join(filepath.Dir(p), completionCacheFile), nil
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "harbor-go-client", completionCacheFile), nil
+}
+
+func loadCompletionCache() map[string]*completionCacheEntry {
+	cache := map[string]*completionCacheEntry{}
+	p, err := completionCachePath()
+	if err != nil {
+		return cache
+	}
+	if data, err := ioutil.ReadFile(p); err == nil {
+		json.Unmarshal(data, &cache)
+	}
+	return cache
+}
+
+// saveCompletionCache saves cache without the expired entries, failures are
+// ignored since it is just a cache.
+func saveCompletionCache(cache map[string]*completionCacheEntry) {
+	p, err := completionCachePath()
+	if err != nil {
+		return
+	}
+	for key, e := range cache {
+		if time.Since(e.Time) >= completionCacheTTL {
+			delete(cache, key)
+		}
+	}
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return
+	}
+	ioutil.WriteFile(p, data, 0600)
+}
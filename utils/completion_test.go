@@ -0,0 +1,218 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbortest"
+)
+
+type completeTest struct {
+	RepoName  string `short:"n" long:"repo_name" complete:"repository"`
+	Tag       string `short:"t" long:"tag" complete:"tag"`
+	ProjectID int    `short:"j" long:"project_id" complete:"project_id"`
+	LabelID   int    `short:"l" long:"label_id" complete:"label_id"`
+	UserID    int    `short:"u" long:"user_id" complete:"user_id"`
+	Force     bool   `short:"f" long:"force"`
+}
+
+func (x *completeTest) Execute(args []string) error {
+	return nil
+}
+
+func init() {
+	Parser.AddCommand("complete_test", "", "", &completeTest{})
+}
+
+// complete returns what the completion scripts get for args.
+func complete(t *testing.T, args ...string) string {
+	t.Helper()
+
+	osArgs, stdout := os.Args, Stdout
+	defer func() { os.Args, Stdout, Opts = osArgs, stdout, Options{} }()
+
+	var buf bytes.Buffer
+	os.Args, Stdout = append([]string{"harbor-go-client"}, args...), &buf
+	if _, err := ParseArgs(args); err != nil {
+		t.Fatalf("%v: %v", args, err)
+	}
+	return buf.String()
+}
+
+func TestCompletion(t *testing.T) {
+	srv := harbortest.NewServer()
+	defer srv.Close()
+	srv.AddProject("prj", false, harbortest.AdminUsername)
+	srv.PushImage("library/busybox", "v1", "sha256:1111")
+	srv.PushImage("library/busybox", "v2", "sha256:2222")
+	srv.PushImage("prj/app", "v1", "sha256:3333")
+	global := srv.AddLabel("stable", "")
+	private := srv.AddLabel("qa", "prj")
+	dev := srv.AddUser("dev", "Dev12345", false)
+
+	dir := t.TempDir()
+	t.Setenv(EnvConfig, filepath.Join(dir, "config.yaml"))
+	t.Setenv(EnvSessionStore, SessionStoreFile)
+	t.Setenv(EnvURL, srv.URL)
+	t.Setenv(EnvUsername, harbortest.AdminUsername)
+	t.Setenv(EnvPassword, harbortest.AdminPassword)
+	t.Setenv("GO_FLAGS_COMPLETION", "1")
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		// commands and options are still completed by go-flags
+		{[]string{"complete_t"}, "complete_test\n"},
+		{[]string{"complete_test", "--repo"}, "--repo_name\n"},
+		{[]string{"complete_test", "--repo_name", ""}, "library/busybox\nprj/app\n"},
+		{[]string{"complete_test", "-n", "li"}, "library/busybox\n"},
+		{[]string{"complete_test", "--repo_name=p"}, "--repo_name=prj/app\n"},
+		{[]string{"complete_test", "-np"}, "-nprj/app\n"},
+		{[]string{"complete_test", "-f", "-n", "library/busybox", "--tag", ""}, "v1\nv2\n"},
+		{[]string{"complete_test", "--repo_name=prj/app", "-t", ""}, "v1\n"},
+		{[]string{"complete_test", "-t", ""}, ""},
+		{[]string{"complete_test", "-j", ""}, "1\n" + strconv.Itoa(srv.Project("prj").ProjectID) + "\n"},
+		{[]string{"complete_test", "-l", ""}, strconv.Itoa(global.ID) + "\n"},
+		{[]string{"complete_test", "-j", strconv.Itoa(srv.Project("prj").ProjectID), "-l", ""},
+			strconv.Itoa(global.ID) + "\n" + strconv.Itoa(private.ID) + "\n"},
+		{[]string{"complete_test", "-u", ""}, "1\n" + strconv.Itoa(dev.UserID) + "\n"},
+		{[]string{"rp_tags", "-n", "prj"}, "prj/app\n"},
+		{[]string{"rp", "tags", "-n", "prj"}, "prj/app\n"},
+		{[]string{"rp", "t"}, "tags\n"},
+		{[]string{"rp_t"}, ""},
+	}
+	for _, tt := range tests {
+		if got := complete(t, tt.args...); got != tt.want {
+			t.Errorf("complete %q: got %q, want %q", tt.args, got, tt.want)
+		}
+	}
+
+	t.Setenv("GO_FLAGS_COMPLETION", "verbose")
+	if got := complete(t, "complete_test", "-n", "prj"); got != "prj/app\t1 tag\n" {
+		t.Errorf("complete with descriptions: got %q", got)
+	}
+}
+
+func TestCompletionCache(t *testing.T) {
+	srv := harbortest.NewServer()
+	defer srv.Close()
+	srv.PushImage("library/busybox", "v1", "sha256:1111")
+
+	dir := t.TempDir()
+	t.Setenv(EnvConfig, filepath.Join(dir, "config.yaml"))
+	t.Setenv(EnvSessionStore, SessionStoreFile)
+	t.Setenv(EnvURL, srv.URL)
+	t.Setenv(EnvUsername, harbortest.AdminUsername)
+	t.Setenv(EnvPassword, harbortest.AdminPassword)
+	t.Setenv("GO_FLAGS_COMPLETION", "1")
+
+	if got := complete(t, "complete_test", "-n", "library/busybox", "-t", ""); got != "v1\n" {
+		t.Fatalf("got %q", got)
+	}
+
+	// served by the cache, even if harbor is gone
+	srv.PushImage("library/busybox", "v2", "sha256:2222")
+	srv.Close()
+	if got := complete(t, "complete_test", "-n", "library/busybox", "-t", ""); got != "v1\n" {
+		t.Errorf("from cache: got %q", got)
+	}
+
+	ttl := completionCacheTTL
+	defer func() { completionCacheTTL = ttl }()
+	completionCacheTTL = time.Nanosecond
+	if got := complete(t, "complete_test", "-n", "library/busybox", "-t", ""); got != "" {
+		t.Errorf("expired cache: got %q", got)
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		var buf bytes.Buffer
+		stdout := Stdout
+		Stdout = &buf
+		_, err := Parser.ParseArgs([]string{"completion", shell})
+		Stdout = stdout
+		if err != nil || !strings.Contains(buf.String(), "GO_FLAGS_COMPLETION=") {
+			t.Errorf("completion %s: %v\n%s", shell, err, buf.String())
+		}
+	}
+	if _, err := Parser.ParseArgs([]string{"completion", "tcsh"}); ExitCode(err) != ExitUsage {
+		t.Errorf("completion tcsh: got error %v", err)
+	}
+}
+
+// TestCompletionShells runs the completion scripts against a fake
+// harbor-go-client, which records the arguments it is given.
+func TestCompletionShells(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "args")
+	fake := "#!/bin/sh\nfor a in \"$@\"; do printf '[%s]' \"$a\"; done >" + out + "\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "harbor-go-client"), []byte(fake), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	// how each shell completes line with the script sourced
+	shells := map[string]func(script, line string) string{
+		"bash": func(script, line string) string {
+			words := strings.Fields(line)
+			if strings.HasSuffix(line, " ") {
+				words = append(words, "")
+			}
+			for i, w := range words {
+				words[i] = shellQuote(w)
+			}
+			return fmt.Sprintf("source %s; COMP_WORDS=(%s); COMP_CWORD=%d; _harbor_go_client",
+				script, strings.Join(words, " "), len(words)-1)
+		},
+		"fish": func(script, line string) string {
+			return "source " + script + "; complete -C " + shellQuote(line)
+		},
+	}
+	tests := []struct {
+		line string
+		want string
+	}{
+		// the empty token being completed is passed too
+		{"harbor-go-client --repo_name ", "[--repo_name][]"},
+		{"harbor-go-client rp ", "[rp][]"},
+		{"harbor-go-client rp t", "[rp][t]"},
+		{"harbor-go-client -n library/b", "[-n][library/b]"},
+	}
+	for shell, command := range shells {
+		path, err := exec.LookPath(shell)
+		if err != nil {
+			t.Logf("%s is not installed", shell)
+			continue
+		}
+		script := filepath.Join(dir, "harbor-go-client."+shell)
+		if err := ioutil.WriteFile(script, []byte(completionScripts[shell]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		for _, tt := range tests {
+			os.Remove(out)
+			cmd := exec.Command(path, "-c", command(script, tt.line))
+			if b, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("%s %q: %v: %s", shell, tt.line, err, b)
+			}
+			got, err := ioutil.ReadFile(out)
+			if err != nil {
+				t.Errorf("%s %q: harbor-go-client is not called: %v", shell, tt.line, err)
+				continue
+			}
+			if string(got) != tt.want {
+				t.Errorf("%s %q: got %s, want %s", shell, tt.line, got, tt.want)
+			}
+		}
+	}
+}
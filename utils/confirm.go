@@ -0,0 +1,420 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+// Stdin is where confirmations are read from, tests replace it.
+var Stdin io.Reader = os.Stdin
+
+var (
+	// ErrAborted is matched by the error of a deletion not confirmed.
+	ErrAborted = errors.New("aborted")
+	// ErrProtected is matched by the error of a deletion refused by the
+	// protection list.
+	ErrProtected = errors.New("protected")
+)
+
+// Confirm holds the options shared by the commands deleting something, it is
+// embedded into their flag structs. Before deleting, the commands describe
+// what is going to be deleted and ask for a confirmation on stdin.
+type Confirm struct {
+	Yes   bool `short:"y" long:"yes" description:"Delete without confirmation, e.g. in scripts."`
+	Force bool `long:"force" description:"Delete even if it is protected by 'protected' of conf/config.yaml, or, for tags on Harbor 1.x, if other tags share its digest."`
+}
+
+// protection is the protection list in conf/config.yaml, which refuses the
+// deletions unless --force is given:
+//
+//	protected:
+//	  repositories:
+//	  - library/*
+//	  labels:
+//	  - protected
+//
+// Repositories are matched by the patterns of path.Match, which protect their
+// tags too (tags can also be matched as repo:tag), and the projects of them.
+// Tags labelled with any of labels are protected, so are their repositories
+// and the labels themselves.
+type protection struct {
+	Repositories []string `yaml:"repositories"`
+	Labels       []string `yaml:"labels"`
+}
+
+// loadProtection returns the protection list, which is empty without
+// conf/config.yaml.
+func loadProtection() (*protection, error) {
+	config, err := generalConfigLoad()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &protection{}, nil
+		}
+		return nil, err
+	}
+	return &config.Protected, nil
+}
+
+// project returns why the project named name is protected, or "".
+func (p *protection) project(name string) string {
+	for _, pattern := range p.Repositories {
+		if ok, _ := path.Match(pattern, name); ok {
+			return "matches " + pattern
+		}
+		if i := strings.Index(pattern, "/"); i > 0 {
+			if ok, _ := path.Match(pattern[:i], name); ok {
+				return "has repositories matching " + pattern
+			}
+		}
+	}
+	return ""
+}
+
+// repository returns why the repository (or repo:tag) named name is
+// protected by the patterns, or "".
+func (p *protection) repository(name string) string {
+	for _, pattern := range p.Repositories {
+		if ok, _ := path.Match(pattern, name); ok {
+			return "matches " + pattern
+		}
+	}
+	return ""
+}
+
+// tag returns why tag of repoName is protected, or "".
+func (p *protection) tag(repoName string, tag *harbor.Tag) string {
+	if why := p.repository(repoName); why != "" {
+		return why
+	}
+	if why := p.repository(repoName + ":" + tag.Name); why != "" {
+		return why
+	}
+	for _, l := range tag.Labels {
+		if p.label(l.Name) != "" {
+			return "labelled " + l.Name
+		}
+	}
+	return ""
+}
+
+// label returns why the label named name is protected, or "".
+func (p *protection) label(name string) string {
+	for _, l := range p.Labels {
+		if l == name {
+			return "protects the tags labelled with it"
+		}
+	}
+	return ""
+}
+
+// deletion describes what is going to be deleted.
+type deletion struct {
+	kind    string // e.g. project
+	name    string
+	details []string
+	updated string
+	why     string // why it is protected, if it is
+}
+
+func (d *deletion) String() string {
+	s := fmt.Sprintf("%s %q", d.kind, d.name)
+	var details []string
+	for _, detail := range d.details {
+		if detail != "" {
+			details = append(details, detail)
+		}
+	}
+	if d.updated != "" {
+		details = append(details, "last updated "+d.updated)
+	}
+	if len(details) > 0 {
+		s += " (" + strings.Join(details, ", ") + ")"
+	}
+	return s
+}
+
+// check refuses d if it is protected without --force, then asks for a
+// confirmation unless --yes is given. Nothing is asked by --dry-run, which
+// deletes nothing anyway.
+func (x *Confirm) check(d *deletion) error {
+	if d.why != "" {
+		if !x.Force {
+			return fmt.Errorf("%s is protected (%s), use --force to delete it anyway: %w", d, d.why, ErrProtected)
+		}
+		fmt.Fprintf(os.Stderr, "WARNING! %s is protected (%s), deleting it by --force.\n", d, d.why)
+	}
+	if x.Yes || Opts.DryRun || Opts.PrintCurl {
+		return nil
+	}
+
+	if ok, err := ask(fmt.Sprintf("About to delete %s.\nAre you sure", d)); err != nil || ok {
+		return err
+	}
+	return fmt.Errorf("deletion of %s is not confirmed, use --yes to skip the confirmation: %w", d, ErrAborted)
+}
+
+// ask asks a yes/no question on stderr, and reads the answer from Stdin. No
+// answer at all, e.g. stdin is not a terminal, is no.
+func ask(question string) (bool, error) {
+	fmt.Fprintf(os.Stderr, "%s? [y/N]: ", question)
+	answer, err := readLine(Stdin)
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	if err == io.EOF {
+		fmt.Fprintln(os.Stderr)
+	}
+	return false, nil
+}
+
+// readLine reads a line from r byte by byte, so that nothing after the line
+// is consumed, e.g. the next answers piped into stdin.
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := r.Read(b)
+		if n == 1 {
+			if b[0] == '\n' {
+				return string(line), nil
+			}
+			line = append(line, b[0])
+		}
+		if err != nil {
+			return string(line), err
+		}
+	}
+}
+
+// Project asks for the confirmation of deleting the project.
+func (x *Confirm) Project(c *harbor.Client, projectID int) error {
+	prj, err := c.GetProject(projectID)
+	if err != nil {
+		return err
+	}
+	repos, err := allRepositories(c, harbor.RepositoryListOptions{ProjectID: projectID})
+	if err != nil {
+		return err
+	}
+	tags := 0
+	for _, r := range repos {
+		tags += r.TagsCount
+	}
+	p, err := loadProtection()
+	if err != nil {
+		return err
+	}
+
+	return x.check(&deletion{
+		kind:    "project",
+		name:    prj.Name,
+		details: []string{plural(prj.RepoCount, "repository", "repositories"), plural(tags, "tag", "tags")},
+		updated: prj.UpdateTime,
+		why:     p.project(prj.Name),
+	})
+}
+
+// Repository asks for the confirmation of deleting the repository with all
+// its tags.
+func (x *Confirm) Repository(c *harbor.Client, repoName string) error {
+	tags, err := c.ListTags(repoName)
+	if err != nil {
+		return err
+	}
+	p, err := loadProtection()
+	if err != nil {
+		return err
+	}
+
+	d := &deletion{
+		kind:    "repository",
+		name:    repoName,
+		details: []string{plural(len(tags), "tag", "tags")},
+		why:     p.repository(repoName),
+	}
+	for _, t := range tags {
+		if t.Created > d.updated {
+			d.updated = t.Created
+		}
+		if why := p.tag(repoName, t); d.why == "" && why != "" {
+			d.why = "tag " + t.Name + " " + why
+		}
+	}
+	return x.check(d)
+}
+
+// Tag asks for the confirmation of deleting the tag, and returns the tags
+// going with it, the tag first, which are to be deleted by DeleteTags. Harbor
+// 1.x deletes the manifest of the tag, so the other tags of its digest go
+// too, and the deletion is refused without --force. Harbor 2.x deletes the
+// tag only.
+func (x *Confirm) Tag(c *harbor.Client, repoName, tag string) ([]*harbor.Tag, error) {
+	t, err := c.GetTag(repoName, tag)
+	if err != nil {
+		return nil, err
+	}
+	p, err := loadProtection()
+	if err != nil {
+		return nil, err
+	}
+
+	group := []*harbor.Tag{t}
+	if c.APIVersion != harbor.API20 {
+		tags, err := c.ListTags(repoName)
+		if err != nil {
+			return nil, err
+		}
+		group = digestGroup(tags, t)
+	}
+	d := digestDeletion(p, repoName, group)
+	if d.why == "" && len(group) > 1 {
+		var shared []string
+		for _, other := range group[1:] {
+			shared = append(shared, other.Name)
+		}
+		d.why = "its digest is shared by " + strings.Join(shared, ", ")
+	}
+	if err := x.check(d); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// digestGroup returns t and the other tags of tags sharing its digest.
+func digestGroup(tags []*harbor.Tag, t *harbor.Tag) []*harbor.Tag {
+	group := []*harbor.Tag{t}
+	if t.Digest == "" {
+		return group
+	}
+	for _, other := range tags {
+		if other.Digest == t.Digest && other.Name != t.Name {
+			group = append(group, other)
+		}
+	}
+	return group
+}
+
+// digestDeletion describes the deletion of tags[0] of repoName, which deletes
+// the others of tags too, as they share its digest. It is protected if any of
+// them is protected by p.
+func digestDeletion(p *protection, repoName string, tags []*harbor.Tag) *deletion {
+	t := tags[0]
+	d := &deletion{
+		kind:    "tag",
+		name:    repoName + ":" + t.Name,
+		details: []string{t.Digest},
+		updated: t.Created,
+		why:     p.tag(repoName, t),
+	}
+	var shared []string
+	for _, other := range tags[1:] {
+		shared = append(shared, other.Name)
+		if why := p.tag(repoName, other); d.why == "" && why != "" {
+			d.why = "tag " + other.Name + " " + why
+		}
+	}
+	if len(shared) > 0 {
+		d.details = append(d.details, "deleting "+strings.Join(shared, ", ")+" of the same digest too")
+	}
+	return d
+}
+
+// DeleteTags deletes tags[0] of repoName, then the others of tags, which
+// share its digest. They are gone already on Harbor 1.x, but Harbor 2.x
+// deletes the tags of an artifact one by one.
+func DeleteTags(c *harbor.Client, repoName string, tags []*harbor.Tag) error {
+	if err := c.DeleteTag(repoName, tags[0].Name); err != nil {
+		return err
+	}
+	for _, t := range tags[1:] {
+		if err := deleteSharedTag(c, repoName, t.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// User asks for the confirmation of deleting the user.
+func (x *Confirm) User(c *harbor.Client, userID int) error {
+	u, err := c.GetUser(userID)
+	if err != nil {
+		return err
+	}
+
+	return x.check(&deletion{
+		kind:    "user",
+		name:    u.Username,
+		details: []string{u.Email},
+		updated: u.UpdateTime,
+	})
+}
+
+// Label asks for the confirmation of deleting the label, which is detached
+// from all the repositories and tags.
+func (x *Confirm) Label(c *harbor.Client, labelID int) error {
+	l, err := c.GetLabel(labelID)
+	if err != nil {
+		return err
+	}
+	p, err := loadProtection()
+	if err != nil {
+		return err
+	}
+
+	scope := "global"
+	if l.Scope == "p" {
+		scope = fmt.Sprintf("project %d", l.ProjectID)
+	}
+	return x.check(&deletion{
+		kind:    "label",
+		name:    l.Name,
+		details: []string{scope},
+		updated: l.UpdateTime,
+		why:     p.label(l.Name),
+	})
+}
+
+// Member asks for the confirmation of removing the member from the project.
+func (x *Confirm) Member(c *harbor.Client, projectID, mid int) error {
+	m, err := c.GetProjectMember(projectID, mid)
+	if err != nil {
+		return err
+	}
+
+	return x.check(&deletion{
+		kind:    "member",
+		name:    m.EntityName,
+		details: []string{fmt.Sprintf("project %d", projectID), m.RoleName},
+	})
+}
+
+// UserGroup asks for the confirmation of deleting the user group.
+func (x *Confirm) UserGroup(c *harbor.Client, groupID int) error {
+	g, err := c.GetUserGroup(groupID)
+	if err != nil {
+		return err
+	}
+
+	return x.check(&deletion{
+		kind:    "user group",
+		name:    g.GroupName,
+		details: []string{g.LdapGroupDN},
+	})
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+// TestConfirmTagV20 makes sure the tags sharing the digest of a tag are not
+// taken as deleted with it by Harbor 2.x, which deletes the tag only.
+func TestConfirmTagV20(t *testing.T) {
+	t.Setenv(EnvConfig, filepath.Join(t.TempDir(), "config.yaml"))
+
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+		switch r.Method + " " + r.URL.EscapedPath() {
+		case "GET /api/v2.0/projects/library/repositories/app/artifacts/v1":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"digest": "sha256:1",
+				"tags":   []map[string]string{{"name": "v1"}, {"name": "latest"}},
+			})
+		case "DELETE /api/v2.0/projects/library/repositories/app/artifacts/v1/tags/v1":
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	c, err := harbor.NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.APIVersion = harbor.API20
+
+	x := &Confirm{Yes: true}
+	tags, err := x.Tag(c, "library/app", "v1")
+	if err != nil {
+		t.Fatalf("got %v, want no refusal as latest stays", err)
+	}
+	if len(tags) != 1 || tags[0].Name != "v1" {
+		t.Fatalf("got tags %+v, want v1 only", tags)
+	}
+
+	requests = nil
+	if err := DeleteTags(c, "library/app", tags); err != nil {
+		t.Fatal(err)
+	}
+	if want := "DELETE /api/v2.0/projects/library/repositories/app/artifacts/v1/tags/v1"; len(requests) != 1 || requests[0] != want {
+		t.Errorf("got requests %v, want %s", requests, want)
+	}
+}
+
+func TestDigestGroup(t *testing.T) {
+	tags := []*harbor.Tag{
+		{Name: "latest", Digest: "sha256:1"},
+		{Name: "v1", Digest: "sha256:1"},
+		{Name: "v2", Digest: "sha256:2"},
+		{Name: "untagged"},
+		{Name: "unknown"},
+	}
+	for _, tt := range []struct {
+		tag  *harbor.Tag
+		want []string
+	}{
+		{tags[1], []string{"v1", "latest"}},
+		{tags[2], []string{"v2"}},
+		{tags[3], []string{"untagged"}},
+	} {
+		var got []string
+		for _, g := range digestGroup(tags, tt.tag) {
+			got = append(got, g.Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.tag.Name, got, tt.want)
+		}
+	}
+}
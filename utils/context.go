@@ -0,0 +1,396 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// contextfile keeps all the server profiles, in a kubeconfig-like style:
+//
+//	current-context: dev
+//	contexts:
+//	- name: dev
+//	  scheme: https
+//	  dstip: harbor.dev.mydomain.com
+//	- name: prod
+//	  scheme: https
+//	  dstip: harbor.mydomain.com
+//
+// It is kept in the per-user directory of configDir, readable by the owner
+// only, as the contexts tell the servers and the client certificates. If no
+// context is defined at all, scheme and dstip in conf/config.yaml are used as
+// before.
+var contextfile = "contexts.yaml"
+
+// contextPath returns the path of contextfile.
+func contextPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, contextfile), nil
+}
+
+var validContextName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Context is a named Harbor server profile.
+type Context struct {
+	Name   string `yaml:"name"`
+	Scheme string `yaml:"scheme"`
+	Dstip  string `yaml:"dstip"`
+
+	// TLS settings, the server's certificate is verified by the system
+	// roots (or CAFile) unless Insecure is set.
+	CAFile     string `yaml:"ca_file,omitempty"`
+	ClientCert string `yaml:"client_cert,omitempty"`
+	ClientKey  string `yaml:"client_key,omitempty"`
+	Insecure   bool   `yaml:"insecure,omitempty"`
+}
+
+// URL returns the root URL of the Harbor server.
+func (c *Context) URL() string {
+	return c.Scheme + "://" + c.Dstip
+}
+
+// sessionKey identifies the beegosessionID of this context in the session
+// store, it never collides with the credentials saved by 'docker login'.
+func (c *Context) sessionKey() string {
+	key := c.URL() + "/harbor-go-client"
+	if c.Name != "" {
+		key += "/" + c.Name
+	}
+	return key
+}
+
+type contextConfig struct {
+	CurrentContext string     `yaml:"current-context"`
+	Contexts       []*Context `yaml:"contexts"`
+}
+
+func (cc *contextConfig) find(name string) (int, *Context) {
+	for i, c := range cc.Contexts {
+		if c.Name == name {
+			return i, c
+		}
+	}
+	return -1, nil
+}
+
+// contextConfigLoad loads all contexts from contextfile. A missing file is
+// the same as an empty one, but the contexts saved next to conf/config.yaml by
+// the older versions are still loaded then, until saved again.
+func contextConfigLoad() (*contextConfig, error) {
+	var cc contextConfig
+
+	path, err := contextPath()
+	if err != nil {
+		return nil, err
+	}
+	dataBytes, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && os.Getenv(EnvConfig) == "" {
+		dataBytes, err = ioutil.ReadFile(confPath(contextfile))
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &cc, nil
+		}
+		return nil, err
+	}
+	err = yaml.Unmarshal(dataBytes, &cc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cc, nil
+}
+
+// contextConfigSave writes all contexts back into contextfile.
+func contextConfigSave(cc *contextConfig) error {
+	c, err := yaml.Marshal(cc)
+	if err != nil {
+		return err
+	}
+
+	path, err := contextPath()
+	if err != nil {
+		return err
+	}
+	return writePrivateFile(path, c)
+}
+
+// CurrentContext returns the harbor service to talk to. From the highest
+// precedence to the lowest, it is decided by:
+//
+//  1. --address and --scheme
+//  2. --context
+//  3. $HARBOR_URL
+//  4. current-context of contextfile
+//  5. scheme and dstip of conf/config.yaml ($HARBOR_CONFIG)
+//
+// Overriding the address of a context also drops its name, so the session of
+// that context is left untouched. TLS settings are overridden by --ca-file,
+// --client-cert, --client-key and --insecure in the same way.
+func CurrentContext() (*Context, error) {
+	c, err := baseContext()
+	if err != nil {
+		if Opts.Address == "" {
+			return nil, err
+		}
+		c = &Context{Scheme: "https"}
+	}
+
+	// never modify the one kept in contextfile
+	copied := *c
+	c = &copied
+
+	if Opts.CAFile != "" {
+		c.CAFile = Opts.CAFile
+	}
+	if Opts.ClientCert != "" {
+		c.ClientCert = Opts.ClientCert
+	}
+	if Opts.ClientKey != "" {
+		c.ClientKey = Opts.ClientKey
+	}
+	if Opts.Insecure {
+		c.Insecure = true
+	}
+	if Opts.Address != "" && Opts.Address != c.Dstip {
+		c.Name = ""
+		c.Dstip = Opts.Address
+	}
+	if Opts.Scheme != "" {
+		c.Scheme = Opts.Scheme
+	}
+
+	if c.Scheme == "" || c.Dstip == "" {
+		return nil, errors.New("the address of harbor service is unknown, set it by --address, $" + EnvURL + " or 'context add'")
+	}
+	return c, nil
+}
+
+// baseContext returns the context before --address and --scheme are applied.
+func baseContext() (*Context, error) {
+	cc, err := contextConfigLoad()
+	if err != nil {
+		return nil, err
+	}
+
+	if Opts.Context != "" {
+		_, c := cc.find(Opts.Context)
+		if c == nil {
+			return nil, fmt.Errorf("context %q not found", Opts.Context)
+		}
+		return c, nil
+	}
+
+	if u := os.Getenv(EnvURL); u != "" {
+		return contextFromURL(u)
+	}
+
+	if cc.CurrentContext != "" {
+		_, c := cc.find(cc.CurrentContext)
+		if c == nil {
+			return nil, fmt.Errorf("context %q not found", cc.CurrentContext)
+		}
+		return c, nil
+	}
+	if len(cc.Contexts) != 0 {
+		return nil, errors.New("no current context, run 'context use' first")
+	}
+
+	// compatible with the single server setting in conf/config.yaml
+	config, err := generalConfigLoad()
+	if err != nil {
+		return nil, err
+	}
+	return &Context{
+		Scheme:     config.Scheme,
+		Dstip:      config.Dstip,
+		CAFile:     config.CAFile,
+		ClientCert: config.ClientCert,
+		ClientKey:  config.ClientKey,
+		Insecure:   config.Insecure,
+	}, nil
+}
+
+// contextFromURL parses an unnamed context from URL like https://harbor.mydomain.com
+func contextFromURL(s string) (*Context, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid $%s: %v", EnvURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid $%s: %q, should be like https://harbor.mydomain.com", EnvURL, s)
+	}
+	return &Context{Scheme: u.Scheme, Dstip: u.Host}, nil
+}
+
+func init() {
+	cmd, _ := Parser.AddCommand("context",
+		"Manage server profiles (contexts).",
+		"Manage named Harbor server profiles, each of which keeps a separate login session.",
+		&struct{}{})
+	cmd.AddCommand("list",
+		"List all contexts.",
+		"List all contexts, the current one is marked with '*'.",
+		&ctxList)
+	cmd.AddCommand("use",
+		"Switch the current context.",
+		"Switch the current context to the one specified by name.",
+		&ctxUse)
+	cmd.AddCommand("add",
+		"Add a new context.",
+		"Add a new context. The first context added becomes the current one.",
+		&ctxAdd)
+	cmd.AddCommand("remove",
+		"Remove a context.",
+		"Remove a context together with its login session.",
+		&ctxRemove)
+	cmd.AddCommand("trust",
+		"Pin the certificate of a context (trust on first use).",
+		"Fetch the certificate of the harbor service without verification, show its fingerprint, and pin it as the CA file of the context once confirmed. By default the top certificate presented in TLS handshake is pinned, with '--from-api' the registry root certificate from /api/systeminfo/getcert is pinned instead.",
+		&ctxTrust)
+}
+
+type contextList struct {
+}
+
+var ctxList contextList
+
+// contextItem is a context as printed by 'context list'.
+type contextItem struct {
+	Current bool   `json:"current"`
+	Name    string `json:"name"`
+	Server  string `json:"server"`
+}
+
+func (x *contextList) Execute(args []string) error {
+	if _, _, err := outputFormat(); err != nil {
+		return err
+	}
+	cc, err := contextConfigLoad()
+	if err != nil {
+		return err
+	}
+
+	items := []*contextItem{}
+	for _, c := range cc.Contexts {
+		items = append(items, &contextItem{Current: c.Name == cc.CurrentContext, Name: c.Name, Server: c.URL()})
+	}
+	return Render(Stdout, items)
+}
+
+type contextUse struct {
+	Args struct {
+		Name string `positional-arg-name:"name" description:"The name of context."`
+	} `positional-args:"yes" required:"yes"`
+}
+
+var ctxUse contextUse
+
+func (x *contextUse) Execute(args []string) error {
+	cc, err := contextConfigLoad()
+	if err != nil {
+		return err
+	}
+
+	if _, c := cc.find(x.Args.Name); c == nil {
+		return fmt.Errorf("context %q not found", x.Args.Name)
+	}
+	cc.CurrentContext = x.Args.Name
+
+	if err := contextConfigSave(cc); err != nil {
+		return err
+	}
+	fmt.Printf("Switched to context %q.\n", x.Args.Name)
+	return nil
+}
+
+type contextAdd struct {
+	Name       string `short:"n" long:"name" description:"(REQUIRED) The name of context." required:"yes"`
+	Scheme     string `short:"s" long:"scheme" description:"The scheme of the harbor service." default:"https" choice:"http" choice:"https"`
+	Dstip      string `short:"a" long:"dstip" description:"(REQUIRED) The address (ip[:port] or hostname) of the harbor service." required:"yes"`
+	CAFile     string `long:"ca-file" description:"The PEM file of CA certificates to verify the harbor service." default:""`
+	ClientCert string `long:"client-cert" description:"The PEM file of client certificate." default:""`
+	ClientKey  string `long:"client-key" description:"The PEM file of client private key." default:""`
+	Insecure   bool   `long:"insecure" description:"Do not verify the certificate of the harbor service."`
+}
+
+var ctxAdd contextAdd
+
+func (x *contextAdd) Execute(args []string) error {
+	if !validContextName.MatchString(x.Name) {
+		return Usagef("context name may only contain letters, digits, '.', '_' and '-'")
+	}
+
+	cc, err := contextConfigLoad()
+	if err != nil {
+		return err
+	}
+
+	if _, c := cc.find(x.Name); c != nil {
+		return fmt.Errorf("context %q already exists", x.Name)
+	}
+	if (x.ClientCert == "") != (x.ClientKey == "") {
+		return Usagef("--client-cert and --client-key must be set together")
+	}
+	cc.Contexts = append(cc.Contexts, &Context{
+		Name:       x.Name,
+		Scheme:     x.Scheme,
+		Dstip:      x.Dstip,
+		CAFile:     x.CAFile,
+		ClientCert: x.ClientCert,
+		ClientKey:  x.ClientKey,
+		Insecure:   x.Insecure,
+	})
+	if cc.CurrentContext == "" {
+		cc.CurrentContext = x.Name
+	}
+
+	if err := contextConfigSave(cc); err != nil {
+		return err
+	}
+	fmt.Printf("Context %q added.\n", x.Name)
+	return nil
+}
+
+type contextRemove struct {
+	Args struct {
+		Name string `positional-arg-name:"name" description:"The name of context."`
+	} `positional-args:"yes" required:"yes"`
+}
+
+var ctxRemove contextRemove
+
+func (x *contextRemove) Execute(args []string) error {
+	cc, err := contextConfigLoad()
+	if err != nil {
+		return err
+	}
+
+	i, c := cc.find(x.Args.Name)
+	if c == nil {
+		return fmt.Errorf("context %q not found", x.Args.Name)
+	}
+	cc.Contexts = append(cc.Contexts[:i], cc.Contexts[i+1:]...)
+	if cc.CurrentContext == c.Name {
+		cc.CurrentContext = ""
+	}
+
+	if err := contextConfigSave(cc); err != nil {
+		return err
+	}
+	if store, err := newSessionStore(); err == nil {
+		store.Erase(c.sessionKey())
+	}
+	fmt.Printf("Context %q removed.\n", x.Args.Name)
+	return nil
+}
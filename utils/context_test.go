@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestContextList(t *testing.T) {
+	t.Setenv(EnvConfig, filepath.Join(t.TempDir(), "config.yaml"))
+	err := contextConfigSave(&contextConfig{
+		CurrentContext: "prod",
+		Contexts: []*Context{
+			{Name: "dev", Scheme: "http", Dstip: "harbor.dev"},
+			{Name: "prod", Scheme: "https", Dstip: "harbor.prod"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := Stdout
+	defer func() { Stdout, Opts = stdout, Options{} }()
+	var buf bytes.Buffer
+	Stdout = &buf
+
+	var got []*contextItem
+	if err := ctxList.Execute(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("%v: %s", err, buf.String())
+	}
+	want := []*contextItem{
+		{Name: "dev", Server: "http://harbor.dev"},
+		{Current: true, Name: "prod", Server: "https://harbor.prod"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	buf.Reset()
+	Opts.Output = "table"
+	if err := ctxList.Execute(nil); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 3 ||
+		strings.Join(strings.Fields(lines[0]), " ") != "CURRENT NAME SERVER" ||
+		strings.Join(strings.Fields(lines[2]), " ") != "true prod https://harbor.prod" {
+		t.Errorf("-o table: got\n%s", buf.String())
+	}
+}
+
+func TestContextTrust(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	t.Setenv(EnvConfig, filepath.Join(t.TempDir(), "config.yaml"))
+	err := contextConfigSave(&contextConfig{
+		Contexts: []*Context{{Name: "dev", Scheme: "https", Dstip: strings.TrimPrefix(srv.URL, "https://")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	caFile := func() string {
+		cc, err := contextConfigLoad()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cc.Contexts[0].CAFile
+	}
+
+	stdin, stdout := Stdin, Stdout
+	defer func() { Stdin, Stdout = stdin, stdout }()
+	Stdout = ioutil.Discard
+
+	// the answers come from Stdin, one line each
+	Stdin = strings.NewReader("n\ny\n")
+	ctxTrust.Args.Name = "dev"
+	if err := ctxTrust.Execute(nil); !errors.Is(err, ErrAborted) {
+		t.Fatalf("declined: got %v, want ErrAborted", err)
+	}
+	if f := caFile(); f != "" {
+		t.Fatalf("declined: got CA file %s", f)
+	}
+	if err := ctxTrust.Execute(nil); err != nil {
+		t.Fatal(err)
+	}
+	f := caFile()
+	if filepath.Base(f) != "dev.crt" {
+		t.Fatalf("confirmed: got CA file %q", f)
+	}
+	if fi, err := os.Stat(f); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("CA file %s: got %v, %v", f, fi.Mode(), err)
+	}
+}
+
+func TestContextFile(t *testing.T) {
+	// the per-user directory, not the current one
+	home, wd := t.TempDir(), t.TempDir()
+	t.Setenv(EnvConfig, "")
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("HOME", home)
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(cwd)
+	if err := os.Chdir(wd); err != nil {
+		t.Fatal(err)
+	}
+
+	// saved by an older version next to conf/config.yaml
+	if err := os.MkdirAll("conf", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join("conf", contextfile), []byte("current-context: old\ncontexts:\n- name: old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cc, err := contextConfigLoad()
+	if err != nil || cc.CurrentContext != "old" {
+		t.Fatalf("old contexts: got %+v, %v", cc, err)
+	}
+
+	cc.CurrentContext = "new"
+	if err := contextConfigSave(cc); err != nil {
+		t.Fatal(err)
+	}
+	path, err := contextPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, _ := os.UserConfigDir()
+	if want := filepath.Join(dir, "harbor-go-client", contextfile); path != want {
+		t.Errorf("path: got %s, want %s", path, want)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("%s: got %v, %v", path, fi.Mode(), err)
+	}
+	if fi, err := os.Stat(filepath.Dir(path)); err != nil || fi.Mode().Perm() != 0700 {
+		t.Errorf("%s: got %v, %v", filepath.Dir(path), fi.Mode(), err)
+	}
+	if cc, err := contextConfigLoad(); err != nil || cc.CurrentContext != "new" {
+		t.Errorf("saved contexts: got %+v, %v", cc, err)
+	}
+}
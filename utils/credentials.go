@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// errCredentialsNotFound is returned by credentialHelper.Get if there is no
+// credentials for the server.
+var errCredentialsNotFound = errors.New("credentials not found")
+
+// credentialHelper talks to a docker credential helper, see
+// https://github.com/docker/docker-credential-helpers for the protocol.
+type credentialHelper struct {
+	program string
+}
+
+func newCredentialHelper(name string) *credentialHelper {
+	return &credentialHelper{program: "docker-credential-" + name}
+}
+
+// helperCredentials is the payload of the credential helper protocol.
+type helperCredentials struct {
+	ServerURL string `json:"ServerURL"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+}
+
+// Get runs "get" for serverURL.
+func (h *credentialHelper) Get(serverURL string) (*helperCredentials, error) {
+	out, err := h.run("get", strings.NewReader(serverURL))
+	if err != nil {
+		return nil, err
+	}
+
+	var cred helperCredentials
+	if err := json.Unmarshal(out, &cred); err != nil {
+		return nil, fmt.Errorf("%s get: %v", h.program, err)
+	}
+	return &cred, nil
+}
+
+// Store runs "store" for cred.
+func (h *credentialHelper) Store(cred *helperCredentials) error {
+	b, err := json.Marshal(cred)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.run("store", bytes.NewReader(b))
+	return err
+}
+
+// Erase runs "erase" for serverURL.
+func (h *credentialHelper) Erase(serverURL string) error {
+	_, err := h.run("erase", strings.NewReader(serverURL))
+	return err
+}
+
+func (h *credentialHelper) run(action string, in io.Reader) ([]byte, error) {
+	cmd := exec.Command(h.program, action)
+	cmd.Stdin = in
+
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if ee, ok := err.(*exec.ExitError); ok && msg == "" {
+			msg = strings.TrimSpace(string(ee.Stderr))
+		}
+		if strings.Contains(msg, "credentials not found") {
+			return nil, errCredentialsNotFound
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("%s %s: %s", h.program, action, msg)
+	}
+	return out, nil
+}
+
+// dockerConfig is the part of ~/.docker/config.json about credentials.
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth string `json:"auth"`
+	} `json:"auths"`
+	CredsStore  string            `json:"credsStore"`
+	CredHelpers map[string]string `json:"credHelpers"`
+}
+
+// dockerConfigLoad loads $DOCKER_CONFIG/config.json (~/.docker/config.json by
+// default), a missing file is the same as an empty one.
+func dockerConfigLoad() (*dockerConfig, error) {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+
+	var dc dockerConfig
+	dataBytes, err := ioutil.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &dc, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(dataBytes, &dc); err != nil {
+		return nil, err
+	}
+	return &dc, nil
+}
+
+// loginCredentials returns the username and password to log in to ctx
+// without asking anyone. They are taken from (in order):
+//
+//  1. $HARBOR_USERNAME and $HARBOR_PASSWORD
+//  2. the credentials saved by 'docker login <dstip>', either by a
+//     credential helper or in the auths of docker's config.json
+func loginCredentials(ctx *Context) (username, password string, err error) {
+	username, password = os.Getenv(EnvUsername), os.Getenv(EnvPassword)
+	if username != "" && password != "" {
+		return username, password, nil
+	}
+
+	dc, err := dockerConfigLoad()
+	if err != nil {
+		return "", "", err
+	}
+
+	helper := dc.CredHelpers[ctx.Dstip]
+	if helper == "" {
+		helper = dc.CredsStore
+	}
+	if helper != "" {
+		cred, err := newCredentialHelper(helper).Get(ctx.Dstip)
+		if err == nil {
+			return cred.Username, cred.Secret, nil
+		}
+		if err != errCredentialsNotFound {
+			return "", "", err
+		}
+	}
+
+	if a, ok := dc.Auths[ctx.Dstip]; ok && a.Auth != "" {
+		b, err := base64.StdEncoding.DecodeString(a.Auth)
+		if err == nil {
+			if i := strings.Index(string(b), ":"); i > 0 {
+				return string(b[:i]), string(b[i+1:]), nil
+			}
+		}
+	}
+
+	return "", "", fmt.Errorf("no credentials for %s, set $%s and $%s or run 'docker login %s'",
+		ctx.Dstip, EnvUsername, EnvPassword, ctx.Dstip)
+}
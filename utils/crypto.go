@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+)
+
+// PassphraseGCM returns the AES-GCM cipher of the 256 bits key derived from
+// passphrase and salt by PBKDF2. It encrypts the session store and the secrets
+// of exports.
+func PassphraseGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, 100000, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// dryRun is the transport of --dry-run and --print-curl: read-only requests
+// are sent by next, while the others are printed into out (with the session
+// and the passwords redacted) and answered with an empty 2xx response without
+// being sent. Logging in is the only exception,
+// which changes nothing in harbor but is needed by the read-only requests.
+type dryRun struct {
+	next     http.RoundTripper
+	out      io.Writer
+	curlOnly bool
+}
+
+// newDryRun returns next wrapped by dryRun if --dry-run or --print-curl is
+// set, or next itself.
+func newDryRun(next http.RoundTripper) http.RoundTripper {
+	if !Opts.DryRun && !Opts.PrintCurl {
+		return next
+	}
+	return &dryRun{next: next, out: Stderr, curlOnly: Opts.PrintCurl}
+}
+
+func (d *dryRun) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !mutating(req) {
+		return d.next.RoundTrip(req)
+	}
+
+	var body []byte
+	if req.Body != nil {
+		data, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = []byte(redactBody(req.Header.Get("Content-Type"), data))
+	}
+
+	header := redactHeader(req.Header)
+	if !d.curlOnly {
+		fmt.Fprintf(d.out, "%s %s\n", req.Method, req.URL)
+		for _, key := range sortedKeys(header) {
+			for _, v := range header[key] {
+				fmt.Fprintf(d.out, "%s: %s\n", key, v)
+			}
+		}
+		if len(body) > 0 {
+			fmt.Fprintf(d.out, "\n%s\n", body)
+		}
+		fmt.Fprintln(d.out)
+	}
+	fmt.Fprintln(d.out, curlCommand(req.Method, req.URL.String(), header, body))
+	if !d.curlOnly {
+		fmt.Fprintln(d.out)
+	}
+
+	status := http.StatusOK
+	if req.Method == http.MethodPost {
+		status = http.StatusCreated
+	}
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode: status,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+		Request:    req,
+	}, nil
+}
+
+// mutating reports whether req may change anything in harbor.
+func mutating(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		return strings.HasSuffix(req.URL.Path, "/log_out")
+	case http.MethodPost:
+		return !strings.HasSuffix(req.URL.Path, "/login")
+	}
+	return true
+}
+
+// curlCommand returns a curl command line sending the same request, in the
+// style of the examples in the doc comments of package harbor (without -k,
+// the certificate is verified).
+func curlCommand(method, url string, header http.Header, body []byte) string {
+	args := []string{"curl", "-X", method}
+	for _, key := range sortedKeys(header) {
+		for _, v := range header[key] {
+			args = append(args, "--header", shellQuote(key+": "+v))
+		}
+	}
+	if len(body) > 0 {
+		args = append(args, "-d", shellQuote(string(body)))
+	}
+	args = append(args, shellQuote(url), "-i")
+	return strings.Join(args, " ")
+}
+
+func sortedKeys(header http.Header) []string {
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// shellQuote quotes s for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMutating(t *testing.T) {
+	tests := []struct {
+		method, path string
+		want         bool
+	}{
+		{"GET", "/api/projects", false},
+		{"HEAD", "/api/projects", false},
+		{"POST", "/login", false},
+		{"POST", "/c/login", false},
+		{"GET", "/log_out", true},
+		{"POST", "/api/projects", true},
+		{"PUT", "/api/projects/1", true},
+		{"DELETE", "/api/projects/1", true},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, "https://localhost"+tt.path, nil)
+		if got := mutating(req); got != tt.want {
+			t.Errorf("mutating(%s %s) = %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCurlCommand(t *testing.T) {
+	header := http.Header{"Content-Type": {"application/json"}}
+	got := curlCommand("PUT", "https://localhost/api/repositories/prj/busybox", header, []byte(`{"description":"it's busybox"}`))
+	want := `curl -X PUT --header 'Content-Type: application/json' -d '{"description":"it'\''s busybox"}' 'https://localhost/api/repositories/prj/busybox' -i`
+	if got != want {
+		t.Errorf("curlCommand:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestDryRunRedacted(t *testing.T) {
+	var out bytes.Buffer
+	d := &dryRun{out: &out}
+	body := `{"old_password":"Old12345","new_password":"New12345"}`
+	req, _ := http.NewRequest("PUT", "https://localhost/api/users/2/password", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Cookie", "beegosessionID=1234")
+	if _, err := d.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"Old12345", "New12345", "1234"} {
+		if strings.Contains(out.String(), secret) {
+			t.Errorf("%q is not redacted in\n%s", secret, out.String())
+		}
+	}
+	if want := `-d '{"new_password":"REDACTED","old_password":"REDACTED"}'`; !strings.Contains(out.String(), want) {
+		t.Errorf("%q not found in\n%s", want, out.String())
+	}
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+// Exit codes of harbor-go-client.
+const (
+	ExitOK           = 0  // success
+	ExitError        = 1  // any other error, e.g. network failure
+	ExitUsage        = 2  // invalid command line, ambiguous name, or 400 Bad Request
+	ExitUnauthorized = 3  // 401 Unauthorized, not logged in or session expired
+	ExitForbidden    = 4  // 403 Forbidden
+	ExitNotFound     = 5  // 404 Not Found
+	ExitConflict     = 6  // 409 Conflict, e.g. the resource already exists
+	ExitServer       = 7  // 5xx, harbor internal error
+	ExitUnsupported  = 8  // not supported by the version of harbor
+	ExitAborted      = 9  // deletion not confirmed, or refused as protected
+	ExitDrifted      = 10 // rp apply refused what has changed since planning
+)
+
+// UsageError reports invalid arguments found by a command itself.
+type UsageError struct {
+	msg string
+}
+
+func (e *UsageError) Error() string {
+	return e.msg
+}
+
+// Usagef returns a *UsageError formatted like fmt.Errorf.
+func Usagef(format string, a ...interface{}) error {
+	return &UsageError{msg: fmt.Sprintf(format, a...)}
+}
+
+// ExitCode maps err (returned by Parse) to the exit code of process.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var flagsErr *flags.Error
+	if errors.As(err, &flagsErr) {
+		if flagsErr.Type == flags.ErrHelp {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+	var usageErr *UsageError
+	if errors.As(err, &usageErr) || errors.Is(err, ErrAmbiguous) {
+		return ExitUsage
+	}
+
+	switch {
+	case errors.Is(err, harbor.ErrBadRequest):
+		return ExitUsage
+	case errors.Is(err, harbor.ErrUnauthorized):
+		return ExitUnauthorized
+	case errors.Is(err, harbor.ErrForbidden):
+		return ExitForbidden
+	case errors.Is(err, harbor.ErrNotFound):
+		return ExitNotFound
+	case errors.Is(err, harbor.ErrConflict):
+		return ExitConflict
+	case errors.Is(err, harbor.ErrServer):
+		return ExitServer
+	case errors.Is(err, harbor.ErrUnsupported):
+		return ExitUnsupported
+	case errors.Is(err, ErrAborted), errors.Is(err, ErrProtected):
+		return ExitAborted
+	case errors.Is(err, ErrDrifted):
+		return ExitDrifted
+	}
+	return ExitError
+}
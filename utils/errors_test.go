@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+func TestExitCode(t *testing.T) {
+	response := func(code int) error {
+		req, _ := http.NewRequest("GET", "https://localhost/api/projects", nil)
+		return &harbor.ErrorResponse{Response: &http.Response{StatusCode: code, Status: http.StatusText(code), Request: req}}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, ExitOK},
+		{"help", &flags.Error{Type: flags.ErrHelp}, ExitOK},
+		{"other", errors.New("connection refused"), ExitError},
+		{"418", response(http.StatusTeapot), ExitError},
+		{"required flag", &flags.Error{Type: flags.ErrRequired}, ExitUsage},
+		{"usage", Usagef("--limit must not be negative"), ExitUsage},
+		{"ambiguous", ErrAmbiguous, ExitUsage},
+		{"400", response(http.StatusBadRequest), ExitUsage},
+		{"401", response(http.StatusUnauthorized), ExitUnauthorized},
+		{"403", response(http.StatusForbidden), ExitForbidden},
+		{"404", response(http.StatusNotFound), ExitNotFound},
+		{"409", response(http.StatusConflict), ExitConflict},
+		{"500", response(http.StatusInternalServerError), ExitServer},
+		{"503", response(http.StatusServiceUnavailable), ExitServer},
+		{"unsupported", &harbor.UnsupportedError{Feature: "labels of repositories", APIVersion: harbor.API20}, ExitUnsupported},
+		{"aborted", ErrAborted, ExitAborted},
+		{"protected", ErrProtected, ExitAborted},
+		{"drifted", ErrDrifted, ExitDrifted},
+	}
+	for _, tt := range tests {
+		if got := ExitCode(tt.err); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, got, tt.want)
+		}
+		if tt.err == nil {
+			continue
+		}
+		// the commands wrap the errors, once or more
+		wrapped := fmt.Errorf("deleting library/busybox: %w", tt.err)
+		if got := ExitCode(wrapped); got != tt.want {
+			t.Errorf("%s wrapped: got %d, want %d", tt.name, got, tt.want)
+		}
+		if got := ExitCode(fmt.Errorf("rp apply: %w", wrapped)); got != tt.want {
+			t.Errorf("%s wrapped twice: got %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
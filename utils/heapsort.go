@@ -3,6 +3,8 @@ package utils
 import (
 	"container/heap"
 	"fmt"
+
+	"github.com/moooofly/harbor-go-client/harbor"
 )
 
 type tagItem struct {
@@ -35,13 +37,10 @@ func (h *tagminheap) Pop() interface{} {
 	return it
 }
 
-// Sort on UNIX timestamp of tags
-var tagmh tagminheap
-
 // -------------
 
 type repoItem struct {
-	data  *repoTop
+	data  *harbor.Repository
 	score float32
 }
 
@@ -70,15 +69,11 @@ func (h *repominheap) Pop() interface{} {
 	return it
 }
 
-// Sort on the score of repos
-var minh = repominheap{}
-var mhBk = repominheap{}
-
 func example1() {
 
 	h := repominheap{
 		&repoItem{
-			data: &repoTop{
+			data: &harbor.Repository{
 				ID:           31,
 				Name:         "prj2/photon",
 				ProjectID:    3,
@@ -92,7 +87,7 @@ func example1() {
 			score: 0.830000,
 		},
 		&repoItem{
-			data: &repoTop{
+			data: &harbor.Repository{
 				ID:           30,
 				Name:         "sf3prj/hello-world",
 				ProjectID:    13,
@@ -106,7 +101,7 @@ func example1() {
 			score: 0.780000,
 		},
 		&repoItem{
-			data: &repoTop{
+			data: &harbor.Repository{
 				ID:           29,
 				Name:         "prj5/hello-world",
 				ProjectID:    6,
@@ -120,7 +115,7 @@ func example1() {
 			score: 0.830000,
 		},
 		&repoItem{
-			data: &repoTop{
+			data: &harbor.Repository{
 				ID:           4,
 				Name:         "prj3/hello-world",
 				ProjectID:    4,
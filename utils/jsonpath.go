@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonpath evaluates a kubectl-like JSONPath template against data decoded
+// from JSON. Only a subset is supported, which is enough for scripting:
+//
+//	{.name}                    field of an object
+//	{.tags[0].name}            element of an array
+//	{[*].name}, {.items[*].id} all the elements of an array
+//
+// Text outside of braces is printed as is, and multiple results of one
+// expression are separated by spaces.
+func jsonpath(tmpl string, data interface{}) (string, error) {
+	var out strings.Builder
+
+	for {
+		start := strings.Index(tmpl, "{")
+		if start < 0 {
+			out.WriteString(tmpl)
+			break
+		}
+		end := strings.Index(tmpl[start:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("jsonpath: unclosed '{' in %q", tmpl)
+		}
+		end += start
+
+		out.WriteString(tmpl[:start])
+
+		results, err := jsonpathEval(tmpl[start+1:end], data)
+		if err != nil {
+			return "", err
+		}
+		for i, r := range results {
+			if i > 0 {
+				out.WriteString(" ")
+			}
+			out.WriteString(jsonpathText(r))
+		}
+
+		tmpl = tmpl[end+1:]
+	}
+
+	return out.String(), nil
+}
+
+// jsonpathEval evaluates one expression (without braces).
+func jsonpathEval(expr string, data interface{}) ([]interface{}, error) {
+	expr = strings.TrimSpace(expr)
+	expr = strings.TrimPrefix(expr, "$")
+
+	nodes := []interface{}{data}
+	for expr != "" {
+		var step string
+
+		switch expr[0] {
+		case '.':
+			expr = expr[1:]
+			n := strings.IndexAny(expr, ".[")
+			if n < 0 {
+				n = len(expr)
+			}
+			step, expr = expr[:n], expr[n:]
+			if step == "" {
+				// e.g. {.[*].name}
+				continue
+			}
+			nodes = jsonpathField(nodes, step)
+		case '[':
+			n := strings.Index(expr, "]")
+			if n < 0 {
+				return nil, fmt.Errorf("jsonpath: unclosed '[' in %q", expr)
+			}
+			step, expr = expr[1:n], expr[n+1:]
+			var err error
+			if nodes, err = jsonpathIndex(nodes, step); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("jsonpath: unexpected %q", expr)
+		}
+	}
+
+	return nodes, nil
+}
+
+func jsonpathField(nodes []interface{}, name string) []interface{} {
+	var res []interface{}
+	for _, n := range nodes {
+		if m, ok := n.(map[string]interface{}); ok {
+			if v, ok := m[name]; ok {
+				res = append(res, v)
+			}
+		}
+	}
+	return res
+}
+
+func jsonpathIndex(nodes []interface{}, index string) ([]interface{}, error) {
+	var res []interface{}
+	for _, n := range nodes {
+		a, ok := n.([]interface{})
+		if !ok {
+			continue
+		}
+		if index == "*" {
+			res = append(res, a...)
+			continue
+		}
+
+		i, err := strconv.Atoi(index)
+		if err != nil {
+			return nil, fmt.Errorf("jsonpath: invalid index %q", index)
+		}
+		if i < 0 {
+			i += len(a)
+		}
+		if i >= 0 && i < len(a) {
+			res = append(res, a[i])
+		}
+	}
+	return res, nil
+}
+
+func jsonpathText(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(v)
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
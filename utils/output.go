@@ -0,0 +1,335 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Output formats supported by -o.
+const (
+	OutputJSON       = "json"
+	OutputYAML       = "yaml"
+	OutputTable      = "table"
+	OutputJSONPath   = "jsonpath"
+	OutputGoTemplate = "go-template"
+)
+
+var outputFormats = []string{OutputJSON, OutputYAML, OutputTable, OutputJSONPath, OutputGoTemplate}
+
+// outputFormat splits -o into the format and its template.
+func outputFormat() (format, arg string, err error) {
+	return parseOutputFormat(Opts.Output)
+}
+
+// isOutputFormat tells if s is a valid value of -o, e.g. table.
+func isOutputFormat(s string) bool {
+	_, _, err := parseOutputFormat(s)
+	return s != "" && err == nil
+}
+
+// parseOutputFormat splits s, a value of -o, into the format and its template.
+func parseOutputFormat(s string) (format, arg string, err error) {
+	format = s
+	if i := strings.Index(format, "="); i >= 0 {
+		format, arg = format[:i], format[i+1:]
+	}
+
+	switch format {
+	case "", OutputJSON, OutputYAML, OutputTable:
+	case OutputJSONPath, OutputGoTemplate:
+		if arg == "" {
+			return "", "", Usagef("missing template, use -o %s=<template>", format)
+		}
+	default:
+		return "", "", Usagef("unknown output format %q, valid formats are: %s",
+			format, strings.Join(outputFormats, ", "))
+	}
+	return format, arg, nil
+}
+
+// Render writes v to w in the format given by -o (and --columns for table).
+//
+// Strings and []byte (e.g. job logs) are always written as they are.
+func Render(w io.Writer, v interface{}) error {
+	format, arg, err := outputFormat()
+	if err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return writeText(w, v)
+	case []byte:
+		return writeText(w, string(v))
+	}
+	// an empty list is often a nil slice, which is [] rather than null
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice && rv.IsNil() {
+		v = reflect.MakeSlice(rv.Type(), 0, 0).Interface()
+	}
+
+	switch format {
+	case "", OutputJSON:
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", b)
+		return err
+	case OutputYAML:
+		data, err := toGeneric(v)
+		if err != nil {
+			return err
+		}
+		b, err := yaml.Marshal(data)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	case OutputTable:
+		return renderTable(w, v)
+	case OutputJSONPath:
+		data, err := toGeneric(v)
+		if err != nil {
+			return err
+		}
+		s, err := jsonpath(arg, data)
+		if err != nil {
+			return err
+		}
+		return writeText(w, s)
+	default:
+		t, err := template.New("output").Parse(arg)
+		if err != nil {
+			return err
+		}
+		data, err := toGeneric(v)
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, data); err != nil {
+			return err
+		}
+		return writeText(w, buf.String())
+	}
+}
+
+func writeText(w io.Writer, s string) error {
+	if !strings.HasSuffix(s, "\n") {
+		s += "\n"
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+// toGeneric converts v into what encoding/json decodes into an interface{},
+// so that all the formats use the same (JSON) field names. Numbers are kept
+// as int64 whenever possible.
+func toGeneric(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+
+	var data interface{}
+	if err := d.Decode(&data); err != nil {
+		return nil, err
+	}
+	return normalizeNumbers(data), nil
+}
+
+func normalizeNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = normalizeNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = normalizeNumbers(e)
+		}
+	}
+	return v
+}
+
+// renderTable writes a list of objects (or a single object) as an aligned
+// table, one column per field. Columns are chosen by --columns, otherwise all
+// the scalar fields are shown in the order of the struct definition.
+func renderTable(w io.Writer, v interface{}) error {
+	data, err := toGeneric(v)
+	if err != nil {
+		return err
+	}
+
+	var rows []map[string]interface{}
+	if t := reflect.TypeOf(v); t.Kind() == reflect.Map {
+		// one row per key, e.g. metadata of project and system configurations
+		return renderMapTable(w, t, data.(map[string]interface{}))
+	}
+
+	switch data := data.(type) {
+	case []interface{}:
+		for _, e := range data {
+			m, ok := e.(map[string]interface{})
+			if !ok {
+				m = map[string]interface{}{"value": e}
+			}
+			rows = append(rows, m)
+		}
+	case map[string]interface{}:
+		rows = append(rows, data)
+	default:
+		return writeText(w, jsonpathText(data))
+	}
+
+	return writeTable(w, tableColumns(v, rows), rows)
+}
+
+func renderMapTable(w io.Writer, t reflect.Type, data map[string]interface{}) error {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var rows []map[string]interface{}
+	for _, k := range keys {
+		row, ok := data[k].(map[string]interface{})
+		if !ok {
+			row = map[string]interface{}{"value": data[k]}
+		}
+		row["name"] = k
+		rows = append(rows, row)
+	}
+
+	columns := tableColumns(reflect.Zero(t.Elem()).Interface(), rows)
+	if Opts.Columns == "" {
+		named := []string{"name"}
+		for _, c := range columns {
+			if c != "name" {
+				named = append(named, c)
+			}
+		}
+		columns = named
+	}
+	return writeTable(w, columns, rows)
+}
+
+func writeTable(w io.Writer, columns []string, rows []map[string]interface{}) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(columns, "\t")))
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, c := range columns {
+			cells[i] = jsonpathText(row[c])
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+func tableColumns(v interface{}, rows []map[string]interface{}) []string {
+	if Opts.Columns != "" {
+		var columns []string
+		for _, c := range strings.Split(Opts.Columns, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				columns = append(columns, c)
+			}
+		}
+		return columns
+	}
+
+	if columns := structColumns(reflect.TypeOf(v)); len(columns) != 0 {
+		return columns
+	}
+
+	// maps have no field order, sort the keys of scalar values instead
+	seen := make(map[string]bool)
+	var columns []string
+	for _, row := range rows {
+		for k, e := range row {
+			switch e.(type) {
+			case map[string]interface{}, []interface{}:
+				continue
+			}
+			if !seen[k] {
+				seen[k] = true
+				columns = append(columns, k)
+			}
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// structColumns returns the JSON names of the scalar fields of t, which may be
+// a struct, or a pointer/slice of it.
+func structColumns(t reflect.Type) []string {
+	if t == nil {
+		return nil
+	}
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var columns []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Implements(jsonMarshaler) || reflect.PtrTo(ft).Implements(jsonMarshaler) {
+			// e.g. time.Time, which is a string in JSON
+			columns = append(columns, jsonName(f))
+			continue
+		}
+		switch ft.Kind() {
+		case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+			continue
+		}
+
+		if name := jsonName(f); name != "-" {
+			columns = append(columns, name)
+		}
+	}
+	return columns
+}
+
+var jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+func jsonName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("json"), ",")[0]
+	if name == "" {
+		name = f.Name
+	}
+	return name
+}
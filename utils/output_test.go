@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+)
+
+type outputItem struct {
+	ID     int               `json:"id"`
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+func TestRender(t *testing.T) {
+	defer func() { Opts = Options{} }()
+
+	items := []*outputItem{
+		{ID: 1, Name: "library", Labels: map[string]string{"env": "prod"}},
+		{ID: 2, Name: "dev"},
+	}
+	tests := []struct {
+		output string
+		v      interface{}
+		want   string
+	}{
+		{"json", []*outputItem{}, "[]\n"},
+		{"json", []*outputItem(nil), "[]\n"},
+		{"yaml", []*outputItem(nil), "[]\n"},
+		{"table", items, "ID  NAME\n1   library\n2   dev\n"},
+		{"table", items[0], "ID  NAME\n1   library\n"},
+		{"table", []*outputItem{}, "ID  NAME\n"},
+		{"table", []*outputItem(nil), "ID  NAME\n"},
+		{"table", []string{"v1", "v2"}, "VALUE\nv1\nv2\n"},
+		{"table", "job log", "job log\n"},
+		{"jsonpath={.name}", items[0], "library\n"},
+		{"jsonpath={[*].name}", items, "library dev\n"},
+		{"jsonpath={[-1].id}", items, "2\n"},
+		{"jsonpath={[0].labels}", items, "{\"env\":\"prod\"}\n"},
+		{"jsonpath=name: {[5].name}", items, "name: \n"},
+		{"jsonpath={[*].name}", []*outputItem{}, "\n"},
+		{"jsonpath={[*].name}", []*outputItem(nil), "\n"},
+		{"jsonpath={.name}", []*outputItem{}, "\n"},
+		{"go-template={{range .}}{{.id}}={{.name}} {{end}}", items, "1=library 2=dev \n"},
+		{"go-template={{.labels.env}}", items[0], "prod\n"},
+		{"go-template={{len .}}", []*outputItem{}, "0\n"},
+		{"go-template={{len .}}", []*outputItem(nil), "0\n"},
+		{"go-template={{range .}}{{.name}}{{else}}none{{end}}", []*outputItem{}, "none\n"},
+	}
+	for _, tt := range tests {
+		Opts.Output = tt.output
+		var buf bytes.Buffer
+		if err := Render(&buf, tt.v); err != nil {
+			t.Errorf("-o %s %+v: %v", tt.output, tt.v, err)
+			continue
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("-o %s %+v: got %q, want %q", tt.output, tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestRenderError(t *testing.T) {
+	defer func() { Opts = Options{} }()
+
+	items := []*outputItem{{ID: 1, Name: "library"}}
+	tests := []struct {
+		output string
+		usage  bool // the error is a UsageError
+	}{
+		{"xml", true},
+		{"jsonpath", true},
+		{"jsonpath=", true},
+		{"go-template", true},
+		{"go-template=", true},
+		{"jsonpath={[*].name", false},
+		{"jsonpath={[0.name}", false},
+		{"jsonpath={[x].name}", false},
+		{"jsonpath={name}", false},
+		{"go-template={{.name", false},
+		{"go-template={{range .}}", false},
+		{"go-template={{.name | nosuchfunc}}", false},
+		{"go-template={{index . 5}}", false},
+		{"go-template={{range .}}{{.name.first}}{{end}}", false},
+	}
+	for _, tt := range tests {
+		Opts.Output = tt.output
+		var buf bytes.Buffer
+		err := Render(&buf, items)
+		if err == nil {
+			t.Errorf("-o %s: got %q, want an error", tt.output, buf.String())
+			continue
+		}
+		if usage := ExitCode(err) == ExitUsage; usage != tt.usage {
+			t.Errorf("-o %s: got %v (usage error: %v), want usage error: %v", tt.output, err, usage, tt.usage)
+		}
+		// nothing is printed by half
+		if buf.Len() != 0 {
+			t.Errorf("-o %s: got %q printed with %v", tt.output, buf.String(), err)
+		}
+	}
+}
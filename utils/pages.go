@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Pages holds the options shared by list commands to fetch more than one
+// page, it is embedded into their flag structs.
+type Pages struct {
+	All   bool `long:"all" description:"Fetch all the pages, 100 items per request, following the Link and X-Total-Count headers of harbor."`
+	Limit int  `long:"limit" description:"Fetch at most N items in total, implies --all."`
+}
+
+// Enabled reports whether --all or --limit is given.
+func (p *Pages) Enabled() bool {
+	return p.All || p.Limit > 0
+}
+
+// RunPages is the same as Run, but prints every item of the iterator returned
+// by fn (at most --limit of them). With -o json or yaml, items are printed as
+// soon as their page arrives, other formats need all of them at first.
+func RunPages(p *Pages, fn func(c *harbor.Client) harbor.Iterator) error {
+	format, _, err := outputFormat()
+	if err != nil {
+		return err
+	}
+	if p.Limit < 0 {
+		return Usagef("--limit must not be negative")
+	}
+
+	c, err := NewClient()
+	if err != nil {
+		return err
+	}
+	it := fn(c)
+
+	switch format {
+	case "", OutputJSON:
+		return streamJSON(Stdout, it, p.Limit)
+	case OutputYAML:
+		return streamYAML(Stdout, it, p.Limit)
+	}
+
+	var items reflect.Value
+	for n := 0; (p.Limit == 0 || n < p.Limit) && it.Next(); n++ {
+		v := reflect.ValueOf(it.Item())
+		if !items.IsValid() {
+			// keep the item type, so that table knows the columns
+			items = reflect.MakeSlice(reflect.SliceOf(v.Type()), 0, 0)
+		}
+		items = reflect.Append(items, v)
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	if !items.IsValid() {
+		return Render(Stdout, []interface{}{})
+	}
+	return Render(Stdout, items.Interface())
+}
+
+// streamJSON writes the items as a JSON array, exactly as Render does. The
+// array is closed even if the iteration fails halfway, but nothing is written
+// if the first page fails.
+func streamJSON(w io.Writer, it harbor.Iterator, limit int) error {
+	n := 0
+	for ; (limit == 0 || n < limit) && it.Next(); n++ {
+		b, err := json.MarshalIndent(it.Item(), "  ", "  ")
+		if err != nil {
+			return err
+		}
+		sep := ",\n  "
+		if n == 0 {
+			// nothing is printed until the first page arrives
+			sep = "[\n  "
+		}
+		if _, err := fmt.Fprintf(w, "%s%s", sep, b); err != nil {
+			return err
+		}
+	}
+
+	end := "\n]\n"
+	if n == 0 {
+		if err := it.Err(); err != nil {
+			return err
+		}
+		end = "[]\n"
+	}
+	if _, err := io.WriteString(w, end); err != nil {
+		return err
+	}
+	return it.Err()
+}
+
+// streamYAML writes the items as a YAML sequence, one item at a time.
+func streamYAML(w io.Writer, it harbor.Iterator, limit int) error {
+	n := 0
+	for ; (limit == 0 || n < limit) && it.Next(); n++ {
+		data, err := toGeneric(it.Item())
+		if err != nil {
+			return err
+		}
+		b, err := yaml.Marshal([]interface{}{data})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+
+	if n == 0 {
+		if err := it.Err(); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "[]\n"); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
+// allRepositories lists the repositories of opt page by page, as harbor
+// returns at most harbor.MaxPageSize of them at a time.
+func allRepositories(c *harbor.Client, opt harbor.RepositoryListOptions) ([]*harbor.Repository, error) {
+	var all []*harbor.Repository
+	opt.PageSize = harbor.MaxPageSize
+	for opt.Page = 1; ; opt.Page++ {
+		repos, err := c.ListRepositories(&opt)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, repos...)
+		if len(repos) < harbor.MaxPageSize {
+			return all, nil
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+// ErrAmbiguous is matched by the error of a name matching more than one
+// resource, which has to be given by its ID instead.
+var ErrAmbiguous = errors.New("ambiguous")
+
+// The commands taking the ID of something accept its name as well, e.g.
+// --project library instead of --project_id 1, which is resolved into the ID
+// through the list (or search) endpoints. Names are matched exactly, while
+// the endpoints match them partially.
+
+// match is a resource named as asked, described for the ambiguity error.
+type match struct {
+	id   int
+	desc string
+}
+
+// resolved returns the ID of the only one in matches, or an error.
+func resolved(kind, name string, matches []match) (int, error) {
+	switch len(matches) {
+	case 0:
+		return 0, fmt.Errorf("%s %q: %w", kind, name, harbor.ErrNotFound)
+	case 1:
+		return matches[0].id, nil
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].id < matches[j].id })
+	var s []string
+	for _, m := range matches {
+		d := "ID " + strconv.Itoa(m.id)
+		if m.desc != "" {
+			d += " (" + m.desc + ")"
+		}
+		s = append(s, d)
+	}
+	return 0, fmt.Errorf("%s %q is ambiguous, it may be %s, give the ID instead: %w",
+		kind, name, strings.Join(s, " or "), ErrAmbiguous)
+}
+
+// idOrName checks that exactly one of id and name is given.
+func idOrName(kind string, id int, name string) error {
+	switch {
+	case id == 0 && name == "":
+		return Usagef("either the ID or the name of the %s is required", kind)
+	case id != 0 && name != "":
+		return Usagef("the ID and the name of the %s are mutually exclusive", kind)
+	}
+	return nil
+}
+
+// ProjectID returns id, or the ID of the project named name if id is 0.
+func ProjectID(c *harbor.Client, id int, name string) (int, error) {
+	if err := idOrName("project", id, name); err != nil || id != 0 {
+		return id, err
+	}
+
+	var matches []match
+	it := c.IterProjects(&harbor.ProjectListOptions{Name: name})
+	for it.Next() {
+		if p := it.Project(); p.Name == name {
+			matches = append(matches, match{id: p.ProjectID})
+		}
+	}
+	if err := it.Err(); err != nil {
+		return 0, err
+	}
+	return resolved("project", name, matches)
+}
+
+// LabelID returns id, or the ID of the label named name if id is 0. Global
+// labels are looked up, so are the project labels of the project projectID,
+// or of all the projects if projectID is 0.
+func LabelID(c *harbor.Client, id int, name string, projectID int) (int, error) {
+	if err := idOrName("label", id, name); err != nil || id != 0 {
+		return id, err
+	}
+
+	projects := map[int]string{}
+	if projectID != 0 {
+		projects[projectID] = strconv.Itoa(projectID)
+	} else {
+		it := c.IterProjects(nil)
+		for it.Next() {
+			projects[it.Project().ProjectID] = it.Project().Name
+		}
+		if err := it.Err(); err != nil {
+			return 0, err
+		}
+	}
+
+	scopes := []*harbor.LabelListOptions{{Name: name, Scope: "g"}}
+	for pid := range projects {
+		scopes = append(scopes, &harbor.LabelListOptions{Name: name, Scope: "p", ProjectID: pid})
+	}
+	var matches []match
+	for _, opt := range scopes {
+		it := c.IterLabels(opt)
+		for it.Next() {
+			l := it.Label()
+			if l.Name != name {
+				continue
+			}
+			desc := "global"
+			if l.Scope == "p" {
+				desc = "project " + projects[l.ProjectID]
+			}
+			matches = append(matches, match{id: l.ID, desc: desc})
+		}
+		if err := it.Err(); err != nil {
+			return 0, err
+		}
+	}
+	return resolved("label", name, matches)
+}
+
+// TargetID returns id, or the ID of the replication target named name if id
+// is 0.
+func TargetID(c *harbor.Client, id int, name string) (int, error) {
+	if err := idOrName("target", id, name); err != nil || id != 0 {
+		return id, err
+	}
+
+	targets, err := c.ListTargets(name)
+	if err != nil {
+		return 0, err
+	}
+	var matches []match
+	for _, t := range targets {
+		if t.Name == name {
+			matches = append(matches, match{id: t.ID, desc: t.Endpoint})
+		}
+	}
+	return resolved("target", name, matches)
+}
+
+// UserID returns id, or the ID of the user named name if id is 0.
+func UserID(c *harbor.Client, id int, name string) (int, error) {
+	if err := idOrName("user", id, name); err != nil || id != 0 {
+		return id, err
+	}
+
+	var matches []match
+	it := c.IterUsers(&harbor.UserSearchOptions{Username: name})
+	for it.Next() {
+		if u := it.User(); u.Username == name {
+			matches = append(matches, match{id: u.UserID, desc: u.Email})
+		}
+	}
+	if err := it.Err(); err != nil {
+		return 0, err
+	}
+	return resolved("user", name, matches)
+}
+
+// MemberID returns id, or the ID of the member named name (a user or a
+// group) of the project projectID if id is 0.
+func MemberID(c *harbor.Client, projectID, id int, name string) (int, error) {
+	if err := idOrName("member", id, name); err != nil || id != 0 {
+		return id, err
+	}
+
+	members, err := c.ListProjectMembers(projectID, name)
+	if err != nil {
+		return 0, err
+	}
+	var matches []match
+	for _, m := range members {
+		if m.EntityName == name {
+			matches = append(matches, match{id: m.ID, desc: m.EntityType})
+		}
+	}
+	return resolved("member", name, matches)
+}
+
+// UserGroupID returns id, or the ID of the user group named name if id is 0.
+func UserGroupID(c *harbor.Client, id int, name string) (int, error) {
+	if err := idOrName("user group", id, name); err != nil || id != 0 {
+		return id, err
+	}
+
+	groups, err := c.ListUserGroups()
+	if err != nil {
+		return 0, err
+	}
+	var matches []match
+	for _, g := range groups {
+		if g.GroupName == name {
+			matches = append(matches, match{id: g.ID, desc: g.LdapGroupDN})
+		}
+	}
+	return resolved("user group", name, matches)
+}
+
+// PolicyID returns id, or the ID of the replication policy named name if id
+// is 0. Policies of different projects may have the same name, projectID
+// narrows them down if it is not 0.
+func PolicyID(c *harbor.Client, id int, name string, projectID int) (int, error) {
+	if err := idOrName("policy", id, name); err != nil || id != 0 {
+		return id, err
+	}
+
+	var matches []match
+	for page := 1; ; page++ {
+		policies, err := c.ListReplicationPolicies(&harbor.ReplicationPolicyListOptions{
+			Name:      name,
+			ProjectID: projectID,
+			Page:      page,
+			PageSize:  harbor.MaxPageSize,
+		})
+		if err != nil {
+			return 0, err
+		}
+		for _, p := range policies {
+			if p.Name != name {
+				continue
+			}
+			var projects []string
+			for _, prj := range p.Projects {
+				projects = append(projects, prj.Name)
+			}
+			matches = append(matches, match{id: p.ID, desc: "project " + strings.Join(projects, ", ")})
+		}
+		if len(policies) < harbor.MaxPageSize {
+			break
+		}
+	}
+	return resolved("policy", name, matches)
+}
@@ -0,0 +1,480 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func init() {
+	AddCommand("rp plan", "rp_plan",
+		"Write the deletions of retention policies into a plan.",
+		"Run retention policy analysis on tags by --rules (or --day and --max), and on repositories by --delete-count, and write what to delete into a plan file to review, which 'rp apply' deletes.",
+		&planRP)
+	AddCommand("rp apply", "rp_apply",
+		"Delete what a plan lists, unless changed since planning.",
+		"Delete the tags and repositories listed by a plan of 'rp plan', re-checking each of them first: the ones pushed, pulled or retagged since planning are not deleted, but reported as drifted.",
+		&applyRP)
+}
+
+// rpPlanVersion is the version of the plan format, bumped on incompatible
+// changes, which rp_apply refuses.
+const rpPlanVersion = 2
+
+// rpPlan is a plan file of rp_plan.
+type rpPlan struct {
+	Version   int    `json:"version" yaml:"version"`
+	CreatedAt string `json:"created_at" yaml:"created_at"`
+	// PolicyHash is the SHA-256 of Policy in JSON, which tells the plans of
+	// the same policy, and which rp_apply checks.
+	PolicyHash string      `json:"policy_hash" yaml:"policy_hash"`
+	Policy     *planPolicy `json:"policy" yaml:"policy"`
+	Items      []*planItem `json:"items" yaml:"items"`
+}
+
+// planPolicy is what a plan is made by.
+type planPolicy struct {
+	Tags  *tagsPlanPolicy  `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Repos *reposPlanPolicy `json:"repos,omitempty" yaml:"repos,omitempty"`
+}
+
+type tagsPlanPolicy struct {
+	RepoName string     `json:"repo_name,omitempty" yaml:"repo_name,omitempty"`
+	Rules    []*tagRule `json:"rules" yaml:"rules"`
+}
+
+type reposPlanPolicy struct {
+	Projects    []string         `json:"projects,omitempty" yaml:"projects,omitempty"`
+	DeleteCount int              `json:"delete_count" yaml:"delete_count"`
+	MinScore    float32          `json:"min_score,omitempty" yaml:"min_score,omitempty"`
+	Score       *retentionPolicy `json:"score" yaml:"score"`
+}
+
+// hash returns the SHA-256 of p in JSON.
+func (p *planPolicy) hash() (string, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// planItem is a repository or a tag to delete, with its state when planned,
+// which rp_apply compares with the current one.
+type planItem struct {
+	Kind       string `json:"kind" yaml:"kind"` // repository or tag
+	Repository string `json:"repository" yaml:"repository"`
+	ProjectID  int    `json:"project_id,omitempty" yaml:"project_id,omitempty"`
+	Tag        string `json:"tag,omitempty" yaml:"tag,omitempty"`
+	Digest     string `json:"digest,omitempty" yaml:"digest,omitempty"`
+	Reason     string `json:"reason" yaml:"reason"`
+
+	UpdateTime string `json:"update_time,omitempty" yaml:"update_time,omitempty"`
+	Created    string `json:"created,omitempty" yaml:"created,omitempty"`
+	PullCount  int    `json:"pull_count,omitempty" yaml:"pull_count,omitempty"`
+	TagsCount  int    `json:"tags_count,omitempty" yaml:"tags_count,omitempty"`
+	// PushTime and PullTime of tags are empty before Harbor 1.9.
+	PushTime string `json:"push_time,omitempty" yaml:"push_time,omitempty"`
+	PullTime string `json:"pull_time,omitempty" yaml:"pull_time,omitempty"`
+	// SharedWith are the other tags of Digest, deleted with the tag.
+	SharedWith []string `json:"shared_with,omitempty" yaml:"shared_with,omitempty"`
+
+	// Action is set by rp_apply: deleted, drifted, skipped or failed, or
+	// delete by --dry-run.
+	Action string `json:"action,omitempty" yaml:"action,omitempty"`
+	Drift  string `json:"drift,omitempty" yaml:"drift,omitempty"`
+}
+
+const (
+	planRepository = "repository"
+	planTag        = "tag"
+)
+
+type planRetentionPolicy struct {
+	Day         *int     `short:"d" long:"day" description:"The tags of a repository created less than N days should not be deleted, as rp tags does."`
+	Max         *int     `short:"m" long:"max" description:"The maximum quantity of tags created more than N days of a repository should keep untouched, as rp tags does."`
+	Rules       string   `short:"r" long:"rules" description:"The file of the ordered rules deciding which tags to keep or delete, as rp tags does." default:""`
+	RepoName    string   `short:"n" long:"repo_name" description:"Repo name for specific target. If not set, the tags of all repos are planned." default:"" complete:"repository"`
+	DeleteCount *int     `long:"delete-count" description:"Plan to delete the N lowest scored repositories by rp.yaml, as rp repos does."`
+	MinScore    float32  `long:"min-score" description:"Never delete the repositories scored at least this, no limit by default."`
+	Projects    []string `long:"project" description:"Only the repositories of this project, can be given more than once." complete:"project"`
+	Args        struct {
+		Plan string `positional-arg-name:"plan" description:"The plan file to write, in YAML if it ends with .yaml or .yml, in JSON otherwise."`
+	} `positional-args:"yes" required:"yes"`
+}
+
+var planRP planRetentionPolicy
+
+func (x *planRetentionPolicy) Execute(args []string) error {
+	policy := &planPolicy{}
+	var rs *tagRules
+	if x.Rules != "" || x.Day != nil || x.Max != nil {
+		tags := tagsRetentionPolicy{Day: x.Day, Max: x.Max, Rules: x.Rules}
+		var err error
+		if rs, err = tags.tagRules(); err != nil {
+			return err
+		}
+		policy.Tags = &tagsPlanPolicy{RepoName: x.RepoName, Rules: rs.Rules}
+	}
+	if x.DeleteCount != nil {
+		if *x.DeleteCount < 0 {
+			return Usagef("--delete-count can not be negative")
+		}
+		rp, err := rpLoad()
+		if err != nil {
+			return err
+		}
+		policy.Repos = &reposPlanPolicy{Projects: x.Projects, DeleteCount: *x.DeleteCount, MinScore: x.MinScore, Score: rp}
+	}
+	if policy.Tags == nil && policy.Repos == nil {
+		return Usagef("nothing to plan, give --rules (or --day and --max) for tags, or --delete-count for repositories")
+	}
+	hash, err := policy.hash()
+	if err != nil {
+		return err
+	}
+
+	return Run(func(c *harbor.Client) (interface{}, error) {
+		plan := &rpPlan{
+			Version:    rpPlanVersion,
+			CreatedAt:  time.Now().UTC().Format(time.RFC3339),
+			PolicyHash: hash,
+			Policy:     policy,
+			Items:      []*planItem{},
+		}
+
+		deleted := map[string]bool{}
+		if p := policy.Repos; p != nil {
+			ranking, err := repoAnalyse(c, p.Score, p.Projects)
+			if err != nil {
+				return nil, err
+			}
+			selectRepos(ranking, p.DeleteCount, p.MinScore)
+			for _, r := range ranking {
+				if !r.delete {
+					continue
+				}
+				deleted[r.Repository] = true
+				plan.Items = append(plan.Items, &planItem{
+					Kind:       planRepository,
+					Repository: r.Repository,
+					ProjectID:  r.ProjectID,
+					Reason:     fmt.Sprintf("ranked %d of %d, scored %v", r.Rank, len(ranking), r.Score),
+					UpdateTime: r.UpdateTime,
+					PullCount:  r.PullCount,
+					TagsCount:  r.TagsCount,
+				})
+			}
+		}
+		if p := policy.Tags; p != nil {
+			ds, err := tagAnalyse(c, rs, p.RepoName)
+			if err != nil {
+				return nil, err
+			}
+			for _, d := range ds {
+				// the tags go with their repositories
+				if !d.delete || deleted[d.Repository] {
+					continue
+				}
+				plan.Items = append(plan.Items, &planItem{
+					Kind:       planTag,
+					Repository: d.Repository,
+					Tag:        d.Tag,
+					Digest:     d.Digest,
+					Reason:     d.Reason,
+					Created:    d.Created,
+					PushTime:   d.tag.PushTime,
+					PullTime:   d.tag.PullTime,
+					SharedWith: d.SharedWith,
+				})
+			}
+		}
+
+		if err := writePlan(x.Args.Plan, plan); err != nil {
+			return nil, err
+		}
+		return plan, nil
+	})
+}
+
+// isYAML tells if file is in YAML by its extension.
+func isYAML(file string) bool {
+	ext := strings.ToLower(filepath.Ext(file))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+func writePlan(file string, plan *rpPlan) error {
+	var data []byte
+	var err error
+	if isYAML(file) {
+		data, err = yaml.Marshal(plan)
+	} else {
+		data, err = json.MarshalIndent(plan, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, data, 0644)
+}
+
+// loadPlan loads the plan file, and checks its version and policy hash.
+func loadPlan(file string) (*rpPlan, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var plan rpPlan
+	// JSON is YAML too
+	if err := yaml.UnmarshalStrict(data, &plan); err != nil {
+		return nil, Usagef("invalid plan %s: %v", file, err)
+	}
+	if plan.Version != rpPlanVersion {
+		return nil, Usagef("invalid plan %s: version %d is not supported, only %d", file, plan.Version, rpPlanVersion)
+	}
+	if plan.Policy == nil {
+		return nil, Usagef("invalid plan %s: no policy", file)
+	}
+	hash, err := plan.Policy.hash()
+	if err != nil {
+		return nil, err
+	}
+	if hash != plan.PolicyHash {
+		return nil, Usagef("invalid plan %s: the policy does not match policy_hash %s, it is edited after planning", file, plan.PolicyHash)
+	}
+	for i, it := range plan.Items {
+		if it.Kind != planRepository && it.Kind != planTag || it.Repository == "" || it.Kind == planTag && it.Tag == "" {
+			return nil, Usagef("invalid plan %s: item %d is neither a repository nor a tag", file, i+1)
+		}
+	}
+	return &plan, nil
+}
+
+// ErrDrifted is matched by the error of rp_apply, if anything has changed
+// since planning.
+var ErrDrifted = errors.New("drifted")
+
+type applyRetentionPolicy struct {
+	Args struct {
+		Plan string `positional-arg-name:"plan" description:"The plan file written by rp plan."`
+	} `positional-args:"yes" required:"yes"`
+	Confirm
+}
+
+var applyRP applyRetentionPolicy
+
+func (x *applyRetentionPolicy) Execute(args []string) error {
+	plan, err := loadPlan(x.Args.Plan)
+	if err != nil {
+		return err
+	}
+
+	drifted, skipped, failed := 0, 0, 0
+	err = Run(func(c *harbor.Client) (interface{}, error) {
+		p, err := loadProtection()
+		if err != nil {
+			return nil, err
+		}
+
+		// check everything before deleting anything, as a tag deleted
+		// changes its repository
+		for _, it := range plan.Items {
+			drift, err := it.drift(c, plan.Items)
+			if err != nil {
+				return nil, err
+			}
+			if drift != "" {
+				it.Action, it.Drift = "drifted", drift
+			}
+		}
+		// the tags of a digest go together, so do their drifts
+		for _, it := range plan.Items {
+			if it.Kind != planTag || it.Action != "" {
+				continue
+			}
+			for _, other := range plan.Items {
+				if other.Kind == planTag && other.Action == "drifted" &&
+					other.Repository == it.Repository && other.Digest == it.Digest && other.Tag != it.Tag {
+					it.Action, it.Drift = "drifted", fmt.Sprintf("the digest is shared by %s, which has changed since planning", other.Tag)
+					break
+				}
+			}
+		}
+
+		var todo []*planItem
+		for _, it := range plan.Items {
+			if it.Action == "drifted" {
+				drifted++
+				continue
+			}
+			why, err := it.protected(c, p)
+			if err != nil {
+				return nil, err
+			}
+			if why != "" && !x.Force {
+				it.Action, it.Drift = "skipped", fmt.Sprintf("protected (%s), use --force to delete it anyway", why)
+				skipped++
+				continue
+			}
+			todo = append(todo, it)
+		}
+		if len(todo) == 0 {
+			return plan.Items, nil
+		}
+
+		var repos, tags int
+		for _, it := range todo {
+			if it.Kind == planRepository {
+				repos++
+			} else {
+				tags++
+			}
+		}
+		if err := x.check(&deletion{
+			kind:    "the items of plan",
+			name:    x.Args.Plan,
+			details: []string{plural(repos, "repository", "repositories"), plural(tags, "tag", "tags")},
+		}); err != nil {
+			return nil, err
+		}
+
+		// the tags of a digest are checked to be all in the plan
+		deleted := map[string]*planItem{}
+		for _, it := range todo {
+			key := it.Repository + "@" + it.Digest
+			if first := deleted[key]; it.Kind == planTag && first != nil {
+				it.Action = first.Action
+				if first.Action == "deleted" || first.Action == "delete" {
+					if err := deleteSharedTag(c, it.Repository, it.Tag); err != nil {
+						it.Action, it.Drift = "failed", err.Error()
+						failed++
+					}
+				}
+				continue
+			}
+			if it.Kind == planTag {
+				deleted[key] = it
+				err = c.DeleteTag(it.Repository, it.Tag)
+			} else {
+				err = c.DeleteRepository(it.Repository)
+			}
+			if err != nil {
+				it.Action, it.Drift = "failed", err.Error()
+				failed++
+				continue
+			}
+			it.Action = "deleted"
+			if Opts.DryRun || Opts.PrintCurl {
+				it.Action = "delete"
+			}
+		}
+		return plan.Items, nil
+	})
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to delete %d item(s)", failed)
+	}
+	if drifted > 0 {
+		return fmt.Errorf("%d item(s) changed since planning, not deleted, plan again: %w", drifted, ErrDrifted)
+	}
+	if skipped > 0 {
+		return fmt.Errorf("%d item(s) protected, not deleted, use --force to delete them anyway: %w", skipped, ErrProtected)
+	}
+	return nil
+}
+
+// drift tells how it has changed since planning, or "". The tags of the same
+// digest have to be in items too, which are deleted together.
+func (it *planItem) drift(c *harbor.Client, items []*planItem) (string, error) {
+	if it.Kind == planRepository {
+		// q matches the names containing it, which can be many pages
+		repos, err := allRepositories(c, harbor.RepositoryListOptions{ProjectID: it.ProjectID, Q: it.Repository})
+		if err != nil {
+			return "", err
+		}
+		for _, r := range repos {
+			if r.Name != it.Repository {
+				continue
+			}
+			switch {
+			case r.UpdateTime != it.UpdateTime:
+				return fmt.Sprintf("updated at %s, not %s", r.UpdateTime, it.UpdateTime), nil
+			case r.PullCount != it.PullCount:
+				return fmt.Sprintf("pulled %d times, not %d", r.PullCount, it.PullCount), nil
+			case r.TagsCount != it.TagsCount:
+				return fmt.Sprintf("has %d tags, not %d", r.TagsCount, it.TagsCount), nil
+			}
+			return "", nil
+		}
+		return "the repository is gone", nil
+	}
+
+	tags, err := c.ListTags(it.Repository)
+	if err != nil {
+		if errors.Is(err, harbor.ErrNotFound) {
+			return "the repository is gone", nil
+		}
+		return "", err
+	}
+	planned := map[string]bool{}
+	for _, other := range items {
+		if other.Kind == planTag && other.Repository == it.Repository {
+			planned[other.Tag] = true
+		}
+	}
+	var tag *harbor.Tag
+	for _, t := range tags {
+		if t.Name == it.Tag {
+			tag = t
+		}
+	}
+	switch {
+	case tag == nil:
+		return "the tag is gone", nil
+	case tag.Digest != it.Digest:
+		return fmt.Sprintf("pushed again, the digest is %s, not %s", tag.Digest, it.Digest), nil
+	case tag.PushTime != it.PushTime:
+		return fmt.Sprintf("pushed at %s, not %s", tag.PushTime, it.PushTime), nil
+	case tag.PullTime != it.PullTime:
+		return fmt.Sprintf("pulled at %s, not %s", tag.PullTime, it.PullTime), nil
+	}
+	digest := tag.Digest
+	for _, t := range tags {
+		if t.Digest == digest && !planned[t.Name] {
+			return fmt.Sprintf("the digest is shared by %s, which is not planned to delete", t.Name), nil
+		}
+	}
+	return "", nil
+}
+
+// protected returns why it is protected, or "", as Confirm does.
+func (it *planItem) protected(c *harbor.Client, p *protection) (string, error) {
+	if why := p.repository(it.Repository); why != "" {
+		return why, nil
+	}
+	tags, err := c.ListTags(it.Repository)
+	if err != nil {
+		return "", err
+	}
+	for _, t := range tags {
+		if it.Kind == planTag && t.Digest != it.Digest {
+			continue
+		}
+		if why := p.tag(it.Repository, t); why != "" {
+			return "tag " + t.Name + " " + why, nil
+		}
+	}
+	return "", nil
+}
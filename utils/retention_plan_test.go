@@ -0,0 +1,234 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbortest"
+)
+
+func TestRetentionPlan(t *testing.T) {
+	srv := rpTest(t)
+	srv.AddProject("prj", false, harbortest.AdminUsername)
+	now := time.Now()
+	srv.Now = func() time.Time { return now.Add(-100 * 24 * time.Hour) }
+	srv.PushImage("prj/old", "v1", "sha256:old")
+	srv.PushImage("prj/older", "v1", "sha256:older")
+	srv.Now = time.Now
+	for i, tag := range []string{"v1", "v1-alias", "v2", "v3", "v4"} {
+		digest := "sha256:" + tag
+		if tag == "v1-alias" {
+			digest = "sha256:v1"
+		}
+		srv.PushImageAt("library/app", tag, digest, now.Add(-time.Duration(20-i)*24*time.Hour))
+	}
+
+	items := func(its []*planItem, action string) string {
+		var s []string
+		for _, it := range its {
+			if action == "" || it.Action == action {
+				name := it.Repository
+				if it.Kind == planTag {
+					name += ":" + it.Tag
+				}
+				s = append(s, name)
+			}
+		}
+		return strings.Join(s, " ")
+	}
+
+	dir := t.TempDir()
+	for _, file := range []string{"plan.json", "plan.yaml"} {
+		file = filepath.Join(dir, file)
+		var plan rpPlan
+		if err := rpRun(t, &plan, "rp", "plan", "-d", "5", "-m", "1", "-n", "library/app", "--delete-count", "1", "--project", "prj", file); err != nil {
+			t.Fatal(err)
+		}
+		if got := items(plan.Items, ""); got != "prj/old library/app:v3 library/app:v2 library/app:v1-alias library/app:v1" {
+			t.Fatalf("%s: got %q", file, got)
+		}
+		if !strings.HasPrefix(plan.PolicyHash, "sha256:") || plan.Items[3].Digest != "sha256:v1" || plan.Items[3].Reason == "" {
+			t.Errorf("%s: got %+v", file, plan)
+		}
+		if _, err := loadPlan(file); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+	}
+	file := filepath.Join(dir, "plan.json")
+
+	// nothing is deleted without the confirmation, or by --dry-run
+	if err := rpRun(t, nil, "rp", "apply", file); !errors.Is(err, ErrAborted) {
+		t.Fatalf("without --yes: got error %v", err)
+	}
+	var its []*planItem
+	if err := rpRun(t, &its, "--dry-run", "rp", "apply", file); err != nil {
+		t.Fatal(err)
+	}
+	if got := items(its, "delete"); got != items(its, "") || len(srv.Tags("library/app")) != 5 {
+		t.Fatalf("--dry-run: got %q", got)
+	}
+
+	// pushed again, retagged and pushed since planning
+	srv.PushImage("library/app", "v2", "sha256:v2-new")
+	srv.PushImage("library/app", "v3-alias", "sha256:v3")
+	srv.PushImage("prj/old", "v2", "sha256:old-2")
+
+	its = nil
+	err := rpRun(t, &its, "rp", "apply", "--yes", file)
+	if !errors.Is(err, ErrDrifted) || ExitCode(err) != ExitDrifted {
+		t.Fatalf("drifted: got error %v", err)
+	}
+	if got := items(its, "deleted"); got != "library/app:v1-alias library/app:v1" {
+		t.Errorf("deleted: got %q", got)
+	}
+	drift := map[string]string{}
+	for _, it := range its {
+		if it.Action == "drifted" {
+			drift[items([]*planItem{it}, "")] = it.Drift
+		}
+	}
+	if len(drift) != 3 || !strings.Contains(drift["library/app:v2"], "sha256:v2-new") ||
+		!strings.Contains(drift["library/app:v3"], "v3-alias") || !strings.Contains(drift["prj/old"], "updated") {
+		t.Errorf("drift: got %v", drift)
+	}
+	if got := strings.Join(srv.Tags("library/app"), " "); got != "v3 v4 v2 v3-alias" {
+		t.Errorf("tags: got %q", got)
+	}
+
+	// the policy is edited after planning
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = []byte(strings.Replace(string(data), `"delete_count": 1`, `"delete_count": 2`, 1))
+	if err := ioutil.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := rpRun(t, nil, "rp", "apply", "--yes", file); ExitCode(err) != ExitUsage {
+		t.Errorf("edited plan: got error %v", err)
+	}
+	if err := rpRun(t, nil, "rp", "plan", file); ExitCode(err) != ExitUsage {
+		t.Errorf("nothing to plan: got error %v", err)
+	}
+}
+
+func TestRetentionPlanProtected(t *testing.T) {
+	srv := rpTest(t)
+	now := time.Now()
+	for i, tag := range []string{"v1", "v2", "v3"} {
+		srv.PushImageAt("library/app", tag, "sha256:"+tag, now.Add(-time.Duration(20-i)*24*time.Hour))
+	}
+	file := filepath.Join(t.TempDir(), "plan.json")
+	var plan rpPlan
+	if err := rpRun(t, &plan, "rp", "plan", "-d", "5", "-m", "1", "-n", "library/app", file); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(os.Getenv(EnvConfig), []byte("protected:\n  repositories:\n  - library/app:v1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the others are deleted, but the exit code tells v1 is not
+	var its []*planItem
+	err := rpRun(t, &its, "rp", "apply", "--yes", file)
+	if !errors.Is(err, ErrProtected) || ExitCode(err) != ExitAborted {
+		t.Fatalf("protected: got error %v", err)
+	}
+	if len(its) != 2 || its[0].Tag != "v2" || its[0].Action != "deleted" || its[1].Tag != "v1" || its[1].Action != "skipped" {
+		t.Errorf("protected: got %+v %+v", its[0], its[1])
+	}
+	if got := strings.Join(srv.Tags("library/app"), " "); got != "v1 v3" {
+		t.Errorf("tags: got %q", got)
+	}
+
+	// v2 is gone since planning
+	its = nil
+	if err := rpRun(t, &its, "rp", "apply", "--yes", "--force", file); !errors.Is(err, ErrDrifted) || errors.Is(err, ErrProtected) {
+		t.Errorf("--force: got error %v, want v2 drifted only", err)
+	}
+	if got := strings.Join(srv.Tags("library/app"), " "); got != "v3" {
+		t.Errorf("tags after --force: got %q", got)
+	}
+}
+
+func TestRetentionPlanPulled(t *testing.T) {
+	srv := rpTest(t)
+	srv.AddProject("prj", false, harbortest.AdminUsername)
+	srv.PushImage("prj/old", "v1", "sha256:old")
+	now := time.Now()
+	for i, tag := range []string{"v1", "v1-alias", "v2", "v3"} {
+		digest := "sha256:" + tag
+		if tag == "v1-alias" {
+			digest = "sha256:v1"
+		}
+		srv.PushImageAt("library/app", tag, digest, now.Add(-time.Duration(20-i)*24*time.Hour))
+	}
+	file := filepath.Join(t.TempDir(), "plan.json")
+	var plan rpPlan
+	if err := rpRun(t, &plan, "rp", "plan", "-d", "5", "-m", "1", "-n", "library/app", "--delete-count", "1", "--project", "prj", file); err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Items) != 4 || plan.Items[1].PushTime == "" || plan.Items[1].PullTime != "" {
+		t.Fatalf("plan: got %+v", plan.Items)
+	}
+
+	// pulled since planning, v1-alias goes with v1
+	pulled := now.Add(time.Hour)
+	srv.Now = func() time.Time { return pulled }
+	srv.PullImage("prj/old", "v1")
+	srv.PullImage("library/app", "v1")
+	var its []*planItem
+	if err := rpRun(t, &its, "rp", "apply", "--yes", file); !errors.Is(err, ErrDrifted) {
+		t.Fatalf("pulled: got error %v", err)
+	}
+	drift := map[string]string{}
+	for _, it := range its {
+		drift[it.Repository+":"+it.Tag] = it.Action + ": " + it.Drift
+	}
+	want := map[string]string{
+		"prj/old:":             "drifted: pulled 1 times, not 0",
+		"library/app:v2":       "deleted: ",
+		"library/app:v1":       "drifted: pulled at " + pulled.UTC().Format(time.RFC3339) + ", not ",
+		"library/app:v1-alias": "drifted: the digest is shared by v1, which has changed since planning",
+	}
+	if !reflect.DeepEqual(drift, want) {
+		t.Errorf("got %q, want %q", drift, want)
+	}
+	if got := strings.Join(srv.Tags("library/app"), " "); got != "v1 v1-alias v3" {
+		t.Errorf("tags: got %q", got)
+	}
+}
+
+func TestRetentionPlanManyRepositories(t *testing.T) {
+	srv := rpTest(t)
+	srv.AddProject("prj", false, harbortest.AdminUsername)
+	for i := 0; i < 100; i++ {
+		srv.PushImage(fmt.Sprintf("prj/app-%03d", i), "v1", "sha256:1111")
+	}
+	// prj/app matches all of them, but comes last
+	srv.PushImage("prj/app", "v1", "sha256:1111")
+
+	file := filepath.Join(t.TempDir(), "plan.json")
+	var plan rpPlan
+	if err := rpRun(t, &plan, "rp", "plan", "--delete-count", "101", "--project", "prj", file); err != nil {
+		t.Fatal(err)
+	}
+	var its []*planItem
+	if err := rpRun(t, &its, "rp", "apply", "--yes", file); err != nil {
+		t.Fatal(err)
+	}
+	for _, it := range its {
+		if it.Action != "deleted" {
+			t.Errorf("%s: got %s %s", it.Repository, it.Action, it.Drift)
+		}
+	}
+	if len(its) != 101 || srv.Repository("prj/app") != nil {
+		t.Errorf("got %d items, prj/app %+v", len(its), srv.Repository("prj/app"))
+	}
+}
@@ -1,238 +1,262 @@
 package utils
 
 import (
-	"bufio"
-	"container/heap"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/moooofly/harbor-go-client/harbor"
 	yaml "gopkg.in/yaml.v2"
 )
 
-type statistics struct {
-	PrivateProjectCount int `json:"private_project_count"`
-	PrivateRepoCount    int `json:"private_repo_count"`
-	PublicProjectCount  int `json:"public_project_count"`
-	PublicRepoCount     int `json:"public_repo_count"`
-	TotalProjectCount   int `json:"total_project_count"`
-	TotalRepoCount      int `json:"total_repo_count"`
-}
-
-var stats statistics
-
-type repoTop struct {
-	ID           int    `json:"id"`
-	Name         string `json:"name"`
-	ProjectID    int    `json:"project_id"`
-	Description  string `json:"description"`
-	PullCount    int    `json:"pull_count"`
-	StarCount    int    `json:"star_count"`
-	TagsCount    int    `json:"tags_count"`
-	CreationTime string `json:"creation_time"`
-	UpdateTime   string `json:"update_time"`
-}
-
-var repos []*repoTop
-
-type repoSearch struct {
-	ProjectID      int    `json:"project_id"`
-	ProjectName    string `json:"project_name"`
-	ProjectPublic  bool   `json:"project_public"`
-	PullCount      int    `json:"pull_count"`
-	RepositoryName string `json:"repository_name"`
-	TagsCount      int    `json:"tags_count"`
-}
-
-type searchRsp struct {
-	Repository []*repoSearch `json:"repository"`
-	Project    []interface{} `json:"project"`
-}
-
-var scRsp searchRsp
-
-type tagInfo struct {
-	Digest        string `json:"digest"`
-	Name          string `json:"name"`
-	Architecture  string `json:"architecture"`
-	DockerVersion string `json:"docker_version"`
-	Author        string `json:"author"`
-	Created       string `json:"created"`
-	Signature     string `json:"signature"`
-}
-
-type tagListRsp []*tagInfo
-
-var tlRsp tagListRsp
-
 func init() {
-	Parser.AddCommand("rp_repos",
+	AddCommand("rp repos", "rp_repos",
 		"Delete repos by retention policy.",
 		"Run retention policy analysis on Repositories, do soft deletion as you command, prompt user performing a GC.",
 		&reposRP)
-	Parser.AddCommand("rp_tags",
+	AddCommand("rp tags", "rp_tags",
 		"Delete tags of repo by retention policy.",
 		"Run retention policy analysis on tags, and do deletion as you command.",
 		&tagsRP)
 }
 
 type reposRetentionPolicy struct {
+	DeleteCount *int     `long:"delete-count" description:"Delete the N lowest scored repositories, instead of asking for N on stdin. 0 deletes nothing, but prints the ranking."`
+	MinScore    float32  `long:"min-score" description:"Never delete the repositories scored at least this, no limit by default."`
+	Projects    []string `long:"project" description:"Only the repositories of this project, can be given more than once. By default all the projects, public or private." complete:"project"`
+	Confirm
 }
 
 var reposRP reposRetentionPolicy
 
 func (x *reposRetentionPolicy) Execute(args []string) error {
-	if err := repoAnalyse(); err != nil {
-		os.Exit(1)
+	rp, err := rpLoad()
+	if err != nil {
+		return err
 	}
-	if err := repoErase(); err != nil {
-		os.Exit(1)
+	if x.DeleteCount != nil && *x.DeleteCount < 0 {
+		return Usagef("--delete-count can not be negative")
 	}
-	rpGCHint()
-	return nil
+
+	return Run(func(c *harbor.Client) (interface{}, error) {
+		ranking, err := repoAnalyse(c, rp, x.Projects)
+		if err != nil {
+			return nil, err
+		}
+
+		var n int
+		if x.DeleteCount != nil {
+			n = *x.DeleteCount
+		} else if n, err = askDeleteCount(ranking); err != nil {
+			return nil, err
+		}
+		selectRepos(ranking, n, x.MinScore)
+		failed := x.repoErase(c, ranking)
+		if failed > 0 {
+			return ranking, fmt.Errorf("failed to delete %d repo(s)", failed)
+		}
+		for _, r := range ranking {
+			if r.Action == "deleted" {
+				rpGCHint()
+				break
+			}
+		}
+		return ranking, nil
+	})
 }
 
 type tagsRetentionPolicy struct {
-	Day      int    `short:"d" long:"day" description:"(REQUIRED) The tags of a repository created less than N days should not be deleted." required:"yes"`
-	Max      int    `short:"m" long:"max" description:"(REQUIRED) The maximum quantity of tags created more than N days of a repository should keep untouched." required:"yes"`
-	RepoName string `short:"n" long:"repo_name" description:"Repo name for specific target. If not set, rp_tags will do jobs on all repos." default:""`
+	Day      *int   `short:"d" long:"day" description:"The tags of a repository created less than N days should not be deleted. Required with --max, unless --rules is given."`
+	Max      *int   `short:"m" long:"max" description:"The maximum quantity of tags created more than N days of a repository should keep untouched. Required with --day, unless --rules is given."`
+	Rules    string `short:"r" long:"rules" description:"The file of the ordered rules deciding which tags to keep or delete, instead of --day and --max." default:""`
+	RepoName string `short:"n" long:"repo_name" description:"Repo name for specific target. If not set, rp_tags will do jobs on all repos." default:"" complete:"repository"`
 	DryRun   bool   `long:"dry-run" description:"Just analyzing, no actual deleting."`
+	Confirm
 }
 
 var tagsRP tagsRetentionPolicy
 
 func (x *tagsRetentionPolicy) Execute(args []string) error {
-	if err := tagAnalyseAndErase(); err != nil {
-		os.Exit(1)
+	rs, err := x.tagRules()
+	if err != nil {
+		return err
+	}
+	p, err := loadProtection()
+	if err != nil {
+		return err
 	}
-	return nil
-}
-
-func tagAnalyseAndErase() error {
-	fmt.Println("===============================")
-	fmt.Println("==  Start tags RP Analysing  ==")
-	fmt.Println("===============================")
-	fmt.Println()
 
-	c, err := CookieLoad()
+	// the tags are printed even if some are not deleted
+	var eraseErr error
+	err = Run(func(c *harbor.Client) (interface{}, error) {
+		ds, err := tagAnalyse(c, rs, x.RepoName)
+		if err != nil {
+			return nil, err
+		}
+		eraseErr = x.tagErase(c, p, ds)
+		return ds, nil
+	})
 	if err != nil {
-		fmt.Println("error:", err)
 		return err
 	}
+	return eraseErr
+}
 
-	// By "/api/search", you can obtain all the items of projects and repositories
-	// By setting "q=" query parameter, you can obtain ALL items.
-	// By setting "q=<xxx>" query parameter, you can obtain items filtered by <xxx>.
-	// But there exists a bug with it, so can not fulfill procession on specific repo correctly based on this now
-	searchURL := URLGen("/api/search") + "?q=" + tagsRP.RepoName
-	fmt.Println("--------------------")
-	fmt.Println("==> GET", searchURL)
+// tagRules loads the rules of --rules, or makes the ones of --day and --max:
+// the tags at most --day days old are kept, then the --max newest of the
+// others, and the rest are deleted. Days are counted in whole days as rp_tags
+// always did, a tag is older than --day if int(days) > --day, that is at
+// least --day+1 days old.
+func (x *tagsRetentionPolicy) tagRules() (*tagRules, error) {
+	if x.Rules != "" {
+		if x.Day != nil || x.Max != nil {
+			return nil, Usagef("--rules can not be given with --day or --max")
+		}
+		return loadTagRules(x.Rules)
+	}
+	if x.Day == nil || x.Max == nil {
+		return nil, Usagef("either --rules, or both --day and --max are required")
+	}
+	if *x.Day < 0 || *x.Max < 0 {
+		return nil, Usagef("--day and --max can not be negative")
+	}
 
-	_, _, errs := Request.Get(searchURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		EndStruct(&scRsp)
-	for _, e := range errs {
-		if e != nil {
-			fmt.Println("error:", e)
-			return e
+	day, max := *x.Day, *x.Max
+	rs := &tagRules{}
+	rs.Rules = append(rs.Rules, &tagRule{
+		Name:      fmt.Sprintf("keep all at most %d days old", day),
+		Action:    tagKeep,
+		NewerThan: day + 1,
+	})
+	// newest 0 would select them all
+	if max > 0 {
+		rs.Rules = append(rs.Rules, &tagRule{
+			Name:      fmt.Sprintf("keep the %d newest more than %d days old", max, day),
+			Action:    tagKeep,
+			OlderThan: day + 1,
+			Newest:    max,
+		})
+	}
+	rs.Rules = append(rs.Rules, &tagRule{
+		Name:      fmt.Sprintf("delete all more than %d days old", day),
+		Action:    tagDelete,
+		OlderThan: day + 1,
+	})
+	for _, r := range rs.Rules {
+		if err := r.compile(); err != nil {
+			return nil, err
 		}
 	}
+	return rs, nil
+}
 
-	if tagsRP.RepoName == "" {
-		fmt.Printf("==> on all Repos, max-days-untouched: %d   max-keep-num-after-Ndays: %d\n",
-			tagsRP.Day, tagsRP.Max)
-	} else {
-		fmt.Printf("==> only on Repo [%s], max-days-untouched: %d   max-keep-num-after-Ndays: %d\n",
-			tagsRP.RepoName, tagsRP.Day, tagsRP.Max)
+// tagAnalyse decides the tags of the repositories matching repoName (all of
+// them if empty) by rs.
+func tagAnalyse(c *harbor.Client, rs *tagRules, repoName string) ([]*tagDecision, error) {
+	// By "/api/search", you can obtain all the items of projects and repositories
+	// By setting "q=" query parameter, you can obtain ALL items.
+	// By setting "q=<xxx>" query parameter, you can obtain items filtered by <xxx>.
+	// But there exists a bug with it, so can not fulfill procession on specific repo correctly based on this now
+	scRsp, err := c.Search(repoName)
+	if err != nil {
+		return nil, err
 	}
-	fmt.Println("--------------------")
 
 	// iterate on all repositories
+	decisions := []*tagDecision{}
 	for _, r := range scRsp.Repository {
-		fmt.Println(" ")
-		fmt.Println("------------------------------------------------------")
-		fmt.Printf("| repo_name: %s | tags_count: %d |\n", r.RepositoryName, r.TagsCount)
-		fmt.Println("------------------------------------------------------")
-		fmt.Println("---")
-
-		fmt.Println("+--------+----------------------------------------------------+----------------------------------+-----------------+")
-		fmt.Printf("| % -6s | % -50s | % -32s | % -15s |\n", "Action", "TagName", "CreateTime", "DaysPast")
-		fmt.Println("+--------+----------------------------------------------------+----------------------------------+-----------------+")
-
-		// obtain tags info of echo repo
-		tagsListURL := URLGen("/api/repositories") + "/" + r.RepositoryName + "/tags"
-
-		_, _, errs := Request.Get(tagsListURL).
-			Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-			EndStruct(&tlRsp)
-		for _, e := range errs {
-			if e != nil {
-				fmt.Println("error:", e)
-				return e
-			}
+		tags, err := c.ListTags(r.RepositoryName)
+		if err != nil {
+			return nil, err
 		}
+		decisions = append(decisions, rs.evaluate(r.RepositoryName, tags, time.Now())...)
+	}
+	return decisions, nil
+}
 
-		// heap sort on tags of echo repo
-		tagmh = tagminheap{}
-		heap.Init(&tagmh)
-		for _, t := range tlRsp {
-			//fmt.Printf("==> name: %s    created: %s\n", t.Name, t.Created)
+// deleteSharedTag deletes a tag whose digest has just been deleted with
+// another tag. Harbor 1.x deletes the manifest with all its tags, but Harbor
+// 2.x deletes the tags of an artifact one by one.
+func deleteSharedTag(c *harbor.Client, repoName, tag string) error {
+	if err := c.DeleteTag(repoName, tag); err != nil && !errors.Is(err, harbor.ErrNotFound) {
+		return err
+	}
+	return nil
+}
 
-			tagC := rfc3339Transform(t.Created)
-			dayPast := time.Now().Sub(tagC).Hours() / 24
+// tagErase deletes the tags decided to delete unless --dry-run, but the ones
+// protected or not confirmed. The tags of the same digest are deleted, or
+// not, together. It returns the error telling the tags not deleted, if any.
+func (x *tagsRetentionPolicy) tagErase(c *harbor.Client, p *protection, ds []*tagDecision) error {
+	toDelete := map[string][]*harbor.Tag{}
+	for _, d := range ds {
+		if d.delete {
+			toDelete[d.Repository] = append(toDelete[d.Repository], d.tag)
+		}
+	}
 
-			// a. by each repo, tags created less than N days keep untouched
-			if tagsRP.Day < int(dayPast) {
-				it := &tagItem{
-					tagName:   t.Name,
-					timestamp: tagC.Unix(),
+	var failed, skipped int
+	var skipErr error
+	done := map[string]*tagDecision{}
+	for _, d := range ds {
+		if !d.delete {
+			continue
+		}
+		key := d.Repository + "@" + d.Digest
+		if first := done[key]; first != nil && d.Digest != "" {
+			d.Action = first.Action
+			d.Reason += fmt.Sprintf(", goes with %s of the same digest", first.Tag)
+			if first.Action == "deleted" || first.Action == "delete" && !x.DryRun {
+				if err := deleteSharedTag(c, d.Repository, d.Tag); err != nil {
+					d.Action, d.Reason = "failed", err.Error()
+					failed++
 				}
-				// tags created more than N days sort by minheap
-				//fmt.Printf("[PUSH] %s <==> create: %s    dayPast: %f\n", it.tagName, t.Created, dayPast)
-				fmt.Printf("| % -6s | % -50s | % -32s | % -15f |\n", "*", t.Name, t.Created, dayPast)
-				heap.Push(&tagmh, it)
-			} else {
-				//fmt.Printf("[noPUSH] %s <==> create: %s    dayPast: %f\n", t.Name, t.Created, dayPast)
-				fmt.Printf("| % -6s | % -50s | % -32s | % -15f |\n", "", t.Name, t.Created, dayPast)
 			}
-
+			continue
 		}
-		// b. by each repo, tags created more than N days keep Max number untouched.
-		gtNdays := tagmh.Len()
-		fmt.Println("+--------+----------------------------------------------------+----------------------------------+-----------------+")
-		fmt.Printf("--> # of tags less than %d days: %d , # of tags more than %d days: %d\n",
-			tagsRP.Day, r.TagsCount-gtNdays, tagsRP.Day, gtNdays)
-		if gtNdays <= tagsRP.Max {
-			fmt.Printf("--> max-keep-num-after-Ndays (%d) more than actual num (%d), so DO NOTHING.\n", tagsRP.Max, gtNdays)
-		} else {
-			fmt.Printf("--> max-keep-num-after-Ndays (%d) less than actual num (%d), so START DELETING.\n", tagsRP.Max, gtNdays)
-			fmt.Println("---")
-			if tagsRP.DryRun == false {
-				for gtNdays > tagsRP.Max {
-					it := heap.Pop(&tagmh).(*tagItem)
-					fmt.Printf("[POP] %s <==> %d\n", it.tagName, it.timestamp)
-
-					targetURL := URLGen("/api/repositories") + "/" + r.RepositoryName + "/tags/" + it.tagName
-					fmt.Println("==> DELETE", targetURL)
-
-					Request.Delete(targetURL).
-						Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-						End(PrintStatus)
-
-					gtNdays--
-				}
-			} else {
-				fmt.Println("with '--dry-run' setting, just analyzing, no actual deleting.")
+		done[key] = d
+		if x.DryRun {
+			d.Action = "delete"
+			continue
+		}
+
+		group := digestGroup(toDelete[d.Repository], d.tag)
+		if err := x.Confirm.check(digestDeletion(p, d.Repository, group)); err != nil {
+			d.Action, d.Reason = "skipped", err.Error()
+			if !errors.Is(err, ErrProtected) && !errors.Is(err, ErrAborted) {
+				d.Action = "failed"
+				failed++
+				continue
+			}
+			if skipErr == nil {
+				skipErr = err
 			}
+			skipped++
+			continue
+		}
+		if err := c.DeleteTag(d.Repository, d.Tag); err != nil {
+			d.Action, d.Reason = "failed", err.Error()
+			failed++
+			continue
+		}
+		d.Action = "deleted"
+		if Opts.DryRun || Opts.PrintCurl {
+			d.Action = "delete"
 		}
 	}
 
-	fmt.Printf("\n=== Finish tags RP Analysing ===\n\n")
-
+	switch {
+	case failed > 0:
+		return fmt.Errorf("failed to delete %d tag(s)", failed)
+	case skipped > 0:
+		return fmt.Errorf("%d tag(s) not deleted, the first: %w", skipped, skipErr)
+	}
 	return nil
 }
 
@@ -304,32 +328,23 @@ func format(rp *retentionPolicy) {
 	fmt.Println("===>", string(rps))
 }
 
-// rfc3339Transform parses timestamp string as RFC3339 layout
-func rfc3339Transform(in string) time.Time {
-	t, err := time.Parse(time.RFC3339, in)
-	if err != nil {
-		fmt.Println("error:", err)
-		os.Exit(1)
-	}
-	return t
-}
-
 // grade calculates the score of each repo according to retention policy
-func grade(r *repoTop, rp *retentionPolicy) float32 {
+func grade(r *harbor.Repository, rp *retentionPolicy) (float32, error) {
 	var uf, pf, tf float32
 
-	day := time.Now().Sub(rfc3339Transform(r.UpdateTime)).Hours() / 24
+	updated, err := time.Parse(time.RFC3339, r.UpdateTime)
+	if err != nil {
+		return 0, fmt.Errorf("update time of repository %s: %v", r.Name, err)
+	}
+	day := time.Now().Sub(updated).Hours() / 24
 	for _, f := range rp.UpdateTime.Factors {
 		if f.Range.Low <= int(day) && int(day) < f.Range.High {
 			uf = f.Weight
 			break
 		}
 	}
-	if uf == float32(0) {
-		fmt.Printf("Out of range: day = %.2f, uf is 0.0\n", day)
-		uf = float32(0)
-	}
 
+	// out of range, pf and tf are 1.0, while uf is 0.0
 	for _, f := range rp.PullCount.Factors {
 		if f.Range.Low <= r.PullCount && r.PullCount < f.Range.High {
 			pf = f.Weight
@@ -337,7 +352,6 @@ func grade(r *repoTop, rp *retentionPolicy) float32 {
 		}
 	}
 	if pf == float32(0) {
-		fmt.Printf("Out of range: pull_count = %d, pf is 1.0\n", r.PullCount)
 		pf = float32(1)
 	}
 
@@ -348,181 +362,169 @@ func grade(r *repoTop, rp *retentionPolicy) float32 {
 		}
 	}
 	if tf == float32(0) {
-		fmt.Printf("Out of range: tags_count = %d, tf is 1.0\n", r.TagsCount)
 		tf = float32(1)
 	}
 
-	score := rp.UpdateTime.Base*uf + rp.PullCount.Base*pf + rp.TagsCount.Base*tf
-	fmt.Printf("[factors] ==> score = UpdateTimeBase*uf + PullCountBase*pf + TagsCountBase*tf = %.2f * %.2f + %.2f * %.2f + %.2f * %.2f = %.2f   repo_id: %d\n",
-		rp.UpdateTime.Base, uf, rp.PullCount.Base, pf, rp.TagsCount.Base, tf, score, r.ID)
-
-	return score
+	return rp.UpdateTime.Base*uf + rp.PullCount.Base*pf + rp.TagsCount.Base*tf, nil
 }
 
-// repoAnalyse calculates scores and output topN element by minheap sort
-func repoAnalyse() error {
-
-	rp, err := rpLoad()
-	if err != nil {
-		fmt.Println("error:", err)
-		return err
-	}
-
-	// output current RP setting with pretty format
-	//format(rp)
-
-	statsURL := URLGen("/api/statistics")
-
-	c, err := CookieLoad()
-	if err != nil {
-		fmt.Println("error:", err)
-		return err
-	}
+// rankedRepo is a repository in the ranking of rp_repos.
+type rankedRepo struct {
+	Rank       int     `json:"rank"`
+	Repository string  `json:"repository"`
+	ProjectID  int     `json:"project_id"`
+	Score      float32 `json:"score"`
+	UpdateTime string  `json:"update_time"`
+	PullCount  int     `json:"pull_count"`
+	TagsCount  int     `json:"tags_count"`
+	// Action is deleted, kept, skipped or failed, or delete by --dry-run.
+	Action string `json:"action"`
+	Reason string `json:"reason,omitempty"`
+
+	delete bool
+}
 
-	resp, _, statsErrs := Request.Get(statsURL).
-		Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-		EndStruct(&stats)
-	if resp.StatusCode != 200 {
-		fmt.Printf("error: Expected StatusCode=200, actual StatusCode=%v\n", resp.StatusCode)
-	}
-	for _, e := range statsErrs {
-		if e != nil {
-			fmt.Println("error:", e)
-			return e
+// repoAnalyse scores the repositories of projects (names), or of all the
+// projects, and ranks them by the scores from low to high, which is the order
+// of deletion.
+func repoAnalyse(c *harbor.Client, rp *retentionPolicy, projects []string) ([]*rankedRepo, error) {
+	var ids []int
+	for _, name := range projects {
+		id, err := ProjectID(c, 0, name)
+		if err != nil {
+			return nil, err
 		}
+		ids = append(ids, id)
 	}
-
-	fmt.Println("-------------------------------------------------")
-	fmt.Println("     Current Number of Public Repositories:", stats.PublicRepoCount)
-	fmt.Println("-------------------------------------------------")
-
-	topURL := URLGen("/api/repositories/top") + "?count=" + strconv.Itoa(stats.PublicRepoCount)
-	fmt.Println("==> GET", topURL)
-	_, _, topErrs := Request.Get(topURL).EndStruct(&repos)
-	for _, e := range topErrs {
-		if e != nil {
-			fmt.Println("error:", e)
-			return e
+	if len(projects) == 0 {
+		it := c.IterProjects(nil)
+		for it.Next() {
+			ids = append(ids, it.Project().ProjectID)
+		}
+		if err := it.Err(); err != nil {
+			return nil, err
 		}
 	}
 
-	heap.Init(&minh)
-	heap.Init(&mhBk)
-	for _, r := range repos {
-		sc := grade(r, rp)
-
-		// NOTE: codes below only for debug
-		// ===========
-		/*
-			rs, err := json.MarshalIndent(r, "", "  ")
+	ranking := []*rankedRepo{}
+	for _, id := range ids {
+		repos, err := allRepositories(c, harbor.RepositoryListOptions{ProjectID: id})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range repos {
+			score, err := grade(r, rp)
 			if err != nil {
-				fmt.Println("error:", err)
-				return err
+				return nil, err
 			}
-			fmt.Printf("score (%f) =>\n%s\n", sc, rs)
-		*/
-		// ===========
-
-		it := &repoItem{
-			data:  r,
-			score: sc,
+			ranking = append(ranking, &rankedRepo{
+				Repository: r.Name,
+				ProjectID:  r.ProjectID,
+				Score:      score,
+				UpdateTime: r.UpdateTime,
+				PullCount:  r.PullCount,
+				TagsCount:  r.TagsCount,
+				Action:     "kept",
+			})
 		}
-		heap.Push(&minh, it)
-		heap.Push(&mhBk, it)
 	}
 
-	fmt.Println("------------------------------------------------------------------------------------------------------")
-	fmt.Printf("      By the Rank of Scores (from low to high) , Suggestion on Deletion of public repos as follow\n")
-	fmt.Println("------------------------------------------------------------------------------------------------------")
-
-	for mhBk.Len() > 0 {
-		it := heap.Pop(&mhBk).(*repoItem)
-		fmt.Printf("%.2f <==> %+v\n", it.score, *it.data)
+	// the older, the earlier among the same scores
+	sort.SliceStable(ranking, func(i, j int) bool {
+		if ranking[i].Score != ranking[j].Score {
+			return ranking[i].Score < ranking[j].Score
+		}
+		if ranking[i].UpdateTime != ranking[j].UpdateTime {
+			return ranking[i].UpdateTime < ranking[j].UpdateTime
+		}
+		return ranking[i].Repository < ranking[j].Repository
+	})
+	for i, r := range ranking {
+		r.Rank = i + 1
 	}
-
-	return nil
+	return ranking, nil
 }
 
-// repoErase implements soft deletion
-func repoErase() error {
+// askDeleteCount prints the ranking on stderr, and asks for the number of
+// repositories to delete on stdin.
+func askDeleteCount(ranking []*rankedRepo) (int, error) {
+	fmt.Fprintln(os.Stderr, "By the rank of scores (from low to high), the suggestion on deletion of repos is as follow:")
+	for _, r := range ranking {
+		fmt.Fprintf(os.Stderr, "%4d  %.2f  %s\n", r.Rank, r.Score, r.Repository)
+	}
 
-	var num int
-	scanner := bufio.NewScanner(os.Stdin)
-	fmt.Print("\nPlease input the number of repo you wish to delete: ")
-	for scanner.Scan() {
-		in, err := strconv.Atoi(scanner.Text())
+	fmt.Fprint(os.Stderr, "\nPlease input the number of repo you wish to delete: ")
+	for {
+		line, err := readLine(Stdin)
+		if n, convErr := strconv.Atoi(strings.TrimSpace(line)); convErr == nil && n >= 0 {
+			return n, nil
+		}
 		if err != nil {
-			fmt.Print("Invalid number, please input again: ")
-			continue
+			fmt.Fprintln(os.Stderr)
+			return 0, Usagef("no number of repos to delete is given, use --delete-count in scripts")
 		}
-		fmt.Println("The number you input: ", in)
-
-		fmt.Print("Confirm [y/n]: ")
+		fmt.Fprint(os.Stderr, "Invalid number, please input again: ")
+	}
+}
 
-		if !scanner.Scan() {
+// selectRepos selects the first n repos of ranking to delete, but the ones
+// scored at least minScore, unless it is 0.
+func selectRepos(ranking []*rankedRepo, n int, minScore float32) {
+	for _, r := range ranking {
+		if n == 0 {
 			break
 		}
-		confirm := scanner.Text()
-
-		if strings.EqualFold(confirm, "y") {
-			num = in
-			break
-		} else {
-			fmt.Print("Please input the number of repo you wish to delete again: ")
+		if minScore > 0 && r.Score >= minScore {
+			r.Reason = fmt.Sprintf("scored at least %v", minScore)
+			continue
 		}
+		r.delete = true
+		n--
 	}
+}
 
-	if err := scanner.Err(); err != nil {
-		fmt.Println("error:", err)
-		return err
-	}
-
-	if num <= 0 || num > 50 {
-		fmt.Println("[Warning] The valid number range is (0, 50].")
-		fmt.Println("[Warning] Sorry, you're not allowed proceeding... Abort.")
-		return fmt.Errorf("error: the number is out of range")
-	}
-
-	fmt.Printf("\n=== Start soft deletion ===\n\n")
-
-	for num > 0 {
-		if minh.Len() > 0 {
-			it := heap.Pop(&minh).(*repoItem)
-
-			// NOTE: Before repo deletion running, you must login first. Codes here doing deletion directly without checking.
-			targetURL := URLGen("/api/repositories") + "/" + it.data.Name
-			fmt.Println("==> DELETE", targetURL)
-
-			c, err := CookieLoad()
-			if err != nil {
-				fmt.Println("Error:", err)
-				return err
+// repoErase deletes (softly) the repos of ranking selected, but the ones
+// protected or not confirmed. It returns the number of the failures.
+func (x *reposRetentionPolicy) repoErase(c *harbor.Client, ranking []*rankedRepo) int {
+	failed := 0
+	for _, r := range ranking {
+		if !r.delete {
+			continue
+		}
+		if err := x.Confirm.Repository(c, r.Repository); err != nil {
+			r.Action, r.Reason = "skipped", err.Error()
+			if !errors.Is(err, ErrProtected) && !errors.Is(err, ErrAborted) {
+				r.Action = "failed"
+				failed++
 			}
-
-			Request.Delete(targetURL).
-				Set("Cookie", "harbor-lang=zh-cn; beegosessionID="+c.BeegosessionID).
-				End(PrintStatus)
+			continue
+		}
+		if err := c.DeleteRepository(r.Repository); err != nil {
+			r.Action, r.Reason = "failed", err.Error()
+			failed++
+			continue
+		}
+		r.Action = "deleted"
+		if Opts.DryRun || Opts.PrintCurl {
+			r.Action = "delete"
 		}
-		num--
 	}
-
-	fmt.Printf("\n=== Finish soft deletion ===\n\n")
-	return nil
+	return failed
 }
 
 // rpGCHint gives a hint about hard deletion.
 func rpGCHint() {
 
-	fmt.Println("-----------------------------")
-	fmt.Println("You have finished 'soft deletion' stage，if you wish to free disk space effectively，you should:")
-	fmt.Println("1. Enter into harbor's main installation directory (e.g. /opt/apps/harbor/)")
-	fmt.Println("2. Run the following commands to preview which files/images will be deleted:")
-	fmt.Println("    a. docker-compose stop")
-	fmt.Println("    b. docker run -it --name gc --rm --volumes-from registry vmware/registry:2.6.2-photon garbage-collect --dry-run /etc/registry/config.yml")
-	fmt.Println("3. Run the following commands to trigger GC operation:")
-	fmt.Println("    a. docker run -it --name gc --rm --volumes-from registry vmware/registry:2.6.2-photon garbage-collect  /etc/registry/config.yml")
-	fmt.Println("    b. docker-compose start")
-	fmt.Println("")
-	fmt.Println("WARNING:\nMake sure that no one is pushing images or Harbor is not running at all before you perform a GC. If someone were pushing an image while GC is running, there is a risk that the image's layers will be mistakenly deleted which results in a corrupted image. So before running GC, a preferred approach is to stop Harbor first.")
-	fmt.Println("-----------------------------")
+	fmt.Fprintln(os.Stderr, "-----------------------------")
+	fmt.Fprintln(os.Stderr, "You have finished 'soft deletion' stage，if you wish to free disk space effectively，you should:")
+	fmt.Fprintln(os.Stderr, "1. Enter into harbor's main installation directory (e.g. /opt/apps/harbor/)")
+	fmt.Fprintln(os.Stderr, "2. Run the following commands to preview which files/images will be deleted:")
+	fmt.Fprintln(os.Stderr, "    a. docker-compose stop")
+	fmt.Fprintln(os.Stderr, "    b. docker run -it --name gc --rm --volumes-from registry vmware/registry:2.6.2-photon garbage-collect --dry-run /etc/registry/config.yml")
+	fmt.Fprintln(os.Stderr, "3. Run the following commands to trigger GC operation:")
+	fmt.Fprintln(os.Stderr, "    a. docker run -it --name gc --rm --volumes-from registry vmware/registry:2.6.2-photon garbage-collect  /etc/registry/config.yml")
+	fmt.Fprintln(os.Stderr, "    b. docker-compose start")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "WARNING:\nMake sure that no one is pushing images or Harbor is not running at all before you perform a GC. If someone were pushing an image while GC is running, there is a risk that the image's layers will be mistakenly deleted which results in a corrupted image. So before running GC, a preferred approach is to stop Harbor first.")
+	fmt.Fprintln(os.Stderr, "-----------------------------")
 }
@@ -1 +1,319 @@
 package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	"github.com/moooofly/harbor-go-client/harbortest"
+)
+
+// rpTest starts a harbor for the rp commands, logged in as admin.
+func rpTest(t *testing.T) *harbortest.Server {
+	srv := harbortest.NewServer()
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	t.Setenv(EnvConfig, filepath.Join(dir, "config.yaml"))
+	t.Setenv(EnvSessionStore, SessionStoreFile)
+	t.Setenv(EnvURL, srv.URL)
+	t.Setenv(EnvUsername, harbortest.AdminUsername)
+	t.Setenv(EnvPassword, harbortest.AdminPassword)
+
+	f := rpfile
+	t.Cleanup(func() { rpfile = f })
+	rpfile = "../conf/rp.yaml"
+	return srv
+}
+
+// rpRun runs an rp command with args, and decodes the list printed into v.
+// The requests of --dry-run are on Stderr, apart from it.
+func rpRun(t *testing.T, v interface{}, args ...string) error {
+	t.Helper()
+
+	stdout, stderr := Stdout, Stderr
+	defer func() {
+		Stdout, Stderr, Opts = stdout, stderr, Options{}
+		reposRP, tagsRP = reposRetentionPolicy{}, tagsRetentionPolicy{}
+		planRP, applyRP = planRetentionPolicy{}, applyRetentionPolicy{}
+	}()
+
+	var buf bytes.Buffer
+	Stdout, Stderr = &buf, ioutil.Discard
+	_, err := ParseArgs(args)
+	if buf.Len() > 0 {
+		if err := json.Unmarshal(buf.Bytes(), v); err != nil {
+			t.Fatalf("%v: decode output %q: %v", args, buf.String(), err)
+		}
+	}
+	return err
+}
+
+// rpRepos runs rp_repos with args, and returns the ranking printed.
+func rpRepos(t *testing.T, args ...string) ([]*rankedRepo, error) {
+	t.Helper()
+
+	var ranking []*rankedRepo
+	err := rpRun(t, &ranking, append([]string{"rp_repos"}, args...)...)
+	return ranking, err
+}
+
+func TestRetentionPolicyRepos(t *testing.T) {
+	srv := rpTest(t)
+	srv.AddProject("prj", false, harbortest.AdminUsername)
+	now := time.Now()
+	for _, r := range []struct {
+		name string
+		age  time.Duration
+	}{
+		{"prj/old", 100 * 24 * time.Hour},
+		{"prj/recent", 10 * 24 * time.Hour},
+		{"library/new", 0},
+		{"library/older", 200 * 24 * time.Hour},
+	} {
+		srv.Now = func() time.Time { return now.Add(-r.age) }
+		srv.PushImage(r.name, "v1", "sha256:1111")
+	}
+	srv.Now = time.Now
+
+	names := func(ranking []*rankedRepo, action string) string {
+		var s []string
+		for _, r := range ranking {
+			if action == "" || r.Action == action {
+				s = append(s, r.Repository)
+			}
+		}
+		return strings.Join(s, " ")
+	}
+
+	// the private project is ranked too, the older first among the same scores
+	ranking, err := rpRepos(t, "--delete-count", "0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := names(ranking, ""), "library/older prj/old prj/recent library/new"; got != want {
+		t.Fatalf("ranking: got %q, want %q", got, want)
+	}
+	if ranking[0].Rank != 1 || ranking[3].Score <= ranking[2].Score || names(ranking, "kept") != names(ranking, "") {
+		t.Errorf("ranking: got %+v", ranking)
+	}
+
+	// not confirmed
+	ranking, err = rpRepos(t, "--project", "prj", "--delete-count", "1")
+	if err != nil || names(ranking, "skipped") != "prj/old" || srv.Repository("prj/old") == nil {
+		t.Fatalf("without --yes: got %v %+v", err, ranking)
+	}
+
+	ranking, err = rpRepos(t, "--dry-run", "--delete-count", "5", "--min-score", "0.785", "--yes")
+	if err != nil || names(ranking, "delete") != "library/older prj/old" || srv.Repository("prj/old") == nil {
+		t.Fatalf("--dry-run: got %v %+v", err, ranking)
+	}
+
+	ranking, err = rpRepos(t, "--project", "prj", "--delete-count", "1", "--yes")
+	if err != nil || names(ranking, "deleted") != "prj/old" || names(ranking, "kept") != "prj/recent" {
+		t.Fatalf("--project prj: got %v %+v", err, ranking)
+	}
+	if srv.Repository("prj/old") != nil || srv.Repository("library/older") == nil {
+		t.Error("deleted the wrong repos")
+	}
+
+	// the number is asked on stdin without --delete-count
+	defer func(r io.Reader) { Stdin = r }(Stdin)
+	Stdin = strings.NewReader("x\n2\ny\nn\n")
+	ranking, err = rpRepos(t)
+	if err != nil || names(ranking, "deleted") != "library/older" || names(ranking, "skipped") != "prj/recent" {
+		t.Errorf("interactive: got %v %+v", err, ranking)
+	}
+}
+
+func TestRetentionPolicyTags(t *testing.T) {
+	srv := rpTest(t)
+	now := time.Now()
+	for i, tag := range []string{"latest", "v1.0.0", "pr-1", "pr-2", "dev"} {
+		srv.PushImageAt("library/app", tag, "sha256:"+tag, now.Add(-time.Duration(20-4*i)*24*time.Hour))
+	}
+
+	tags := func(ds []*tagDecision, action string) string {
+		var s []string
+		for _, d := range ds {
+			if d.Action == action {
+				s = append(s, d.Tag)
+			}
+		}
+		return strings.Join(s, " ")
+	}
+
+	// the tags created less than 5 days are kept, then the newest older one
+	var ds []*tagDecision
+	if err := rpRun(t, &ds, "rp_tags", "-d", "5", "-m", "1", "--dry-run"); err != nil {
+		t.Fatal(err)
+	}
+	if got := tags(ds, "delete"); got != "pr-1 v1.0.0 latest" {
+		t.Errorf("--day --max: got %q to delete", got)
+	}
+	if ds[0].Tag != "dev" || ds[0].Reason != "rule 1: keep all at most 5 days old" ||
+		ds[1].Tag != "pr-2" || ds[1].Reason != "rule 2: keep the 1 newest more than 5 days old" {
+		t.Errorf("--day --max: got %+v %+v", ds[0], ds[1])
+	}
+	if len(srv.Tags("library/app")) != 5 {
+		t.Fatal("--dry-run deleted tags")
+	}
+
+	rules := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := ioutil.WriteFile(rules, []byte(`
+rules:
+- name: never delete latest
+  action: keep
+  match: ^latest$
+- action: delete
+  match: ^pr-
+  older_than: 7
+- action: delete
+  older_than: 14
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// old goes with latest, and pr-0 with pr-1
+	srv.PushImageAt("library/app", "old", "sha256:latest", now.Add(-30*24*time.Hour))
+	srv.PushImageAt("library/app", "pr-0", "sha256:pr-1", now.Add(-11*24*time.Hour))
+	ds = nil
+	if err := rpRun(t, &ds, "--dry-run", "rp", "tags", "--rules", rules, "-n", "library/app"); err != nil {
+		t.Fatal(err)
+	}
+	old := ds[len(ds)-1]
+	if old.Tag != "old" || old.Action != "kept" || old.Rule != 3 ||
+		!strings.Contains(old.Reason, "shared by latest") || strings.Join(old.SharedWith, " ") != "latest" {
+		t.Errorf("old: got %+v", old)
+	}
+	if got := tags(ds, "delete"); got != "pr-2 pr-0 pr-1 v1.0.0" {
+		t.Errorf("--rules --dry-run: got %q to delete", got)
+	}
+
+	// not confirmed, the tags are printed still
+	defer func(r io.Reader) { Stdin = r }(Stdin)
+	Stdin = strings.NewReader("")
+	ds = nil
+	err := rpRun(t, &ds, "rp", "tags", "--rules", rules, "-n", "library/app")
+	if !errors.Is(err, ErrAborted) || tags(ds, "skipped") != "pr-2 pr-0 pr-1 v1.0.0" || len(srv.Tags("library/app")) != 7 {
+		t.Fatalf("without --yes: got %v, %q skipped", err, tags(ds, "skipped"))
+	}
+
+	config := os.Getenv(EnvConfig)
+	if err := ioutil.WriteFile(config, []byte("protected:\n  repositories:\n  - library/*\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ds = nil
+	err = rpRun(t, &ds, "rp", "tags", "--yes", "--rules", rules, "-n", "library/app")
+	if !errors.Is(err, ErrProtected) || ExitCode(err) != ExitAborted || len(srv.Tags("library/app")) != 7 {
+		t.Fatalf("protected: got %v, %d tags", err, len(srv.Tags("library/app")))
+	}
+	if err := os.Remove(config); err != nil {
+		t.Fatal(err)
+	}
+
+	ds = nil
+	if err := rpRun(t, &ds, "rp", "tags", "--yes", "--rules", rules, "-n", "library/app"); err != nil {
+		t.Fatal(err)
+	}
+	if got := tags(ds, "deleted"); got != "pr-2 pr-0 pr-1 v1.0.0" {
+		t.Errorf("--rules: got %q deleted", got)
+	}
+	if got := strings.Join(srv.Tags("library/app"), " "); got != "latest dev old" {
+		t.Errorf("--rules: got tags %q", got)
+	}
+
+	for _, args := range [][]string{
+		{"rp_tags"},
+		{"rp_tags", "-d", "5"},
+		{"rp_tags", "-d", "5", "-m", "1", "--rules", rules},
+		{"rp_tags", "-d", "-1", "-m", "1"},
+	} {
+		if err := rpRun(t, nil, args...); ExitCode(err) != ExitUsage {
+			t.Errorf("%v: got error %v", args, err)
+		}
+	}
+	if err := ioutil.WriteFile(rules, []byte("rules:\n- action: remove\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := rpRun(t, nil, "rp_tags", "--rules", rules); ExitCode(err) != ExitUsage {
+		t.Errorf("invalid rules: got error %v", err)
+	}
+}
+
+// TestRetentionPolicyTagsDayMax compares --day and --max with the way rp_tags
+// selected the tags to delete before --rules.
+func TestRetentionPolicyTagsDayMax(t *testing.T) {
+	srv := rpTest(t)
+	now := time.Now()
+	ages := map[string]time.Duration{
+		"10m":  10 * time.Minute,
+		"12h":  12 * time.Hour,
+		"1.5d": 36 * time.Hour,
+		"3d":   3 * 24 * time.Hour,
+		"5.5d": 132 * time.Hour,
+		"8d":   8 * 24 * time.Hour,
+		"20d":  20 * 24 * time.Hour,
+	}
+	order := []string{"10m", "12h", "1.5d", "3d", "5.5d", "8d", "20d"}
+	for _, tag := range order {
+		srv.PushImageAt("library/app", tag, "sha256:"+tag, now.Add(-ages[tag]))
+	}
+
+	// the tags more than day whole days old, but the max newest of them
+	baseline := func(day, max int) string {
+		var old []string
+		for _, tag := range order {
+			if day < int(ages[tag].Hours()/24) {
+				old = append(old, tag)
+			}
+		}
+		if len(old) <= max {
+			return ""
+		}
+		return strings.Join(old[max:], " ")
+	}
+
+	for _, tt := range []struct{ day, max int }{
+		{7, 0}, {0, 0}, {0, 2}, {1, 0}, {5, 1}, {3, 10},
+	} {
+		var ds []*tagDecision
+		args := []string{"rp_tags", "-d", strconv.Itoa(tt.day), "-m", strconv.Itoa(tt.max), "--dry-run"}
+		if err := rpRun(t, &ds, args...); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, d := range ds {
+			if d.Action == "delete" {
+				got = append(got, d.Tag)
+			}
+		}
+		if want := baseline(tt.day, tt.max); strings.Join(got, " ") != want {
+			t.Errorf("%v: got %q to delete, want %q", args, got, want)
+		}
+	}
+}
+
+func TestGradeBadUpdateTime(t *testing.T) {
+	rpTest(t)
+	rp, err := rpLoad()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := grade(&harbor.Repository{Name: "library/busybox", UpdateTime: "yesterday"}, rp); err == nil {
+		t.Error("got no error")
+	}
+	r := &harbor.Repository{Name: "library/busybox", UpdateTime: time.Now().Format(time.RFC3339)}
+	if _, err := grade(r, rp); err != nil {
+		t.Error(err)
+	}
+}
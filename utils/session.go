@@ -0,0 +1,384 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Session stores, set by session_store in conf/config.yaml or by
+// $HARBOR_SESSION_STORE. Any other value is taken as the name of a docker
+// credential helper, e.g. "pass" means docker-credential-pass.
+const (
+	SessionStoreFile      = "file"
+	SessionStoreEncrypted = "encrypted"
+)
+
+var sessionfile = "sessions.yaml"
+var encryptedSessionfile = "sessions.enc"
+
+// errSessionNotFound is returned by sessionStore.Get if there is no session.
+var errSessionNotFound = errors.New("session not found")
+
+// Session is a login session saved by login.
+type Session struct {
+	ID       string    `yaml:"id" json:"id"` // beegosessionID
+	Username string    `yaml:"username" json:"username"`
+	Since    time.Time `yaml:"since" json:"since"` // the time of login
+}
+
+// sessionStore keeps the session of every harbor server (context).
+type sessionStore interface {
+	Get(key string) (*Session, error)
+	Store(key string, session *Session) error
+	Erase(key string) error
+}
+
+// newSessionStore returns the session store in use.
+func newSessionStore() (sessionStore, error) {
+	kind := os.Getenv(EnvSessionStore)
+	if kind == "" {
+		if config, err := generalConfigLoad(); err == nil {
+			kind = config.SessionStore
+		}
+	}
+
+	switch kind {
+	case "", SessionStoreFile:
+		dir, err := configDir()
+		if err != nil {
+			return nil, err
+		}
+		return &fileSessionStore{path: filepath.Join(dir, sessionfile)}, nil
+	case SessionStoreEncrypted:
+		passphrase := os.Getenv(EnvSessionPassphrase)
+		if passphrase == "" {
+			return nil, fmt.Errorf("$%s is required by the encrypted session store", EnvSessionPassphrase)
+		}
+		dir, err := configDir()
+		if err != nil {
+			return nil, err
+		}
+		return &fileSessionStore{path: filepath.Join(dir, encryptedSessionfile), passphrase: []byte(passphrase)}, nil
+	default:
+		return &helperSessionStore{helper: newCredentialHelper(kind)}, nil
+	}
+}
+
+// SessionLoad returns the session of the current context, it is nil if not
+// logged in.
+func SessionLoad() (*Session, error) {
+	ctx, err := CurrentContext()
+	if err != nil {
+		return nil, err
+	}
+	return loadSession(ctx)
+}
+
+// loadSession returns the session of ctx, it is nil if not logged in.
+func loadSession(ctx *Context) (*Session, error) {
+	store, err := newSessionStore()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := store.Get(ctx.sessionKey())
+	if err == errSessionNotFound {
+		return nil, nil
+	}
+	return session, err
+}
+
+// SessionSave saves the session for the current context, it is called only in
+// stage of login and replaces the old one if any. Nothing is saved with
+// --replay, whose session is a placeholder, or with --dry-run.
+func SessionSave(session *Session) error {
+	if Opts.Replay != "" || Opts.DryRun || Opts.PrintCurl {
+		return nil
+	}
+	ctx, err := CurrentContext()
+	if err != nil {
+		return err
+	}
+	store, err := newSessionStore()
+	if err != nil {
+		return err
+	}
+
+	return store.Store(ctx.sessionKey(), session)
+}
+
+// SessionRemove removes the session of the current context only, it is
+// called in stage of logout. Nothing is removed with --replay or --dry-run.
+func SessionRemove() error {
+	if Opts.Replay != "" || Opts.DryRun || Opts.PrintCurl {
+		return nil
+	}
+	ctx, err := CurrentContext()
+	if err != nil {
+		return err
+	}
+	store, err := newSessionStore()
+	if err != nil {
+		return err
+	}
+
+	return store.Erase(ctx.sessionKey())
+}
+
+// fileSessionStore keeps all sessions in one file readable by the owner only,
+// which is encrypted by AES-GCM if passphrase is set.
+type fileSessionStore struct {
+	path       string
+	passphrase []byte
+}
+
+type sessionFile struct {
+	Sessions map[string]*Session `yaml:"sessions"`
+}
+
+// encryptedSessionFile is the content of sessions.enc, all fields are base64
+// encoded.
+type encryptedSessionFile struct {
+	Salt  string `yaml:"salt"`
+	Nonce string `yaml:"nonce"`
+	Data  string `yaml:"data"`
+}
+
+func (s *fileSessionStore) Get(key string) (*Session, error) {
+	sf, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	session, ok := sf.Sessions[key]
+	if !ok || session == nil {
+		return nil, errSessionNotFound
+	}
+	return session, nil
+}
+
+func (s *fileSessionStore) Store(key string, session *Session) error {
+	sf, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	sf.Sessions[key] = session
+	return s.save(sf)
+}
+
+func (s *fileSessionStore) Erase(key string) error {
+	sf, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := sf.Sessions[key]; !ok {
+		return nil
+	}
+	delete(sf.Sessions, key)
+	return s.save(sf)
+}
+
+func (s *fileSessionStore) load() (*sessionFile, error) {
+	sf := &sessionFile{Sessions: make(map[string]*Session)}
+
+	dataBytes, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return sf, nil
+		}
+		return nil, err
+	}
+
+	if s.passphrase != nil {
+		if dataBytes, err = s.decrypt(dataBytes); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := yaml.Unmarshal(dataBytes, sf); err != nil {
+		return nil, err
+	}
+	if sf.Sessions == nil {
+		sf.Sessions = make(map[string]*Session)
+	}
+	return sf, nil
+}
+
+// save replaces the file atomically, so that it is never readable by others
+// and never left half-written.
+func (s *fileSessionStore) save(sf *sessionFile) error {
+	dataBytes, err := yaml.Marshal(sf)
+	if err != nil {
+		return err
+	}
+
+	if s.passphrase != nil {
+		if dataBytes, err = s.encrypt(dataBytes); err != nil {
+			return err
+		}
+	}
+
+	return writePrivateFile(s.path, dataBytes)
+}
+
+func (s *fileSessionStore) encrypt(plain []byte) ([]byte, error) {
+	salt := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+
+	aead, err := PassphraseGCM(string(s.passphrase), salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(&encryptedSessionFile{
+		Salt:  base64.StdEncoding.EncodeToString(salt),
+		Nonce: base64.StdEncoding.EncodeToString(nonce),
+		Data:  base64.StdEncoding.EncodeToString(aead.Seal(nil, nonce, plain, nil)),
+	})
+}
+
+func (s *fileSessionStore) decrypt(dataBytes []byte) ([]byte, error) {
+	var ef encryptedSessionFile
+	if err := yaml.Unmarshal(dataBytes, &ef); err != nil {
+		return nil, err
+	}
+
+	salt, err1 := base64.StdEncoding.DecodeString(ef.Salt)
+	nonce, err2 := base64.StdEncoding.DecodeString(ef.Nonce)
+	data, err3 := base64.StdEncoding.DecodeString(ef.Data)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return nil, fmt.Errorf("%s is corrupted", s.path)
+	}
+
+	aead, err := PassphraseGCM(string(s.passphrase), salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("%s is corrupted", s.path)
+	}
+	plain, err := aead.Open(nil, nonce, data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("can not decrypt %s, wrong $%s?", s.path, EnvSessionPassphrase)
+	}
+	return plain, nil
+}
+
+// helperSessionStore keeps sessions by a docker credential helper, the whole
+// Session is saved as the secret.
+type helperSessionStore struct {
+	helper *credentialHelper
+}
+
+func (s *helperSessionStore) Get(key string) (*Session, error) {
+	cred, err := s.helper.Get(key)
+	if err == errCredentialsNotFound {
+		return nil, errSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var session Session
+	if err := json.Unmarshal([]byte(cred.Secret), &session); err != nil {
+		return nil, fmt.Errorf("invalid session saved by %s: %v", s.helper.program, err)
+	}
+	return &session, nil
+}
+
+func (s *helperSessionStore) Store(key string, session *Session) error {
+	b, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	return s.helper.Store(&helperCredentials{
+		ServerURL: key,
+		Username:  session.Username,
+		Secret:    string(b),
+	})
+}
+
+func (s *helperSessionStore) Erase(key string) error {
+	err := s.helper.Erase(key)
+	if err == errCredentialsNotFound {
+		return nil
+	}
+	return err
+}
+
+func init() {
+	cmd, _ := Parser.AddCommand("session",
+		"Show login session.",
+		"Show the login session of the current context.",
+		&struct{}{})
+	cmd.AddCommand("status",
+		"Show which user is logged in, on which server, and since when.",
+		"Show which user is logged in, on which server, and since when. The session is checked against harbor, exit code is 3 if not logged in or expired.",
+		&sessStatus)
+}
+
+type sessionStatus struct {
+}
+
+var sessStatus sessionStatus
+
+type sessionStatusResult struct {
+	Context  string    `json:"context"`
+	Server   string    `json:"server"`
+	Username string    `json:"username"`
+	Admin    bool      `json:"has_admin_role"`
+	Since    time.Time `json:"since"`
+}
+
+func (x *sessionStatus) Execute(args []string) error {
+	return RunLoginout(func(c *harbor.Client) (interface{}, error) {
+		ctx, err := CurrentContext()
+		if err != nil {
+			return nil, err
+		}
+		session, err := SessionLoad()
+		if err != nil {
+			return nil, err
+		}
+		if session == nil {
+			return nil, fmt.Errorf("not logged in to %s: %w", ctx.URL(), harbor.ErrUnauthorized)
+		}
+
+		u, err := c.GetCurrentUser()
+		if errors.Is(err, harbor.ErrUnauthorized) {
+			return nil, fmt.Errorf("session of %s on %s (since %s) has expired: %w",
+				session.Username, ctx.URL(), session.Since.Format(time.RFC3339), harbor.ErrUnauthorized)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return &sessionStatusResult{
+			Context:  ctx.Name,
+			Server:   ctx.URL(),
+			Username: u.Username,
+			Admin:    u.HasAdminRole,
+			Since:    session.Since,
+		}, nil
+	})
+}
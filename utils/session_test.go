@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testSessionStore stores, gets and erases sessions of store.
+func testSessionStore(t *testing.T, store sessionStore) {
+	t.Helper()
+
+	dev := &Session{ID: "dev-1234", Username: "admin", Since: time.Now().UTC().Truncate(time.Second)}
+	prod := &Session{ID: "prod-5678", Username: "robot", Since: dev.Since.Add(-time.Hour)}
+	if _, err := store.Get("https://harbor.dev/harbor-go-client/dev"); err != errSessionNotFound {
+		t.Fatalf("empty store: got %v, want errSessionNotFound", err)
+	}
+	if err := store.Store("https://harbor.dev/harbor-go-client/dev", dev); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Store("https://harbor.prod/harbor-go-client/prod", prod); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]*Session{
+		"https://harbor.dev/harbor-go-client/dev":   dev,
+		"https://harbor.prod/harbor-go-client/prod": prod,
+	} {
+		got, err := store.Get(key)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %+v, %v, want %+v", key, got, err, want)
+		}
+	}
+
+	if err := store.Erase("https://harbor.dev/harbor-go-client/dev"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get("https://harbor.dev/harbor-go-client/dev"); err != errSessionNotFound {
+		t.Errorf("erased: got %v, want errSessionNotFound", err)
+	}
+	if err := store.Erase("https://harbor.dev/harbor-go-client/dev"); err != nil {
+		t.Errorf("erased twice: %v", err)
+	}
+}
+
+func TestFileSessionStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions", sessionfile)
+	testSessionStore(t, &fileSessionStore{path: path})
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := fi.Mode().Perm(); mode != 0600 {
+		t.Errorf("got mode %v, want 0600", mode)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "prod-5678") {
+		t.Errorf("got file\n%s", data)
+	}
+}
+
+func TestEncryptedSessionStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), encryptedSessionfile)
+	testSessionStore(t, &fileSessionStore{path: path, passphrase: []byte("secret")})
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := fi.Mode().Perm(); mode != 0600 {
+		t.Errorf("got mode %v, want 0600", mode)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "prod-5678") || strings.Contains(string(data), "robot") {
+		t.Errorf("session in plain text:\n%s", data)
+	}
+
+	wrong := &fileSessionStore{path: path, passphrase: []byte("guess")}
+	if _, err := wrong.Get("https://harbor.prod/harbor-go-client/prod"); err == nil || !strings.Contains(err.Error(), "can not decrypt") {
+		t.Errorf("wrong passphrase: got %v", err)
+	}
+	if err := wrong.Store("https://harbor.prod/harbor-go-client/prod", &Session{ID: "evil"}); err == nil {
+		t.Error("wrong passphrase: overwrote the sessions")
+	}
+
+	// a new salt and nonce every time
+	store := &fileSessionStore{path: path, passphrase: []byte("secret")}
+	if err := store.Store("https://harbor.dev/harbor-go-client/dev", &Session{ID: "dev-1234"}); err != nil {
+		t.Fatal(err)
+	}
+	again, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Store("https://harbor.dev/harbor-go-client/dev", &Session{ID: "dev-1234"}); err != nil {
+		t.Fatal(err)
+	}
+	if last, _ := ioutil.ReadFile(path); string(last) == string(again) {
+		t.Error("the same session is encrypted the same way twice")
+	}
+}
+
+// fakeHelper is a docker credential helper keeping one credential per file.
+const fakeHelper = `#!/bin/sh
+dir=$(dirname "$0")/store
+mkdir -p "$dir"
+case "$1" in
+store)
+	cred=$(cat)
+	key=$(echo "$cred" | sed 's/.*"ServerURL":"\([^"]*\)".*/\1/' | tr '/:' '__')
+	echo "$cred" > "$dir/$key"
+	;;
+get)
+	key=$(cat | tr '/:' '__')
+	if [ ! -f "$dir/$key" ]; then
+		echo "credentials not found in native keychain"
+		exit 1
+	fi
+	cat "$dir/$key"
+	;;
+erase)
+	key=$(cat | tr '/:' '__')
+	if [ ! -f "$dir/$key" ]; then
+		echo "credentials not found in native keychain"
+		exit 1
+	fi
+	rm "$dir/$key"
+	;;
+esac
+`
+
+func TestHelperSessionStore(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "docker-credential-fake"), []byte(fakeHelper), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	testSessionStore(t, &helperSessionStore{helper: newCredentialHelper("fake")})
+
+	// the whole session is the secret, under the username of the session
+	cred, err := newCredentialHelper("fake").Get("https://harbor.prod/harbor-go-client/prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cred.Username != "robot" || !strings.Contains(cred.Secret, `"id":"prod-5678"`) {
+		t.Errorf("got credentials %+v", cred)
+	}
+}
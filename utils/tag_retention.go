@@ -0,0 +1,310 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// tagRule is a rule of tag retention. It selects the tags of a repository
+// matching Match and the age limits, then, if Newest or HighestSemver is
+// given, only the newest or the highest versions among them, and keeps or
+// deletes the ones not decided by the rules before.
+type tagRule struct {
+	Name          string `yaml:"name" json:"name,omitempty"`
+	Action        string `yaml:"action" json:"action"`
+	Match         string `yaml:"match" json:"match,omitempty"`
+	OlderThan     int    `yaml:"older_than" json:"older_than,omitempty"`
+	NewerThan     int    `yaml:"newer_than" json:"newer_than,omitempty"`
+	Newest        int    `yaml:"newest" json:"newest,omitempty"`
+	HighestSemver int    `yaml:"highest_semver" json:"highest_semver,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// tagRules is the ordered rule list of a rules file given to rp_tags.
+type tagRules struct {
+	Rules []*tagRule `yaml:"rules" json:"rules"`
+}
+
+const (
+	tagKeep   = "keep"
+	tagDelete = "delete"
+)
+
+// loadTagRules loads and checks the rules file.
+func loadTagRules(file string) (*tagRules, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var rs tagRules
+	if err := yaml.UnmarshalStrict(data, &rs); err != nil {
+		return nil, Usagef("invalid rules %s: %v", file, err)
+	}
+	if len(rs.Rules) == 0 {
+		return nil, Usagef("invalid rules %s: no rules", file)
+	}
+	for i, r := range rs.Rules {
+		if err := r.compile(); err != nil {
+			return nil, Usagef("invalid rules %s: rule %d: %v", file, i+1, err)
+		}
+	}
+	return &rs, nil
+}
+
+func (r *tagRule) compile() error {
+	if r.Action != tagKeep && r.Action != tagDelete {
+		return fmt.Errorf("action must be %s or %s, not %q", tagKeep, tagDelete, r.Action)
+	}
+	if r.OlderThan < 0 || r.NewerThan < 0 || r.Newest < 0 || r.HighestSemver < 0 {
+		return fmt.Errorf("older_than, newer_than, newest and highest_semver can not be negative")
+	}
+	if r.Newest > 0 && r.HighestSemver > 0 {
+		return fmt.Errorf("newest and highest_semver can not be given together")
+	}
+	re, err := regexp.Compile(r.Match)
+	if err != nil {
+		return err
+	}
+	r.re = re
+	return nil
+}
+
+// String describes r by its name, or by its conditions.
+func (r *tagRule) String() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	s := []string{r.Action}
+	switch {
+	case r.Newest > 0:
+		s = append(s, fmt.Sprintf("the %d newest", r.Newest))
+	case r.HighestSemver > 0:
+		s = append(s, fmt.Sprintf("the %d highest versions of each major", r.HighestSemver))
+	}
+	if r.Match != "" {
+		s = append(s, "matching "+r.Match)
+	} else if len(s) == 1 {
+		s = append(s, "all")
+	}
+	if r.OlderThan > 0 {
+		s = append(s, fmt.Sprintf("older than %d days", r.OlderThan))
+	}
+	if r.NewerThan > 0 {
+		s = append(s, fmt.Sprintf("newer than %d days", r.NewerThan))
+	}
+	return strings.Join(s, " ")
+}
+
+// tagDecision tells what is done to a tag, and which rule decides it.
+type tagDecision struct {
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+	Digest     string `json:"digest"`
+	Created    string `json:"created"`
+	// Action is kept or deleted, or delete by --dry-run, or failed.
+	Action string `json:"action"`
+	// Rule is the number of the rule deciding the tag from 1, 0 if no rule
+	// matches it, which keeps it.
+	Rule   int    `json:"rule"`
+	Reason string `json:"reason"`
+	// SharedWith are the other tags of the same digest, which go together
+	// with the tag, as Harbor deletes the manifest of a tag deleted.
+	SharedWith []string `json:"shared_with,omitempty"`
+
+	tag     *harbor.Tag
+	created time.Time
+	// badCreated is set if Created is not RFC 3339, the age of the tag is
+	// unknown then, so no rule decides it.
+	badCreated bool
+	delete     bool
+}
+
+// evaluate decides the tags of a repository by the rules in order, the
+// first rule selecting a tag decides it, but a tag sharing its digest with a
+// tag kept is kept too. The decisions are ordered from the newest tag.
+func (rs *tagRules) evaluate(repoName string, tags []*harbor.Tag, now time.Time) []*tagDecision {
+	ds := make([]*tagDecision, 0, len(tags))
+	for _, t := range tags {
+		created, err := time.Parse(time.RFC3339, t.Created)
+		d := &tagDecision{
+			Repository: repoName,
+			Tag:        t.Name,
+			Digest:     t.Digest,
+			Created:    t.Created,
+			tag:        t,
+			created:    created,
+		}
+		if err != nil {
+			d.badCreated = true
+			d.Reason = fmt.Sprintf("kept as its creation time %q is not valid", t.Created)
+		}
+		ds = append(ds, d)
+	}
+	sort.SliceStable(ds, func(i, j int) bool {
+		if !ds[i].created.Equal(ds[j].created) {
+			return ds[i].created.After(ds[j].created)
+		}
+		return ds[i].Tag < ds[j].Tag
+	})
+
+	for i, r := range rs.Rules {
+		for _, d := range r.selectTags(ds, now) {
+			if d.Rule == 0 {
+				d.Rule, d.delete = i+1, r.Action == tagDelete
+				d.Reason = fmt.Sprintf("rule %d: %s", i+1, r)
+			}
+		}
+	}
+	for _, d := range ds {
+		d.Action = "kept"
+		if d.Rule == 0 && !d.badCreated {
+			d.Reason = "no rule matches"
+		}
+	}
+	keepSharedDigests(ds)
+	return ds
+}
+
+// keepSharedDigests groups ds by digest, and keeps the tags to delete which
+// share their digests with the tags kept.
+func keepSharedDigests(ds []*tagDecision) {
+	byDigest := map[string][]*tagDecision{}
+	for _, d := range ds {
+		if d.Digest != "" {
+			byDigest[d.Digest] = append(byDigest[d.Digest], d)
+		}
+	}
+	for _, d := range ds {
+		group := byDigest[d.Digest]
+		if len(group) < 2 {
+			continue
+		}
+		var kept []string
+		for _, other := range group {
+			if other != d {
+				d.SharedWith = append(d.SharedWith, other.Tag)
+				if !other.delete {
+					kept = append(kept, other.Tag)
+				}
+			}
+		}
+		if d.delete && len(kept) > 0 {
+			d.Reason += fmt.Sprintf(", but kept as its digest is shared by %s kept", strings.Join(kept, ", "))
+		}
+	}
+	// after the reasons, which name the tags kept by the rules only
+	for _, d := range ds {
+		for _, other := range byDigest[d.Digest] {
+			if !other.delete {
+				d.delete = false
+			}
+		}
+	}
+}
+
+// selectTags returns the tags of ds (ordered from the newest) selected by r.
+func (r *tagRule) selectTags(ds []*tagDecision, now time.Time) []*tagDecision {
+	var matched []*tagDecision
+	for _, d := range ds {
+		age := now.Sub(d.created)
+		if d.badCreated || !r.re.MatchString(d.Tag) ||
+			r.OlderThan > 0 && age < time.Duration(r.OlderThan)*24*time.Hour ||
+			r.NewerThan > 0 && age >= time.Duration(r.NewerThan)*24*time.Hour {
+			continue
+		}
+		matched = append(matched, d)
+	}
+
+	switch {
+	case r.Newest > 0:
+		if len(matched) > r.Newest {
+			matched = matched[:r.Newest]
+		}
+	case r.HighestSemver > 0:
+		var versions []*semver
+		byVersion := map[*semver]*tagDecision{}
+		for _, d := range matched {
+			if v := parseSemver(d.Tag); v != nil {
+				versions = append(versions, v)
+				byVersion[v] = d
+			}
+		}
+		sort.SliceStable(versions, func(i, j int) bool { return versions[j].less(versions[i]) })
+		matched = nil
+		perMajor := map[int]int{}
+		for _, v := range versions {
+			if perMajor[v.major] < r.HighestSemver {
+				perMajor[v.major]++
+				matched = append(matched, byVersion[v])
+			}
+		}
+	}
+	return matched
+}
+
+// semver is a semantic version, optionally prefixed by v.
+type semver struct {
+	major, minor, patch int
+	pre                 []string
+}
+
+var semverRe = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?$`)
+
+// parseSemver returns nil if s is not a semantic version.
+func parseSemver(s string) *semver {
+	m := semverRe.FindStringSubmatch(s)
+	if m == nil {
+		return nil
+	}
+	v := &semver{}
+	v.major, _ = strconv.Atoi(m[1])
+	v.minor, _ = strconv.Atoi(m[2])
+	v.patch, _ = strconv.Atoi(m[3])
+	if m[4] != "" {
+		v.pre = strings.Split(m[4], ".")
+	}
+	return v
+}
+
+// less compares the versions by the precedence of semantic versioning, the
+// pre-releases are lower than their releases.
+func (v *semver) less(w *semver) bool {
+	if v.major != w.major {
+		return v.major < w.major
+	}
+	if v.minor != w.minor {
+		return v.minor < w.minor
+	}
+	if v.patch != w.patch {
+		return v.patch < w.patch
+	}
+	if len(v.pre) == 0 || len(w.pre) == 0 {
+		return len(v.pre) > len(w.pre)
+	}
+	for i := 0; i < len(v.pre) && i < len(w.pre); i++ {
+		a, b := v.pre[i], w.pre[i]
+		if a == b {
+			continue
+		}
+		x, errA := strconv.Atoi(a)
+		y, errB := strconv.Atoi(b)
+		switch {
+		case errA == nil && errB == nil:
+			return x < y
+		case errA == nil || errB == nil:
+			// numeric identifiers are lower than alphanumeric ones
+			return errA == nil
+		}
+		return a < b
+	}
+	return len(v.pre) < len(w.pre)
+}
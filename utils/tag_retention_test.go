@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+func TestTagRules(t *testing.T) {
+	rs, err := loadTagRules(filepath.Join("..", "conf", "tag_rules.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	var tags []*harbor.Tag
+	add := func(name string, days int) {
+		tags = append(tags, &harbor.Tag{
+			Name:    name,
+			Digest:  "sha256:" + name,
+			Created: now.Add(-time.Duration(days) * 24 * time.Hour).Format(time.RFC3339),
+		})
+	}
+	add("latest", 400)
+	for i := 1; i <= 7; i++ {
+		add(fmt.Sprintf("release-%d", i), 100-i)
+	}
+	for _, v := range []string{"1.0.0", "1.1.0", "1.2.0-beta.2", "1.2.0-beta.10", "1.2.0", "2.0.0", "v2.1.0-rc", "v0.1.0"} {
+		add(v, 50)
+	}
+	add("pr-1", 10)
+	add("pr-2", 3)
+	add("dev", 30)
+
+	want := map[string]int{
+		"latest":        1,
+		"release-7":     3,
+		"release-3":     3,
+		"release-2":     0,
+		"1.2.0":         4,
+		"1.2.0-beta.10": 4,
+		"1.2.0-beta.2":  4,
+		"1.1.0":         0,
+		"1.0.0":         0,
+		"2.0.0":         4,
+		"v2.1.0-rc":     4,
+		"v0.1.0":        2,
+		"pr-1":          5,
+		"pr-2":          0,
+		"dev":           0,
+	}
+	ds := rs.evaluate("prj/app", tags, now)
+	if len(ds) != len(tags) || ds[0].Tag != "pr-2" || ds[len(ds)-1].Tag != "latest" {
+		t.Fatalf("not ordered from the newest: %+v", ds)
+	}
+	for _, d := range ds {
+		rule, ok := want[d.Tag]
+		if !ok {
+			continue
+		}
+		if d.Rule != rule || d.delete != (rule == 5) || d.Action != "kept" || d.Reason == "" {
+			t.Errorf("%s: got rule %d delete %v (%s), want rule %d", d.Tag, d.Rule, d.delete, d.Reason, rule)
+		}
+	}
+	if got := ds[len(ds)-1].Reason; got != "rule 1: never delete latest" {
+		t.Errorf("latest: got %q", got)
+	}
+	if got := rs.Rules[2].String(); got != "keep the 5 newest matching ^release-" {
+		t.Errorf("rule 3: got %q", got)
+	}
+}
+
+func TestTagRulesBadCreated(t *testing.T) {
+	rs, err := loadTagRules(filepath.Join("..", "conf", "tag_rules.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the age of pr-2 is unknown, it must not be taken for older than 7 days
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	tags := []*harbor.Tag{
+		{Name: "pr-1", Digest: "sha256:1", Created: now.Add(-10 * 24 * time.Hour).Format(time.RFC3339)},
+		{Name: "pr-2", Digest: "sha256:2", Created: "2024-05-01 00:00:00"},
+		{Name: "pr-3", Digest: "sha256:3"},
+	}
+	for _, d := range rs.evaluate("prj/app", tags, now) {
+		switch d.Tag {
+		case "pr-1":
+			if !d.delete || d.Rule != 5 {
+				t.Errorf("%s: got rule %d delete %v (%s), want deleted by rule 5", d.Tag, d.Rule, d.delete, d.Reason)
+			}
+		default:
+			if d.delete || d.Rule != 0 || !strings.Contains(d.Reason, "creation time") {
+				t.Errorf("%s: got rule %d delete %v (%s), want kept", d.Tag, d.Rule, d.delete, d.Reason)
+			}
+		}
+	}
+}
+
+func TestSemver(t *testing.T) {
+	ordered := []string{"0.9.9", "1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "v1.0.0+build", "1.0.1", "1.10.0"}
+	for i := 0; i+1 < len(ordered); i++ {
+		v, w := parseSemver(ordered[i]), parseSemver(ordered[i+1])
+		if v == nil || w == nil || !v.less(w) || w.less(v) {
+			t.Errorf("%s < %s: got %v", ordered[i], ordered[i+1], v != nil && w != nil && v.less(w))
+		}
+	}
+	for _, s := range []string{"latest", "1.0", "01.0.0", "1.0.0-", "release-1.0.0"} {
+		if parseSemver(s) != nil {
+			t.Errorf("%s is not a semantic version", s)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/moooofly/harbor-go-client/harbor"
+)
+
+// tlsConfig returns the TLS settings of ctx. The certificate of the harbor
+// service is verified by the system roots, or by the CA file of ctx if set.
+func tlsConfig(ctx *Context) (*tls.Config, error) {
+	cfg := &tls.Config{InsecureSkipVerify: ctx.Insecure}
+
+	if ctx.CAFile != "" {
+		pemBytes, err := ioutil.ReadFile(ctx.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pemBytes) {
+			return nil, fmt.Errorf("no certificate found in %s", ctx.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if ctx.ClientCert != "" || ctx.ClientKey != "" {
+		if ctx.ClientCert == "" || ctx.ClientKey == "" {
+			return nil, Usagef("client certificate and key must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(ctx.ClientCert, ctx.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+type contextTrust struct {
+	FromAPI bool `long:"from-api" description:"Pin the registry root certificate returned by /api/systeminfo/getcert (admin only). The session is sent once the certificate presented by the server is confirmed, even with --yes."`
+	Yes     bool `short:"y" long:"yes" description:"Do not ask for confirmation."`
+	Args    struct {
+		Name string `positional-arg-name:"name" description:"The name of context."`
+	} `positional-args:"yes" required:"yes"`
+}
+
+var ctxTrust contextTrust
+
+func (x *contextTrust) Execute(args []string) error {
+	cc, err := contextConfigLoad()
+	if err != nil {
+		return err
+	}
+	_, ctx := cc.find(x.Args.Name)
+	if ctx == nil {
+		return fmt.Errorf("context %q not found", x.Args.Name)
+	}
+	if ctx.Scheme != "https" {
+		return Usagef("context %q does not use https", ctx.Name)
+	}
+
+	var cert *x509.Certificate
+	if x.FromAPI {
+		cert, err = fetchRootCert(ctx)
+	} else {
+		cert, err = fetchPeerCert(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	// shown with the question, even if stdout is redirected
+	fmt.Fprintf(os.Stderr, "Subject:     %s\n", cert.Subject)
+	fmt.Fprintf(os.Stderr, "Issuer:      %s\n", cert.Issuer)
+	fmt.Fprintf(os.Stderr, "Not After:   %s\n", cert.NotAfter.Format(time.RFC3339))
+	fmt.Fprintf(os.Stderr, "SHA-256:     %s\n", fingerprint(cert))
+
+	if !x.Yes {
+		ok, err := ask(fmt.Sprintf("Trust this certificate for context %q", ctx.Name))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("certificate of context %q is not trusted, use --yes to skip the confirmation: %w", ctx.Name, ErrAborted)
+		}
+	}
+
+	// kept with the contexts, not next to conf/config.yaml
+	dir, err := configDir()
+	if err != nil {
+		return err
+	}
+	path, err := filepath.Abs(filepath.Join(dir, "certs", ctx.Name+".crt"))
+	if err != nil {
+		return err
+	}
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	if err := writePrivateFile(path, pemBytes); err != nil {
+		return err
+	}
+
+	ctx.CAFile = path
+	ctx.Insecure = false
+	if err := contextConfigSave(cc); err != nil {
+		return err
+	}
+	fmt.Fprintf(Stdout, "Certificate pinned to %s for context %q.\n", path, ctx.Name)
+	return nil
+}
+
+// fetchPeerCert returns the top certificate of the chain presented by the
+// harbor service, which is not verified at all.
+func fetchPeerCert(ctx *Context) (*x509.Certificate, error) {
+	certs, err := peerCerts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return certs[len(certs)-1], nil
+}
+
+// peerCerts returns the chain presented by the harbor service, from the leaf,
+// which is not verified at all.
+func peerCerts(ctx *Context) ([]*x509.Certificate, error) {
+	addr := ctx.Dstip
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "443")
+	}
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 30 * time.Second}, "tcp", addr, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificate presented by %s", addr)
+	}
+	return certs, nil
+}
+
+// fetchRootCert downloads the registry root certificate, without verifying
+// the harbor service. As anyone in the middle could take the session of ctx,
+// it is sent only once the fingerprint of the certificate presented by the
+// service is confirmed (even with --yes), and only to the service presenting
+// that very certificate.
+func fetchRootCert(ctx *Context) (*x509.Certificate, error) {
+	cfg := &tls.Config{InsecureSkipVerify: true}
+	session, err := loadSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if session != nil {
+		certs, err := peerCerts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		leaf := certs[0]
+		fmt.Fprintf(os.Stderr, "The certificate presented by %s is not verified:\n", ctx.Dstip)
+		fmt.Fprintf(os.Stderr, "Subject:     %s\n", leaf.Subject)
+		fmt.Fprintf(os.Stderr, "SHA-256:     %s\n", fingerprint(leaf))
+		ok, err := ask(fmt.Sprintf("Send the session of context %q to it", ctx.Name))
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("session of context %q is not sent to an unverified server: %w", ctx.Name, ErrAborted)
+		}
+
+		cfg.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 || !bytes.Equal(rawCerts[0], leaf.Raw) {
+				return fmt.Errorf("the certificate presented by %s is not the one confirmed", ctx.Dstip)
+			}
+			return nil
+		}
+	}
+
+	hc := &http.Client{
+		Transport: newTransport(cfg),
+		Timeout:   Opts.Timeout,
+	}
+	c, err := harbor.NewClient(ctx.URL(), hc)
+	if err != nil {
+		return nil, err
+	}
+	if session != nil {
+		c.SessionID = session.ID
+	}
+
+	data, err := c.GetRootCert()
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no certificate returned by /api/systeminfo/getcert")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	hex := make([]string, len(sum))
+	for i, b := range sum {
+		hex[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(hex, ":")
+}
@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePEM writes the PEM blocks into a file of dir, and returns its path.
+func writePEM(t *testing.T, dir, name string, blocks ...*pem.Block) string {
+	t.Helper()
+	var data []byte
+	for _, b := range blocks {
+		data = append(data, pem.EncodeToMemory(b)...)
+	}
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTLSConfig(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+	cert := srv.TLS.Certificates[0]
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile := writePEM(t, dir, "cert.pem", &pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]})
+	keyFile := writePEM(t, dir, "key.pem", &pem.Block{Type: "PRIVATE KEY", Bytes: key})
+	emptyFile := writePEM(t, dir, "empty.pem")
+
+	cfg, err := tlsConfig(&Context{})
+	if err != nil || cfg.InsecureSkipVerify || cfg.RootCAs != nil || len(cfg.Certificates) != 0 {
+		t.Errorf("no settings: got %+v, %v, want the system roots", cfg, err)
+	}
+	if cfg, err := tlsConfig(&Context{Insecure: true}); err != nil || !cfg.InsecureSkipVerify {
+		t.Errorf("insecure: got %+v, %v", cfg, err)
+	}
+
+	// the CA file verifies the server
+	cfg, err = tlsConfig(&Context{CAFile: certFile})
+	if err != nil || cfg.RootCAs == nil {
+		t.Fatalf("CA file: got %+v, %v", cfg, err)
+	}
+	hc := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+	resp, err := hc.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("CA file: %v", err)
+	}
+	resp.Body.Close()
+	if _, err := (&http.Client{}).Get(srv.URL); err == nil {
+		t.Error("the system roots verify the test server")
+	}
+	if _, err := tlsConfig(&Context{CAFile: emptyFile}); err == nil || !strings.Contains(err.Error(), "no certificate") {
+		t.Errorf("CA file of no certificate: got %v", err)
+	}
+	if _, err := tlsConfig(&Context{CAFile: filepath.Join(dir, "missing.pem")}); err == nil {
+		t.Error("missing CA file: got no error")
+	}
+
+	// the client certificate and key go together
+	cfg, err = tlsConfig(&Context{ClientCert: certFile, ClientKey: keyFile})
+	if err != nil || len(cfg.Certificates) != 1 {
+		t.Errorf("client certificate: got %+v, %v", cfg, err)
+	}
+	for _, ctx := range []*Context{{ClientCert: certFile}, {ClientKey: keyFile}} {
+		if _, err := tlsConfig(ctx); ExitCode(err) != ExitUsage {
+			t.Errorf("%+v: got %v, want a usage error", ctx, err)
+		}
+	}
+	if _, err := tlsConfig(&Context{ClientCert: keyFile, ClientKey: certFile}); err == nil {
+		t.Error("swapped client certificate and key: got no error")
+	}
+}
+
+func TestFetchRootCert(t *testing.T) {
+	var requests []string
+	var srv *httptest.Server
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path+" "+r.Header.Get("Cookie"))
+		switch r.URL.Path {
+		case "/api/systeminfo":
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"harbor_version": "v1.8.0"}`)
+			return
+		case "/api/systeminfo/getcert":
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	}))
+	defer srv.Close()
+
+	t.Setenv(EnvConfig, filepath.Join(t.TempDir(), "config.yaml"))
+	t.Setenv(EnvSessionStore, SessionStoreFile)
+	ctx := &Context{Name: "dev", Scheme: "https", Dstip: strings.TrimPrefix(srv.URL, "https://")}
+	store, err := newSessionStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Store(ctx.sessionKey(), &Session{ID: "1234", Username: "admin"}); err != nil {
+		t.Fatal(err)
+	}
+	defer func(r io.Reader) { Stdin = r }(Stdin)
+
+	// not confirmed, nothing is sent
+	Stdin = strings.NewReader("n\n")
+	if _, err := fetchRootCert(ctx); !errors.Is(err, ErrAborted) || len(requests) != 0 {
+		t.Fatalf("declined: got %v, %d requests", err, len(requests))
+	}
+
+	Stdin = strings.NewReader("y\n")
+	cert, err := fetchRootCert(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(cert.Raw, srv.Certificate().Raw) {
+		t.Error("confirmed: got another certificate")
+	}
+	if len(requests) != 2 || requests[1] != "/api/systeminfo/getcert beegosessionID=1234" {
+		t.Errorf("confirmed: got requests %q", requests)
+	}
+
+	// without session, nothing is asked
+	if err := store.Erase(ctx.sessionKey()); err != nil {
+		t.Fatal(err)
+	}
+	Stdin = strings.NewReader("")
+	if _, err := fetchRootCert(ctx); err != nil || len(requests) != 4 || requests[3] != "/api/systeminfo/getcert " {
+		t.Errorf("no session: got %v, requests %q", err, requests)
+	}
+}
@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+)
+
+// commandGroups are the commands grouping the others by noun, e.g. the
+// commands of "project member" manage the members of projects.
+var commandGroups = map[string]struct{ short, long string }{
+	"project":            {"Manage projects.", "Manage projects, their members and metadata."},
+	"project member":     {"Manage members of a project.", "Manage the members of a project, and their roles."},
+	"project metadata":   {"Manage metadata of a project.", "Manage the metadata of a project, e.g. public and auto_scan."},
+	"repo":               {"Manage repositories.", "Manage repositories and their labels."},
+	"repo label":         {"Manage labels of a repository.", "Add, remove and list the labels of a repository."},
+	"tag":                {"Manage tags (images) of repositories.", "Manage tags (images) of repositories, scan them and show their manifests and vulnerabilities."},
+	"tag label":          {"Manage labels of a tag.", "Add, remove and list the labels of a tag (image)."},
+	"label":              {"Manage labels.", "Manage global and project labels."},
+	"replication":        {"Manage replication.", "Manage replication policies, jobs and targets."},
+	"replication policy": {"Manage replication policies.", "Manage replication policies, and trigger them."},
+	"replication job":    {"Manage replication jobs.", "List, stop and delete replication jobs, and show their logs."},
+	"replication target": {"Manage replication targets.", "Manage replication targets (endpoints), and ping them."},
+	"user":               {"Manage users.", "Manage users and user groups."},
+	"user group":         {"Manage user groups.", "Manage user groups (LDAP groups)."},
+	"system":             {"Show and configure the harbor system.", "Show system information, statistics and access logs, manage configurations and more."},
+	"system config":      {"Manage configurations.", "Show, update and reset the configurations of harbor."},
+	"rp":                 {"Run retention policies.", "Run retention policies on repositories and tags."},
+}
+
+// aliases maps the flat command names, which are used before the commands
+// are grouped into a tree, to the paths of the commands in the tree, e.g.
+// prj_get to "project get".
+var aliases = map[string]string{}
+
+// AddCommand adds the command to the command tree at path, e.g. "project
+// member get", adding the groups on the way if needed. alias is the flat name
+// of the command, e.g. prj_member_get, which is still accepted but hidden from
+// the help.
+func AddCommand(path, alias, short, long string, data interface{}) (*flags.Command, error) {
+	words := strings.Fields(path)
+	parent := Parser.Command
+	for i := range words[:len(words)-1] {
+		group := strings.Join(words[:i+1], " ")
+		cmd := parent.Find(words[i])
+		if cmd == nil {
+			desc, ok := commandGroups[group]
+			if !ok {
+				return nil, fmt.Errorf("unknown command group %q", group)
+			}
+			var err error
+			if cmd, err = parent.AddCommand(words[i], desc.short, desc.long, &struct{}{}); err != nil {
+				return nil, err
+			}
+		}
+		parent = cmd
+	}
+
+	cmd, err := parent.AddCommand(words[len(words)-1], short, long, data)
+	if err != nil {
+		return nil, err
+	}
+	if alias != "" {
+		aliases[alias] = path
+	}
+	return cmd, nil
+}
+
+// expandAlias replaces the flat command name in args, if any, by its path in
+// the command tree. Only the global options may come before the command.
+func expandAlias(args []string) []string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if strings.HasPrefix(arg, "-") {
+			if optionOf(nil, arg) != nil {
+				// the value of the global option
+				i++
+			}
+			continue
+		}
+
+		path, ok := aliases[arg]
+		if !ok {
+			break
+		}
+		ret := append([]string{}, args[:i]...)
+		ret = append(ret, strings.Fields(path)...)
+		return append(ret, args[i+1:]...)
+	}
+	return args
+}
+
+// expandArgs is expandAlias, but leaves the last argument alone when it is
+// being completed, so that a flat name is never completed.
+func expandArgs(args []string) []string {
+	n := len(args)
+	if os.Getenv("GO_FLAGS_COMPLETION") != "" && n > 0 {
+		n--
+	}
+	return append(append([]string{}, expandAlias(args[:n])...), args[n:]...)
+}
+
+// Parse parses os.Args by Parser, with the flat command names accepted.
+func Parse() ([]string, error) {
+	return ParseArgs(os.Args[1:])
+}
+
+// outputShorthand rewrites -o after the commands having their own -o, e.g.
+// --owner of prjs_list, into --output if its value is an output format, so
+// that both -o admin and -o table work as expected. The others are left to
+// the option of the command.
+func outputShorthand(args []string) []string {
+	var cmd *flags.Command
+	var ret []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(ret, args[i:]...)
+		}
+		if cmd != nil && cmd.FindOptionByShortName('o') != nil {
+			switch {
+			case arg == "-o" && i+1 < len(args) && isOutputFormat(args[i+1]):
+				ret = append(ret, "--output="+args[i+1])
+				i++
+				continue
+			case strings.HasPrefix(arg, "-o") && isOutputFormat(arg[2:]):
+				ret = append(ret, "--output="+arg[2:])
+				continue
+			}
+		}
+
+		parent := Parser.Command
+		if cmd != nil {
+			parent = cmd
+		}
+		if c := parent.Find(arg); c != nil {
+			cmd = c
+		}
+		ret = append(ret, arg)
+	}
+	return ret
+}
+
+// ParseArgs parses args by Parser, with the flat command names accepted.
+func ParseArgs(args []string) ([]string, error) {
+	return Parser.ParseArgs(outputShorthand(expandArgs(args)))
+}
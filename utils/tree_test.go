@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpandAlias(t *testing.T) {
+	tests := []struct {
+		args, want []string
+	}{
+		{[]string{"rp_tags", "-n", "library/busybox"}, []string{"rp", "tags", "-n", "library/busybox"}},
+		{[]string{"--dry-run", "-o", "yaml", "rp_repos"}, []string{"--dry-run", "-o", "yaml", "rp", "repos"}},
+		{[]string{"-oyaml", "--context=rp_tags", "rp_repos"}, []string{"-oyaml", "--context=rp_tags", "rp", "repos"}},
+		// the value of --context is not a command
+		{[]string{"--context", "rp_tags", "version"}, []string{"--context", "rp_tags", "version"}},
+		{[]string{"rp", "tags"}, []string{"rp", "tags"}},
+		{[]string{"context", "rp_tags"}, []string{"context", "rp_tags"}},
+		{[]string{"--", "rp_tags"}, []string{"--", "rp_tags"}},
+		{nil, nil},
+	}
+	for _, tt := range tests {
+		if got := expandAlias(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("expandAlias(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+
+	// the flat name being completed is left alone
+	t.Setenv("GO_FLAGS_COMPLETION", "1")
+	if got := expandArgs([]string{"rp_tags"}); !reflect.DeepEqual(got, []string{"rp_tags"}) {
+		t.Errorf("expandArgs while completing: got %q", got)
+	}
+	if got := expandArgs([]string{"rp_tags", "-n"}); !reflect.DeepEqual(got, []string{"rp", "tags", "-n"}) {
+		t.Errorf("expandArgs while completing: got %q", got)
+	}
+}
+
+func TestAddCommandUnknownGroup(t *testing.T) {
+	if _, err := AddCommand("nothing here", "", "", "", &struct{}{}); err == nil {
+		t.Error("AddCommand to an unknown group: got no error")
+	}
+	if Parser.Find("nothing") != nil {
+		t.Error("AddCommand added the unknown group")
+	}
+}
@@ -1,16 +1,13 @@
 package utils
 
 import (
-	"crypto/tls"
-	"errors"
-	"fmt"
 	"io/ioutil"
-	"net/http"
 	"os"
-	"strings"
+	"path/filepath"
+	"time"
 
 	"github.com/jessevdk/go-flags"
-	"github.com/parnurzeal/gorequest"
+	"github.com/moooofly/harbor-go-client/harbor"
 	yaml "gopkg.in/yaml.v2"
 )
 
@@ -24,130 +21,130 @@ var (
 	GitHash       = "unknown"
 )
 
-var errMalCookies = errors.New("get malformed cookies")
-var errCookiesNotAvailable = errors.New("target cookies are not available")
+// Options holds the options shared by all commands.
+type Options struct {
+	Context string `long:"context" description:"The name of context (server profile) to use, instead of the current one." complete:"context"`
+	Address string `long:"address" description:"The address (ip[:port] or hostname) of the harbor service, overrides all other settings."`
+	Scheme  string `long:"scheme" description:"The scheme of the harbor service, overrides all other settings." choice:"http" choice:"https"`
+	Output  string `short:"o" long:"output" description:"Output format, one of: json|yaml|table|jsonpath=<template>|go-template=<template>." default:"json"`
+	Columns string `long:"columns" description:"Comma separated fields to show as columns with '-o table', e.g. 'project_id,name'."`
+	Verbose bool   `long:"verbose" description:"Show the requests and responses on stderr."`
 
-// Parser is a command registry
-var Parser = flags.NewParser(nil, flags.Default)
+	CAFile     string `long:"ca-file" description:"The PEM file of CA certificates to verify the harbor service, overrides the one of context."`
+	ClientCert string `long:"client-cert" description:"The PEM file of client certificate, overrides the one of context."`
+	ClientKey  string `long:"client-key" description:"The PEM file of client private key, overrides the one of context."`
+	Insecure   bool   `long:"insecure" description:"Do not verify the certificate of the harbor service."`
 
-// Request is a new SuperAgent object with a setting of not verifying
-// server's certificate chain and host name.
-var Request = gorequest.New().TLSClientConfig(&tls.Config{InsecureSkipVerify: true})
+	Timeout time.Duration `long:"timeout" env:"HARBOR_TIMEOUT" description:"Timeout of every request, e.g. 30s or 2m, 0 means no timeout." default:"30s"`
+	Retries int           `long:"retries" env:"HARBOR_RETRIES" description:"How many times an idempotent request is retried after a network error or a 429/502/503/504 response." default:"3"`
 
-var configfile = "conf/config.yaml"
-var secretfile = "conf/.cookie.yaml"
+	DryRun    bool `long:"dry-run" description:"Print the requests which would change anything (with the session redacted) and an equivalent curl command line, instead of sending them. Read-only requests and login are still sent."`
+	PrintCurl bool `long:"print-curl" description:"The same as --dry-run, but print curl command lines only."`
 
-// Beegocookie is for beegosessionID storage
-type Beegocookie struct {
-	BeegosessionID string `yaml:"beegosessionID"`
+	Record string `long:"record" description:"Record every request and response into a cassette file, with the session and passwords redacted."`
+	Replay string `long:"replay" description:"Serve the responses from a cassette file written by --record, instead of the harbor service."`
 }
 
-type generalConfig struct {
-	Scheme string `yaml:"scheme"`
-	Dstip  string `yaml:"dstip"`
-}
+// Opts is filled by Parser with the global options.
+var Opts Options
+
+// Parser is a command registry. Errors are not printed by it, see main.go.
+var Parser = flags.NewParser(&Opts, flags.HelpFlag|flags.PassDoubleDash)
+
+// Environment variables which can be used instead of configuration files,
+// mostly for CI jobs. The ones of global options, e.g. $HARBOR_TIMEOUT of
+// --timeout, are given by the env tags of Options, and read by Parser only.
+const (
+	// EnvURL is the root URL of the harbor service, e.g. https://harbor.mydomain.com
+	EnvURL = "HARBOR_URL"
+	// EnvUsername is the username used by login.
+	EnvUsername = "HARBOR_USERNAME"
+	// EnvPassword is the password used by login.
+	EnvPassword = "HARBOR_PASSWORD"
+	// EnvConfig is the path of config.yaml, all the other files (contexts and
+	// sessions) are kept in the same directory.
+	EnvConfig = "HARBOR_CONFIG"
+	// EnvSessionStore overrides session_store of config.yaml.
+	EnvSessionStore = "HARBOR_SESSION_STORE"
+	// EnvSessionPassphrase is the passphrase of the encrypted session store.
+	EnvSessionPassphrase = "HARBOR_SESSION_PASSPHRASE"
+	// EnvExportPassphrase is the passphrase encrypting the secrets of export.
+	EnvExportPassphrase = "HARBOR_EXPORT_PASSPHRASE"
+)
 
-// SysConfig defines system configurations
-type SysConfig struct {
-	AuthMode                   string `yaml:"auth_mode" json:"auth_mode"`
-	EmailFrom                  string `yaml:"email_from" json:"email_from"`
-	EmailHost                  string `yaml:"email_host" json:"email_host"`
-	EmailPort                  int    `yaml:"email_port" json:"email_port"`
-	EmailIdentity              string `yaml:"email_identity" json:"email_identity"`
-	EmailUsername              string `yaml:"email_username" json:"email_username"`
-	EmailSsl                   bool   `yaml:"email_ssl" json:"email_ssl"`
-	EmailInsecure              bool   `yaml:"email_insecure" json:"email_insecure"`
-	LdapURL                    string `yaml:"ldap_url" json:"ldap_url"`
-	LdapBaseDN                 string `yaml:"ldap_base_dn" json:"ldap_base_dn"`
-	LdapFilter                 string `yaml:"ldap_filter" json:"ldap_filter"`
-	LdapScope                  int    `yaml:"ldap_scope" json:"ldap_scope"`
-	LdapUID                    string `yaml:"ldap_uid" jsonb:"ldap_uid"`
-	LdapSearchDN               string `yaml:"ldap_search_dn" json:"ldap_search_dn"`
-	LdapTimeout                int    `yaml:"ldap_timeout" json:"ldap_timeout"`
-	ProjectCreationRestriction string `yaml:"project_creation_restriction" json:"project_creation_restriction"`
-	SelfRegistration           bool   `yaml:"self_registration" json:"self_registration"`
-	TokenExpiration            int    `yaml:"token_expiration" json:"token_expiration"`
-	VerifyRemoteCert           bool   `yaml:"verify_remote_cert" json:"verify_remote_cert"`
-	ScanAllPolicy              struct {
-		Type      string `yaml:"type" json:"type"`
-		Parameter struct {
-			DailyTime int `yaml:"daily_time" json:"daily_time"`
-		} `yaml:"parameter" json:"parameter"`
-	} `yaml:"scan_all_policy" json:"scan_all_policy"`
-}
+var configfile = "conf/config.yaml"
 
-// cookieFilter filters specific cookie string.
-func cookieFilter(cookies []*http.Cookie, filter string) (string, error) {
-
-	for _, cookie := range cookies {
-		parts := strings.Split(strings.TrimSpace(cookie.String()), ";")
-
-		if len(parts) == 1 && parts[0] == "" {
-			return "", errMalCookies
-		}
-
-		for _, part := range parts {
-			part = strings.TrimSpace(part)
-			j := strings.Index(part, "=")
-			if j < 0 {
-				if part == filter {
-					return "", errMalCookies
-				}
-				fmt.Println("name=", part)
-				continue
-			}
-			name, value := part[:j], part[j+1:]
-			if 0 == strings.Compare(name, filter) {
-				return value, nil
-			}
-		}
+// configPath returns the path of config.yaml.
+func configPath() string {
+	if p := os.Getenv(EnvConfig); p != "" {
+		return p
 	}
-	return "", errCookiesNotAvailable
+	return configfile
 }
 
-// cookieSave saves beegosessionID into .cookie.yaml
-//
-// This function is called only in stage of login, and will reset the content of
-// .cookie.yaml no matter whether it exists or not.
-func cookieSave(beegosessionID string) error {
+// confPath returns the path of a file kept next to config.yaml.
+func confPath(name string) string {
+	return filepath.Join(filepath.Dir(configPath()), name)
+}
 
-	var cookie Beegocookie
-	cookie.BeegosessionID = beegosessionID
+// configDir returns the per-user directory keeping contexts, sessions and
+// trusted certificates, which is the directory of $HARBOR_CONFIG if it is set.
+func configDir() (string, error) {
+	if p := os.Getenv(EnvConfig); p != "" {
+		return filepath.Dir(p), nil
+	}
 
-	c, err := yaml.Marshal(&cookie)
+	dir, err := os.UserConfigDir()
 	if err != nil {
-		return err
+		return "", err
 	}
-	//fmt.Printf("--- c dump:\n%s\n\n", string(c))
+	return filepath.Join(dir, "harbor-go-client"), nil
+}
 
-	if err = ioutil.WriteFile(secretfile, []byte(c), 0644); err != nil {
+// writePrivateFile writes data into path readable by the owner only, creating
+// its directory if needed. The file is replaced at once, never left
+// half-written.
+func writePrivateFile(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
 		return err
 	}
 
-	return nil
-}
-
-// CookieLoad loads beegosessionID from .cookie.yaml.
-func CookieLoad() (*Beegocookie, error) {
-	var cookie Beegocookie
-
-	dataBytes, err := ioutil.ReadFile(secretfile)
+	// ioutil.TempFile creates the file with mode 0600
+	f, err := ioutil.TempFile(dir, "."+filepath.Base(path))
 	if err != nil {
-		return nil, err
+		return err
 	}
-	err = yaml.Unmarshal([]byte(dataBytes), &cookie)
-	if err != nil {
-		return nil, err
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
 	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
 
-	return &cookie, nil
+type generalConfig struct {
+	Scheme       string `yaml:"scheme"`
+	Dstip        string `yaml:"dstip"`
+	CAFile       string `yaml:"ca_file"`
+	ClientCert   string `yaml:"client_cert"`
+	ClientKey    string `yaml:"client_key"`
+	Insecure     bool   `yaml:"insecure"`
+	SessionStore string `yaml:"session_store"`
+
+	Protected protection `yaml:"protected"`
 }
 
 // SysConfigLoad loads system configuration from conf/config.yaml.
-func SysConfigLoad() (*SysConfig, error) {
-	var config SysConfig
+func SysConfigLoad() (*harbor.Configurations, error) {
+	var config harbor.Configurations
 
-	dataBytes, err := ioutil.ReadFile(configfile)
+	dataBytes, err := ioutil.ReadFile(configPath())
 	if err != nil {
 		return nil, err
 	}
@@ -164,7 +161,7 @@ func SysConfigLoad() (*SysConfig, error) {
 func generalConfigLoad() (*generalConfig, error) {
 	var config generalConfig
 
-	dataBytes, err := ioutil.ReadFile(configfile)
+	dataBytes, err := ioutil.ReadFile(configPath())
 	if err != nil {
 		return nil, err
 	}
@@ -175,74 +172,3 @@ func generalConfigLoad() (*generalConfig, error) {
 
 	return &config, nil
 }
-
-// URLGen generates target URL.
-func URLGen(uri string) string {
-	config, err := generalConfigLoad()
-	if err != nil {
-		fmt.Println("URLGen:", err)
-		os.Exit(1)
-	}
-	url := config.Scheme + "://" + config.Dstip + uri
-
-	return url
-}
-
-// LoginProc is the callback function for login.
-func LoginProc(resp gorequest.Response, body string, errs []error) {
-	for _, e := range errs {
-		if e != nil {
-			fmt.Println(e)
-			return
-		}
-	}
-
-	cookies := (*http.Response)(resp).Cookies()
-	fmt.Println("<== Cookies:", cookies)
-
-	sid, err := cookieFilter(cookies, "beegosessionID")
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-
-	// TODO: 根据状态码进行 .cookie.yaml 文件处理，以及用户友好提示
-	fmt.Println("<== Rsp Status:", resp.Status)
-	fmt.Println("<== Rsp Body:", body)
-
-	err = cookieSave(sid)
-	if err != nil {
-		fmt.Printf("Error: %v\n", err)
-		return
-	}
-}
-
-// LogoutProc is the callback function for logout.
-func LogoutProc(resp gorequest.Response, body string, errs []error) {
-	for _, e := range errs {
-		if e != nil {
-			fmt.Println(e)
-			return
-		}
-	}
-
-	fmt.Println("<== Rsp Cookies:", (*http.Response)(resp).Cookies())
-	fmt.Println("<== Rsp Status:", resp.Status)
-	fmt.Println("<== Rsp Body:", body)
-
-	os.Remove(secretfile)
-}
-
-// PrintStatus is a regular callback function.
-func PrintStatus(resp gorequest.Response, body string, errs []error) {
-	fmt.Println("<== ")
-	for _, e := range errs {
-		if e != nil {
-			fmt.Println(e)
-			return
-		}
-	}
-
-	fmt.Println("<== Rsp Status:", resp.Status)
-	fmt.Printf("<== Rsp Body: %s\n", body)
-}